@@ -0,0 +1,17 @@
+-- Data of a deployment created by the original db/init.sql, loaded on top of 0001_init
+-- before the upgrade is tested
+INSERT INTO users (id, name, phone, email, password_hash, google_id, is_verified, role_id) VALUES
+    (1, 'Admin', '+251911000001', 'admin@example.com', 'x', NULL, TRUE, (SELECT id FROM roles WHERE name = 'admin')),
+    (2, 'Manager', NULL, 'manager@example.com', 'x', NULL, FALSE, (SELECT id FROM roles WHERE name = 'admin')),
+    (3, 'Staff', '+251 91 100 0003', 'staff@example.com', 'x', NULL, TRUE, (SELECT id FROM roles WHERE name = 'staff')),
+    (4, 'New staff', NULL, 'staff2@example.com', 'x', NULL, FALSE, (SELECT id FROM roles WHERE name = 'staff')),
+    (5, 'Customer', '00251911000005', 'customer@gmail.com', 'x', 'google-sub-5', TRUE, (SELECT id FROM roles WHERE name = 'customer'));
+SELECT setval('users_id_seq', 5);
+
+INSERT INTO admin_roles (user_id, level) VALUES (1, 'admin'), (2, 'manager');
+INSERT INTO staff_roles (user_id, dept) VALUES (3, 'cashier'), (4, '');
+INSERT INTO customer_roles (user_id, loyalty_points) VALUES (5, 10);
+
+INSERT INTO otp_history (user_id, phone, code, status) VALUES
+    (5, '00251911000005', '123456', 'VERIFIED'),
+    (5, '00251911000005', '654321', 'SENT');
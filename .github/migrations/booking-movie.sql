@@ -0,0 +1,5 @@
+-- Data of a deployment created by the original db/init.sql, loaded on top of 0001_init
+-- before the upgrade is tested
+INSERT INTO bookings (id, user_id, schedule_id, total_amount, status) VALUES (1, 5, 1, 250, 'PAID');
+INSERT INTO booking_seats (booking_id, seat_number) VALUES (1, 'A1'), (1, 'A2');
+INSERT INTO booking_snacks (booking_id, schedule_snack_id, quantity, price) VALUES (1, 1, 1, 50);
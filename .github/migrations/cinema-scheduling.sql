@@ -0,0 +1,8 @@
+-- Data of a deployment created by the original db/init.sql, loaded on top of 0001_init
+-- before the upgrade is tested
+INSERT INTO genres (name) VALUES ('Drama');
+INSERT INTO halls (id, name, capacity, location) VALUES (1, 'Hall 1', 120, 'Bole');
+INSERT INTO movies (id, title, description, genres, duration, release_year) VALUES (1, 'Movie', 'About', ARRAY['Drama'], 120, 2024);
+INSERT INTO schedules (id, movie_id, hall_id, show_time, available_seats) VALUES (1, 1, 1, NOW() + INTERVAL '1 day', 120);
+INSERT INTO snacks (id, name, price) VALUES (1, 'Popcorn', 50);
+INSERT INTO schedule_snacks (schedule_id, snack_id) VALUES (1, 1);
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -41,22 +42,48 @@ func ensureClient() bool {
 	return true
 }
 
-// SaveOTP saves OTP with TTL
-func SaveOTP(userID int, phone, otp string, ttlMinutes int) error {
+// Ping checks that Redis answers (readiness probe)
+func Ping(c context.Context) error {
+	if rdb == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	return rdb.Ping(c).Err()
+}
+
+// OTPEntry is what's cached for a pending OTP: the otp_history row and the code's HMAC
+type OTPEntry struct {
+	RequestID int    `json:"request_id"`
+	Hash      string `json:"hash"`
+}
+
+// SaveOTP saves the hashed OTP with TTL
+func SaveOTP(userID int, phone string, entry OTPEntry, ttlMinutes int) error {
 	if !ensureClient() {
 		return fmt.Errorf("redis client not initialized")
 	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
 	key := fmt.Sprintf("otp:%d:%s", userID, phone)
-	return rdb.Set(ctx, key, otp, time.Duration(ttlMinutes)*time.Minute).Err()
+	return rdb.Set(ctx, key, data, time.Duration(ttlMinutes)*time.Minute).Err()
 }
 
-// GetOTP retrieves OTP
-func GetOTP(userID int, phone string) (string, error) {
+// GetOTP retrieves the pending OTP entry
+func GetOTP(userID int, phone string) (*OTPEntry, error) {
 	if !ensureClient() {
-		return "", fmt.Errorf("redis client not initialized")
+		return nil, fmt.Errorf("redis client not initialized")
 	}
 	key := fmt.Sprintf("otp:%d:%s", userID, phone)
-	return rdb.Get(ctx, key).Result()
+	data, err := rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	entry := &OTPEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
 }
 
 // DeleteOTP removes OTP
@@ -68,6 +95,26 @@ func DeleteOTP(userID int, phone string) error {
 	return rdb.Del(ctx, key).Err()
 }
 
+// DeleteUserOTPs removes every pending OTP of a user and their failed attempt counters,
+// whatever phone they were sent to
+func DeleteUserOTPs(userID int) error {
+	if !ensureClient() {
+		return fmt.Errorf("redis client not initialized")
+	}
+	for _, pattern := range []string{"otp:%d:*", "otp_failed:%d:*"} {
+		iter := rdb.Scan(ctx, 0, fmt.Sprintf(pattern, userID), 100).Iterator()
+		for iter.Next(ctx) {
+			if err := rdb.Del(ctx, iter.Val()).Err(); err != nil {
+				return err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CanRequestOTP checks if OTP was requested recently
 func CanRequestOTP(phone string, cooldown time.Duration) bool {
 	if !ensureClient() {
@@ -87,6 +134,16 @@ func CanRequestOTP(phone string, cooldown time.Duration) bool {
 	return true
 }
 
+// ResetOTPCooldown clears the request cooldown (e.g. after a failed delivery)
+func ResetOTPCooldown(phone string) {
+	if !ensureClient() {
+		return
+	}
+	if err := rdb.Del(ctx, fmt.Sprintf("otp_request:%s", phone)).Err(); err != nil {
+		log.Printf("⚠️ Redis DEL error in ResetOTPCooldown: %v", err)
+	}
+}
+
 // IncrementFailedOTP increments failed attempts counter
 func IncrementFailedOTP(userID int, phone string) (int64, error) {
 	if !ensureClient() {
@@ -107,3 +164,96 @@ func IncrementFailedOTP(userID int, phone string) (int64, error) {
 	}
 	return count, nil
 }
+
+// AllowRequest enforces a cooldown between requests of one kind for one target
+// (e.g. password reset emails per address). Fails open if Redis is down.
+func AllowRequest(kind, target string, cooldown time.Duration) bool {
+	if !ensureClient() {
+		return true
+	}
+
+	key := fmt.Sprintf("cooldown:%s:%s", kind, target)
+	ok, err := rdb.SetNX(ctx, key, "1", cooldown).Result()
+	if err != nil {
+		log.Printf("⚠️ Redis SETNX error in AllowRequest: %v", err)
+		return true
+	}
+	return ok
+}
+
+// AllowRequests allows at most limit requests of one kind for one target per window
+// (fixed window, counted in Redis). Fails open if Redis is down.
+func AllowRequests(kind, target string, limit int, window time.Duration) bool {
+	if !ensureClient() {
+		return true
+	}
+
+	key := fmt.Sprintf("ratelimit:%s:%s", kind, target)
+	count, err := rdb.Incr(ctx, key).Result()
+	if err != nil {
+		log.Printf("⚠️ Redis INCR error in AllowRequests: %v", err)
+		return true
+	}
+	if count == 1 {
+		if err := rdb.Expire(ctx, key, window).Err(); err != nil {
+			log.Printf("⚠️ Redis EXPIRE error in AllowRequests: %v", err)
+		}
+	}
+	return count <= int64(limit)
+}
+
+// ResetFailedOTP clears the failed attempts counter (new OTP issued or verified)
+func ResetFailedOTP(userID int, phone string) {
+	if !ensureClient() {
+		return
+	}
+	key := fmt.Sprintf("otp_failed:%d:%s", userID, phone)
+	if err := rdb.Del(ctx, key).Err(); err != nil {
+		log.Printf("⚠️ Redis DEL error in ResetFailedOTP: %v", err)
+	}
+}
+
+// GetOTPLockout returns how long a subject ("phone:<phone>" or "user:<id>") is still locked out of OTP
+func GetOTPLockout(subject string) (time.Duration, error) {
+	if !ensureClient() {
+		return 0, fmt.Errorf("redis client not initialized")
+	}
+	ttl, err := rdb.TTL(ctx, "otp_lock:"+subject).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// LockOTP locks a subject out of OTP with exponential backoff:
+// base, 2×base, 4×base, ... capped at max. The backoff level resets after a day without lockouts.
+func LockOTP(subject string, base, max time.Duration) (time.Duration, error) {
+	if !ensureClient() {
+		return 0, fmt.Errorf("redis client not initialized")
+	}
+
+	levelKey := "otp_lock_level:" + subject
+	level, err := rdb.Incr(ctx, levelKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if err := rdb.Expire(ctx, levelKey, 24*time.Hour).Err(); err != nil {
+		log.Printf("⚠️ Redis EXPIRE error: %v", err)
+	}
+
+	duration := base
+	for i := int64(1); i < level && duration < max; i++ {
+		duration *= 2
+	}
+	if duration > max {
+		duration = max
+	}
+
+	if err := rdb.Set(ctx, "otp_lock:"+subject, "1", duration).Err(); err != nil {
+		return 0, err
+	}
+	return duration, nil
+}
@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"cinema-shared/auth"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	revokedTokensKey   = "revoked_tokens"   // sorted set: jti → exp (unix)
+	revokedUsersKey    = "revoked_users"    // hash: user_id → revoked-before (unix)
+	revokedSessionsKey = "revoked_sessions" // hash: sid → revoked at (unix)
+)
+
+// RevocationList is the snapshot shared with the other services
+type RevocationList = auth.RevocationList
+
+// Revocations checks tokens against Redis (auth.RevocationChecker)
+type Revocations struct{}
+
+// IsRevoked rejects tokens on the denylist, of revoked sessions, or issued up to a "log out everywhere"
+func (Revocations) IsRevoked(c *auth.Claims) (bool, error) {
+	if c.ID != "" {
+		revoked, err := IsTokenRevoked(c.ID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	if c.SessionID != "" {
+		revoked, err := IsSessionRevoked(c.SessionID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	revokedBefore, err := GetUserRevokedBefore(c.UserID)
+	if err != nil {
+		return false, err
+	}
+	// iat has whole seconds: a token issued in the cutoff's second may predate it
+	return revokedBefore > 0 && c.IssuedAtUnix() <= revokedBefore, nil
+}
+
+// RevokeToken puts a token's jti on the denylist until the token expires
+func RevokeToken(jti string, expiresAt time.Time) error {
+	if !ensureClient() {
+		return fmt.Errorf("redis client not initialized")
+	}
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil // nothing to revoke, token is already dead
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.Set(ctx, "revoked:"+jti, "1", ttl)
+	pipe.ZAdd(ctx, revokedTokensKey, &redis.Z{Score: float64(expiresAt.Unix()), Member: jti})
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IsTokenRevoked checks the denylist for a jti
+func IsTokenRevoked(jti string) (bool, error) {
+	if !ensureClient() {
+		return false, fmt.Errorf("redis client not initialized")
+	}
+	n, err := rdb.Exists(ctx, "revoked:"+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RevokeAllUserTokens revokes every token issued to a user up to now, this second included
+// ("log out of all devices")
+func RevokeAllUserTokens(userID int, maxTokenTTL time.Duration) error {
+	if !ensureClient() {
+		return fmt.Errorf("redis client not initialized")
+	}
+	now := time.Now().Unix()
+	id := strconv.Itoa(userID)
+
+	pipe := rdb.TxPipeline()
+	pipe.Set(ctx, "revoked_user:"+id, now, maxTokenTTL)
+	pipe.HSet(ctx, revokedUsersKey, id, now)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetUserRevokedBefore returns the cutoff for a user (0 if none)
+func GetUserRevokedBefore(userID int) (int64, error) {
+	if !ensureClient() {
+		return 0, fmt.Errorf("redis client not initialized")
+	}
+	val, err := rdb.Get(ctx, "revoked_user:"+strconv.Itoa(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return val, err
+}
+
+// RevokeSessions marks sessions as revoked so their tokens stop working everywhere
+func RevokeSessions(ids []string, maxTokenTTL time.Duration) error {
+	if !ensureClient() {
+		return fmt.Errorf("redis client not initialized")
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	now := time.Now().Unix()
+
+	pipe := rdb.TxPipeline()
+	for _, id := range ids {
+		pipe.Set(ctx, "revoked_session:"+id, now, maxTokenTTL)
+		pipe.HSet(ctx, revokedSessionsKey, id, now)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IsSessionRevoked checks whether a session has been revoked
+func IsSessionRevoked(sid string) (bool, error) {
+	if !ensureClient() {
+		return false, fmt.Errorf("redis client not initialized")
+	}
+	n, err := rdb.Exists(ctx, "revoked_session:"+sid).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetRevocationList returns all live revocations, pruning expired entries
+func GetRevocationList(maxTokenTTL time.Duration) (*RevocationList, error) {
+	if !ensureClient() {
+		return nil, fmt.Errorf("redis client not initialized")
+	}
+	now := time.Now().Unix()
+
+	// Drop jtis whose tokens have expired anyway
+	if err := rdb.ZRemRangeByScore(ctx, revokedTokensKey, "-inf", strconv.FormatInt(now, 10)).Err(); err != nil {
+		return nil, err
+	}
+	tokens, err := rdb.ZRangeWithScores(ctx, revokedTokensKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := rdb.HGetAll(ctx, revokedUsersKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := rdb.HGetAll(ctx, revokedSessionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	list := &RevocationList{
+		Tokens:   make(map[string]int64, len(tokens)),
+		Users:    make(map[string]int64, len(users)),
+		Sessions: make(map[string]int64, len(sessions)),
+	}
+	for _, z := range tokens {
+		if jti, ok := z.Member.(string); ok {
+			list.Tokens[jti] = int64(z.Score)
+		}
+	}
+
+	cutoff := now - int64(maxTokenTTL.Seconds())
+	for id, val := range users {
+		ts, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || ts < cutoff {
+			// Every token issued before this has expired
+			rdb.HDel(ctx, revokedUsersKey, id)
+			continue
+		}
+		list.Users[id] = ts
+	}
+	for id, val := range sessions {
+		ts, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || ts < cutoff {
+			rdb.HDel(ctx, revokedSessionsKey, id)
+			continue
+		}
+		list.Sessions[id] = ts
+	}
+	return list, nil
+}
@@ -0,0 +1,163 @@
+// Command mockoidc is a tiny OpenID Connect provider for local development and tests.
+// It signs ID tokens for whatever identity the caller asks for:
+//
+//	go run ./cmd/mockoidc -addr :9099
+//	curl 'http://localhost:9099/token?sub=alice&email=alice@example.com&email_verified=true&aud=cinema'
+//
+// Register it with OIDC_PROVIDERS=mock, OIDC_MOCK_ISSUER=http://localhost:9099, OIDC_MOCK_CLIENT_IDS=cinema.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mockoidc"
+
+var (
+	signingKey *rsa.PrivateKey
+	issuer     string
+
+	// access token → userinfo claims (for OIDC_<NAME>_TYPE=oauth2)
+	mu          sync.Mutex
+	accessUsers = map[string]map[string]interface{}{}
+)
+
+func main() {
+	addr := flag.String("addr", ":9099", "listen address")
+	flag.StringVar(&issuer, "issuer", "", "issuer URL (default http://localhost<addr>)")
+	flag.Parse()
+	if issuer == "" {
+		issuer = "http://localhost" + *addr
+		if !strings.HasPrefix(*addr, ":") {
+			issuer = "http://" + *addr
+		}
+	}
+
+	var err error
+	signingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("❌ Failed to generate signing key: %v", err)
+	}
+
+	http.HandleFunc("/.well-known/openid-configuration", discovery)
+	http.HandleFunc("/jwks", jwks)
+	http.HandleFunc("/token", token)
+	http.HandleFunc("/userinfo", userInfo)
+
+	log.Printf("🚀 Mock OIDC provider %s listening on %s", issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                issuer,
+		"jwks_uri":                              issuer + "/jwks",
+		"token_endpoint":                        issuer + "/token",
+		"userinfo_endpoint":                     issuer + "/userinfo",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func jwks(w http.ResponseWriter, r *http.Request) {
+	pub := signingKey.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// token issues an ID token and an access token for the identity in the query:
+// sub (required), email, email_verified, name, aud (default "cinema"), nonce
+func token(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sub := q.Get("sub")
+	if sub == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "sub is required"})
+		return
+	}
+	aud := q.Get("aud")
+	if aud == "" {
+		aud = "cinema"
+	}
+
+	profile := map[string]interface{}{"sub": sub}
+	if v := q.Get("email"); v != "" {
+		profile["email"] = v
+		profile["email_verified"] = q.Get("email_verified") == "true"
+	}
+	if v := q.Get("name"); v != "" {
+		profile["name"] = v
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": issuer,
+		"aud": aud,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for k, v := range profile {
+		claims[k] = v
+	}
+	if v := q.Get("nonce"); v != "" {
+		claims["nonce"] = v
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = keyID
+	idToken, err := t.SignedString(signingKey)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	raw := make([]byte, 24)
+	_, _ = rand.Read(raw)
+	accessToken := hex.EncodeToString(raw)
+	mu.Lock()
+	accessUsers[accessToken] = profile
+	mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id_token":     idToken,
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+func userInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	mu.Lock()
+	profile, ok := accessUsers[accessToken]
+	mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,12 +19,88 @@ type Config struct {
 	DBPassword     string
 	DBName         string
 	PostgresURL    string
-	JWTSecret      string
+	DBAutoMigrate  bool          // apply pending migrations at startup
+	ShutdownDrain  time.Duration // readiness fails this long before the server stops on SIGTERM
 	JWTExpiryHours int
+	OTPHMACKey     string
+
+	// OTP brute-force protection
+	OTPMaxAttempts int
+	OTPLockoutBase time.Duration
+	OTPLockoutMax  time.Duration
+
+	// Public phone login: codes sent per number and per client IP per hour
+	PhoneLoginMaxPerPhone int
+	PhoneLoginMaxPerIP    int
+
+	// Region (ISO 3166-1 alpha-2) national phone numbers are read in; empty = international only
+	PhoneDefaultRegion string
+
+	// Password login brute-force protection
+	LoginDelayAfter    int           // failures before progressive delays start
+	LoginMaxFailures   int           // failures per account before a lockout
+	LoginIPMaxFailures int           // failures per IP before a lockout
+	LoginFailureWindow time.Duration // counters reset after this long without failures
+	LoginLockout       time.Duration
+
+	// TOTP two-factor authentication
+	TOTPIssuer string
+
+	// Secrets at rest (TOTP seeds, JWT signing keys)
+	SecretsEncryptionKey string
+
+	// JWT signing key rotation
+	JWTKeyRotation time.Duration // age at which the active signing key is replaced
+	JWTKeyOverlap  time.Duration // retired keys stay published (JWKS) this long
+
+	// Passwordless email login
+	MagicLinkTTL        time.Duration
+	MagicLinkMaxPerHour int // links emailed per address per hour (plus one per minute)
+
+	// Personal data: deleted accounts are anonymized after this grace period
+	AccountDeletionGrace time.Duration
+	// Audit events older than this are pruned (0 keeps them forever)
+	AuditRetention time.Duration
+	// Service-to-service calls (bookings for data exports)
+	BookingServiceURL string
+	InternalAPIKey    string
+
+	// External login providers (OpenID Connect or OAuth2), see OIDC_PROVIDERS
+	OIDCProviders []OIDCProvider
+
 	GoogleClientID string
 	RedisHost      string
 	RedisPort      string
 	RedisPassword  string
+	AppBaseURL     string
+	MailDriver     string
+	MailFrom       string
+	SMTPHost       string
+	SMTPPort       string
+	SMTPUsername   string
+	SMTPPassword   string
+
+	// SMS delivery (OTP)
+	SMSProvider      string
+	SMSLogFile       string
+	TwilioBaseURL    string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFrom       string
+	ATBaseURL        string
+	ATUsername       string
+	ATAPIKey         string
+	ATSenderID       string
+}
+
+// OIDCProvider configures one external login provider
+type OIDCProvider struct {
+	Name        string   // used in URLs and user_identities.provider, e.g. "google"
+	Type        string   // "oidc" (ID token checked via discovery + JWKS) or "oauth2" (access token sent to UserInfoURL)
+	Issuer      string   // oidc: discovery at <Issuer>/.well-known/openid-configuration
+	ClientIDs   []string // oidc: accepted ID token audiences
+	UserInfoURL string   // oauth2: returns the user for a bearer access token
+	AppSecret   string   // oauth2, required: sent as appsecret_proof (Facebook Graph API)
 }
 
 // LoadConfig reads environment variables and returns a Config struct
@@ -37,11 +116,52 @@ func LoadConfig() *Config {
 	dbUser := getEnv("DB_USER", "postgres")
 	dbPassword := getEnv("DB_PASSWORD", "")
 	dbName := getEnv("DB_NAME", "cinema_auth")
-	jwtSecret := getEnv("JWT_SECRET", "secret")
+	otpHMACKey := getEnv("OTP_HMAC_KEY", "")
+	otpMaxAttempts := getEnvInt("OTP_MAX_ATTEMPTS", 5)
+	otpLockoutBase := getEnvInt("OTP_LOCKOUT_BASE_SECONDS", 60)
+	otpLockoutMax := getEnvInt("OTP_LOCKOUT_MAX_SECONDS", 3600)
+	loginDelayAfter := getEnvInt("LOGIN_DELAY_AFTER_FAILURES", 3)
+	loginMaxFailures := getEnvInt("LOGIN_MAX_FAILURES", 10)
+	loginIPMaxFailures := getEnvInt("LOGIN_IP_MAX_FAILURES", 50)
+	loginFailureWindow := getEnvInt("LOGIN_FAILURE_WINDOW_SECONDS", 900)
+	loginLockout := getEnvInt("LOGIN_LOCKOUT_SECONDS", 900)
+	totpIssuer := getEnv("TOTP_ISSUER", "Cinema")
+	secretsEncryptionKey := getEnv("SECRETS_ENCRYPTION_KEY", "")
+	jwtKeyRotationDays := getEnvInt("JWT_KEY_ROTATION_DAYS", 30)
+	jwtKeyOverlapHours := getEnvInt("JWT_KEY_OVERLAP_HOURS", 8*24)
+	phoneLoginMaxPerPhone := getEnvInt("PHONE_LOGIN_MAX_PER_PHONE_HOUR", 5)
+	phoneLoginMaxPerIP := getEnvInt("PHONE_LOGIN_MAX_PER_IP_HOUR", 20)
+	phoneDefaultRegion := getEnv("PHONE_DEFAULT_REGION", "")
+	dbAutoMigrate := getEnv("DB_AUTO_MIGRATE", "true") != "false"
+	shutdownDrainSeconds := getEnvInt("SHUTDOWN_DRAIN_SECONDS", 5)
+	magicLinkTTLMinutes := getEnvInt("MAGIC_LINK_TTL_MINUTES", 15)
+	magicLinkMaxPerHour := getEnvInt("MAGIC_LINK_MAX_PER_HOUR", 5)
+	accountDeletionGraceDays := getEnvInt("ACCOUNT_DELETION_GRACE_DAYS", 30)
+	auditRetentionDays := getEnvInt("AUDIT_RETENTION_DAYS", 365)
+	bookingServiceURL := getEnv("BOOKING_SERVICE_URL", "")
+	internalAPIKey := getEnv("INTERNAL_API_KEY", "")
 	googleClientID := getEnv("GOOGLE_CLIENT_ID", "")
+	oidcProviders := loadOIDCProviders(googleClientID)
 	redisHost := getEnv("REDIS_HOST", "localhost")
 	redisPort := getEnv("REDIS_PORT", "6379")
 	redisPassword := getEnv("REDIS_PASSWORD", "")
+	appBaseURL := getEnv("APP_BASE_URL", "http://localhost:3000")
+	mailDriver := getEnv("MAIL_DRIVER", "log")
+	mailFrom := getEnv("MAIL_FROM", "no-reply@cinema.local")
+	smtpHost := getEnv("SMTP_HOST", "")
+	smtpPort := getEnv("SMTP_PORT", "587")
+	smtpUsername := getEnv("SMTP_USERNAME", "")
+	smtpPassword := getEnv("SMTP_PASSWORD", "")
+	smsProvider := getEnv("SMS_PROVIDER", "log")
+	smsLogFile := getEnv("SMS_LOG_FILE", "")
+	twilioBaseURL := getEnv("TWILIO_BASE_URL", "https://api.twilio.com")
+	twilioAccountSID := getEnv("TWILIO_ACCOUNT_SID", "")
+	twilioAuthToken := getEnv("TWILIO_AUTH_TOKEN", "")
+	twilioFrom := getEnv("TWILIO_FROM", "")
+	atBaseURL := getEnv("AT_BASE_URL", "https://api.africastalking.com")
+	atUsername := getEnv("AT_USERNAME", "")
+	atAPIKey := getEnv("AT_API_KEY", "")
+	atSenderID := getEnv("AT_SENDER_ID", "")
 
 	// Build Postgres URL
 	postgresURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
@@ -56,13 +176,114 @@ func LoadConfig() *Config {
 		DBPassword:     dbPassword,
 		DBName:         dbName,
 		PostgresURL:    postgresURL,
-		JWTSecret:      jwtSecret,
+		DBAutoMigrate:  dbAutoMigrate,
+		ShutdownDrain:  time.Duration(shutdownDrainSeconds) * time.Second,
 		JWTExpiryHours: 72,
+		OTPHMACKey:     otpHMACKey,
+		OTPMaxAttempts: otpMaxAttempts,
+		OTPLockoutBase: time.Duration(otpLockoutBase) * time.Second,
+		OTPLockoutMax:  time.Duration(otpLockoutMax) * time.Second,
+
+		PhoneLoginMaxPerPhone: phoneLoginMaxPerPhone,
+		PhoneLoginMaxPerIP:    phoneLoginMaxPerIP,
+
+		PhoneDefaultRegion: phoneDefaultRegion,
+
+		LoginDelayAfter:    loginDelayAfter,
+		LoginMaxFailures:   loginMaxFailures,
+		LoginIPMaxFailures: loginIPMaxFailures,
+		LoginFailureWindow: time.Duration(loginFailureWindow) * time.Second,
+		LoginLockout:       time.Duration(loginLockout) * time.Second,
+
+		TOTPIssuer: totpIssuer,
+
+		SecretsEncryptionKey: secretsEncryptionKey,
+
+		JWTKeyRotation: time.Duration(jwtKeyRotationDays) * 24 * time.Hour,
+		JWTKeyOverlap:  time.Duration(jwtKeyOverlapHours) * time.Hour,
+
+		MagicLinkTTL:        time.Duration(magicLinkTTLMinutes) * time.Minute,
+		MagicLinkMaxPerHour: magicLinkMaxPerHour,
+
+		AccountDeletionGrace: time.Duration(accountDeletionGraceDays) * 24 * time.Hour,
+		AuditRetention:       time.Duration(auditRetentionDays) * 24 * time.Hour,
+		BookingServiceURL:    bookingServiceURL,
+		InternalAPIKey:       internalAPIKey,
+
+		OIDCProviders: oidcProviders,
+
 		GoogleClientID: googleClientID,
 		RedisHost:      redisHost,
 		RedisPort:      redisPort,
 		RedisPassword:  redisPassword,
+		AppBaseURL:     appBaseURL,
+		MailDriver:     mailDriver,
+		MailFrom:       mailFrom,
+		SMTPHost:       smtpHost,
+		SMTPPort:       smtpPort,
+		SMTPUsername:   smtpUsername,
+		SMTPPassword:   smtpPassword,
+
+		SMSProvider:      smsProvider,
+		SMSLogFile:       smsLogFile,
+		TwilioBaseURL:    twilioBaseURL,
+		TwilioAccountSID: twilioAccountSID,
+		TwilioAuthToken:  twilioAuthToken,
+		TwilioFrom:       twilioFrom,
+		ATBaseURL:        atBaseURL,
+		ATUsername:       atUsername,
+		ATAPIKey:         atAPIKey,
+		ATSenderID:       atSenderID,
+	}
+}
+
+// loadOIDCProviders reads OIDC_PROVIDERS (comma-separated names) and OIDC_<NAME>_* for each.
+// GOOGLE_CLIENT_ID alone still enables Google.
+func loadOIDCProviders(googleClientID string) []OIDCProvider {
+	var providers []OIDCProvider
+	hasGoogle := false
+	for _, name := range splitList(getEnv("OIDC_PROVIDERS", "")) {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		p := OIDCProvider{
+			Name:        name,
+			Type:        getEnv(prefix+"TYPE", "oidc"),
+			Issuer:      strings.TrimRight(getEnv(prefix+"ISSUER", ""), "/"),
+			ClientIDs:   splitList(getEnv(prefix+"CLIENT_IDS", "")),
+			UserInfoURL: getEnv(prefix+"USERINFO_URL", ""),
+			AppSecret:   getEnv(prefix+"APP_SECRET", ""),
+		}
+		if name == "google" {
+			hasGoogle = true
+			if p.Issuer == "" {
+				p.Issuer = "https://accounts.google.com"
+			}
+			if len(p.ClientIDs) == 0 && googleClientID != "" {
+				p.ClientIDs = []string{googleClientID}
+			}
+		}
+		providers = append(providers, p)
 	}
+	if !hasGoogle && googleClientID != "" {
+		providers = append(providers, OIDCProvider{
+			Name:      "google",
+			Type:      "oidc",
+			Issuer:    "https://accounts.google.com",
+			ClientIDs: []string{googleClientID},
+		})
+	}
+	return providers
+}
+
+// splitList splits a comma-separated value, dropping blanks
+func splitList(val string) []string {
+	var out []string
+	for _, part := range strings.Split(val, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
 }
 
 // getEnv returns env variable or fallback value
@@ -72,3 +293,14 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvInt returns an integer env variable or fallback value
+func getEnvInt(key string, fallback int) int {
+	if val := os.Getenv(key); val != "" {
+		if v, err := strconv.Atoi(val); err == nil {
+			return v
+		}
+		log.Printf("⚠️ Invalid integer for %s=%q, using %d", key, val, fallback)
+	}
+	return fallback
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"auth-backend/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reassignUserBookings asks booking-movie to move the bookings of one user to another and returns
+// the moved booking IDs. ids limits the move to those bookings; nil moves them all.
+func reassignUserBookings(fromUserID, toUserID int, ids []int) ([]int, error) {
+	if settings.BookingServiceURL == "" {
+		return nil, errors.New("BOOKING_SERVICE_URL not set")
+	}
+	payload := map[string]interface{}{"to_user_id": toUserID}
+	if ids != nil {
+		payload["booking_ids"] = ids
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("%s/api/internal/users/%d/bookings/reassign", strings.TrimRight(settings.BookingServiceURL, "/"), fromUserID),
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Key", settings.InternalAPIKey)
+
+	resp, err := bookingClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("booking service: status %d: %s", resp.StatusCode, msg)
+	}
+
+	var out struct {
+		BookingIDs []int `json:"booking_ids"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	if out.BookingIDs == nil {
+		out.BookingIDs = []int{}
+	}
+	return out.BookingIDs, nil
+}
+
+// restoreBookings moves bookings moved for a merge that then failed back to the source account
+func restoreBookings(sourceID, targetID int, ids []int) {
+	if len(ids) == 0 {
+		return
+	}
+	back, err := reassignUserBookings(targetID, sourceID, ids)
+	if err != nil || len(back) != len(ids) {
+		log.Printf("🚨 [MergeUsers] Failed to move %d bookings back from user %d to %d (%d moved back): %v; booking IDs: %v",
+			len(ids), targetID, sourceID, len(back), err, ids)
+		return
+	}
+	log.Printf("↩️ [MergeUsers] Moved %d bookings back from user %d to %d", len(back), targetID, sourceID)
+}
+
+// ---------------- MergeUsers ----------------
+// Merges a duplicate account (source_user_id) into the account in the URL and deletes it.
+// Bookings are moved in booking-movie first, and moved back if the merge then fails;
+// the target keeps its credentials, role and 2FA.
+func MergeUsers(c *gin.Context) {
+	var req struct {
+		SourceUserID int `json:"source_user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	if req.SourceUserID == target.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge an account into itself"})
+		return
+	}
+	source, err := models.GetUserByID(req.SourceUserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+	if source == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Source user not found"})
+		return
+	}
+
+	if target.AnonymizedAt != nil || source.AnonymizedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Anonymized accounts cannot be merged"})
+		return
+	}
+	if target.Role != source.Role {
+		c.JSON(http.StatusConflict, gin.H{"error": "Both accounts must have the same role"})
+		return
+	}
+	// Includes the last-admin check, before anything changes in booking-movie
+	if !guardTargetUser(c, target, false) || !guardTargetUser(c, source, true) {
+		return
+	}
+
+	movedIDs, err := reassignUserBookings(source.ID, target.ID, nil)
+	if err != nil {
+		log.Printf("❌ [MergeUsers] Failed to move bookings of user %d to %d: %v", source.ID, target.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to move bookings, nothing was merged"})
+		return
+	}
+	moved := len(movedIDs)
+
+	// Revoke while the session rows still exist
+	if err := revokeSessions(source.ID, ""); err != nil {
+		log.Printf("⚠️ [MergeUsers] Failed to revoke sessions of user %d: %v", source.ID, err)
+	}
+
+	adminID := c.GetInt("user_id")
+	result, err := models.MergeUsers(target, source, &models.AccountMerge{
+		Kind:         models.MergeAdmin,
+		TargetUserID: target.ID,
+		SourceUserID: &source.ID,
+		ActorID:      &adminID,
+		IP:           c.ClientIP(),
+		Details: map[string]interface{}{
+			"bookings_moved": moved,
+			"source_email":   source.Email,
+			"source_phone":   source.PhoneNumber,
+		},
+	})
+	if err != nil {
+		// Nothing was merged: the source keeps its bookings
+		restoreBookings(source.ID, target.ID, movedIDs)
+		if errors.Is(err, models.ErrLastAdmin) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last active admin"})
+			return
+		}
+		log.Printf("❌ [MergeUsers] Failed to merge user %d into %d: %v", source.ID, target.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge accounts"})
+		return
+	}
+
+	recordAdminAction(c, models.EventAccountsMerged, target.ID, map[string]interface{}{
+		"source_user_id": source.ID,
+		"bookings_moved": moved,
+	})
+	log.Printf("✅ [MergeUsers] User %d merged into %d by %d", source.ID, target.ID, adminID)
+
+	response := gin.H{"message": "Accounts merged", "bookings_moved": moved, "merge": result}
+	if merged, err := models.GetUserWithExtra(target.ID); err == nil && merged != nil {
+		response["user"] = adminUserView(&merged.User, roleExtraOf(merged))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ---------------- ListUserMerges ----------------
+// Identity links and account merges involving a user
+func ListUserMerges(c *gin.Context) {
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	merges, err := models.ListAccountMerges(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account merges"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"merges": merges})
+}
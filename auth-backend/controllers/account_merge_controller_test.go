@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// reassignCall is one request auth-backend made to booking-movie's reassign endpoint
+type reassignCall struct {
+	Path       string
+	ToUserID   int   `json:"to_user_id"`
+	BookingIDs []int `json:"booking_ids"`
+}
+
+// fakeBookingService answers reassign calls with respond(call) and records them
+func fakeBookingService(t *testing.T, respond func(call reassignCall) (int, []int)) *[]reassignCall {
+	t.Helper()
+	calls := &[]reassignCall{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Internal-Key") != "internal" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		call := reassignCall{Path: r.URL.Path}
+		json.NewDecoder(r.Body).Decode(&call)
+		*calls = append(*calls, call)
+
+		status, ids := respond(call)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"moved": len(ids), "booking_ids": ids})
+	}))
+	t.Cleanup(srv.Close)
+
+	saved := settings
+	cfg := *settings
+	cfg.BookingServiceURL, cfg.InternalAPIKey = srv.URL, "internal"
+	settings = &cfg
+	t.Cleanup(func() { settings = saved })
+	return calls
+}
+
+func TestReassignUserBookings(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []int
+		status  int
+		reply   []int
+		want    []int
+		wantErr bool
+	}{
+		{"every booking", nil, http.StatusOK, []int{11, 12}, []int{11, 12}, false},
+		{"no bookings", nil, http.StatusOK, nil, []int{}, false},
+		{"only the given bookings", []int{12}, http.StatusOK, []int{12}, []int{12}, false},
+		{"booking service fails", nil, http.StatusInternalServerError, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakeBookingService(t, func(reassignCall) (int, []int) { return tt.status, tt.reply })
+
+			got, err := reassignUserBookings(5, 7, tt.ids)
+			if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("reassignUserBookings() = %v, %v; want %v (error: %v)", got, err, tt.want, tt.wantErr)
+			}
+			call := (*calls)[0]
+			if call.Path != "/api/internal/users/5/bookings/reassign" || call.ToUserID != 7 || !reflect.DeepEqual(call.BookingIDs, tt.ids) {
+				t.Errorf("request = %+v, want user 5 -> 7 limited to %v", call, tt.ids)
+			}
+		})
+	}
+}
+
+func TestRestoreBookings(t *testing.T) {
+	calls := fakeBookingService(t, func(call reassignCall) (int, []int) { return http.StatusOK, call.BookingIDs })
+
+	// A failed merge of 5 into 7 moves exactly the moved bookings back
+	restoreBookings(5, 7, []int{11, 12})
+	want := []reassignCall{{Path: "/api/internal/users/7/bookings/reassign", ToUserID: 5, BookingIDs: []int{11, 12}}}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("requests = %+v, want %+v", *calls, want)
+	}
+
+	// Nothing moved, nothing to undo: never "move everything back"
+	*calls = nil
+	restoreBookings(5, 7, []int{})
+	if len(*calls) != 0 {
+		t.Errorf("restoreBookings without bookings made %d requests", len(*calls))
+	}
+}
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"auth-backend/models"
+	"auth-backend/utils"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -9,14 +11,14 @@ import (
 )
 
 // ---------------- CreateUserByAdmin ----------------
-// Admin-only route to create staff/admin/customer
+// Creates a user with any role whose permissions the caller holds
 func CreateUserByAdmin(c *gin.Context) {
 	var req struct {
 		Name         string                 `json:"name" binding:"required"`
 		Email        *string                `json:"email"`
 		PhoneNumber  *string                `json:"phone_number"`
 		Password     string                 `json:"password" binding:"required"`
-		Role         string                 `json:"role" binding:"required"` // admin/staff/customer
+		Role         string                 `json:"role" binding:"required"` // any role from /api/admin/roles
 		ExtraDetails map[string]interface{} `json:"extra,omitempty"`
 	}
 
@@ -25,12 +27,37 @@ func CreateUserByAdmin(c *gin.Context) {
 		return
 	}
 
-	req.Role = strings.ToLower(req.Role)
-	if req.Role != "admin" && req.Role != "staff" && req.Role != "customer" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be 'admin', 'staff', or 'customer'"})
+	req.Role = legacyRole(strings.ToLower(req.Role), extraString(req.ExtraDetails, "level"))
+	if !canAssignRole(c, req.Role) {
 		return
 	}
 
+	if dept := extraString(req.ExtraDetails, "dept"); req.Role == "staff" && dept != "" {
+		exists, err := models.DepartmentExists(dept)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check department"})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown department"})
+			return
+		}
+	}
+
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.PhoneNumber != nil {
+		phone, err := utils.NormalizePhone(*req.PhoneNumber)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.PhoneNumber = &phone
+	}
+
 	user := &models.User{
 		Name:         req.Name,
 		Email:        req.Email,
@@ -41,6 +68,14 @@ func CreateUserByAdmin(c *gin.Context) {
 	}
 
 	createdUser, created, err := models.CreateOrFetchUser(user, req.ExtraDetails)
+	if errors.Is(err, models.ErrUserExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		return
+	}
+	if errors.Is(err, models.ErrUnknownRole) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
@@ -50,19 +85,39 @@ func CreateUserByAdmin(c *gin.Context) {
 		return
 	}
 
+	diff := map[string]models.AuditChange{
+		"name":     {To: createdUser.Name},
+		"role":     {To: createdUser.Role},
+		"password": {To: models.AuditRedacted},
+	}
+	if createdUser.Email != nil {
+		diff["email"] = models.AuditChange{To: *createdUser.Email}
+	}
+	if createdUser.PhoneNumber != nil {
+		diff["phone"] = models.AuditChange{To: *createdUser.PhoneNumber}
+	}
+	for k, v := range req.ExtraDetails {
+		diff["extra."+k] = models.AuditChange{To: v}
+	}
+	recordAudit(c, &models.AuditEvent{
+		Action:       models.AuditUserCreated,
+		TargetUserID: &createdUser.ID,
+		Diff:         diff,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User created successfully",
-		"user":    createdUser,
+		"user":    adminUserView(createdUser, nil),
 	})
 }
 
 // ---------------- ChangeUserRole ----------------
-// Admin can upgrade staff → admin
+// Legacy body-based form of PUT /api/admin/users/:id/role
 func ChangeUserRole(c *gin.Context) {
 	var req struct {
 		UserID int    `json:"user_id" binding:"required"`
-		Role   string `json:"role" binding:"required"` // only 'admin'
-		Level  string `json:"level"`                   // optional, admin level
+		Role   string `json:"role" binding:"required"`
+		Level  string `json:"level"` // legacy: role "admin" + level "manager" means role "manager"
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -70,12 +125,6 @@ func ChangeUserRole(c *gin.Context) {
 		return
 	}
 
-	req.Role = strings.ToLower(req.Role)
-	if req.Role != "admin" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Role can only be upgraded to 'admin'"})
-		return
-	}
-
 	user, err := models.GetUserByID(req.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
@@ -85,61 +134,57 @@ func ChangeUserRole(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
+	applyRoleChange(c, user, legacyRole(strings.ToLower(req.Role), req.Level))
+}
 
-	if user.Role != "staff" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only staff can be upgraded to admin"})
-		return
-	}
-
-	user.Role = "admin"
-	if err := models.UpdateUser(user, map[string]interface{}{"level": req.Level}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role"})
-		return
+// legacyRole maps the old admin level "manager" onto the manager role
+func legacyRole(role, level string) string {
+	if role == "admin" && strings.ToLower(level) == "manager" {
+		return "manager"
 	}
+	return role
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User role updated successfully",
-		"user":    user,
-	})
+func extraString(extra map[string]interface{}, key string) string {
+	s, _ := extra[key].(string)
+	return s
 }
 
-// ---------------- ListUsers ----------------
-// Returns all users with role-specific extra info
-func ListUsers(c *gin.Context) {
-	users, err := models.GetAllUsers()
+// ---------------- ListRolePolicies ----------------
+// Returns every role with its login policy
+func ListRolePolicies(c *gin.Context) {
+	roles, err := models.ListRolePolicies()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
 
-	var response []gin.H
-	for _, u := range users {
-		roleExtra := map[string]interface{}{}
-
-		switch u.Role {
-		case "admin":
-			level, _ := models.GetAdminLevel(u.ID)
-			roleExtra["level"] = level
-		case "staff":
-			dept, _ := models.GetStaffDept(u.ID)
-			roleExtra["dept"] = dept
-		case "customer":
-			points, _ := models.GetCustomerPoints(u.ID)
-			roleExtra["loyalty_points"] = points
-		}
+// ---------------- UpdateRoleEmailPolicy ----------------
+// Admin can require a verified email before users of a role can log in
+func UpdateRoleEmailPolicy(c *gin.Context) {
+	var req struct {
+		RequireVerifiedEmail *bool `json:"require_verified_email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-		response = append(response, gin.H{
-			"id":          u.ID,
-			"name":        u.Name,
-			"email":       u.Email,
-			"phone":       u.PhoneNumber,
-			"role":        u.Role,
-			"is_verified": u.IsVerified,
-			"created_at":  u.CreatedAt,
-			"updated_at":  u.UpdatedAt,
-			"role_extra":  roleExtra,
-		})
+	role := strings.ToLower(c.Param("role"))
+	found, err := models.SetRoleRequireVerifiedEmail(role, *req.RequireVerifiedEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role policy"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"users": response})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role policy updated",
+		"role":    models.RolePolicy{Name: role, RequireVerifiedEmail: *req.RequireVerifiedEmail},
+	})
 }
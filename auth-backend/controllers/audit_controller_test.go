@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"auth-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditEventFilter(t *testing.T) {
+	day := func(s string) *time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return &d
+	}
+	instant := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    string
+		want     models.AuditEventFilter
+		wantPage int
+		wantOK   bool
+	}{
+		{"defaults", "", models.AuditEventFilter{Limit: 100}, 1, true},
+		{"every filter", "actor_id=3&target_user_id=9&action=login.&ip=203.0.113.7&from=2026-03-01&to=2026-03-31&page=3&page_size=20",
+			models.AuditEventFilter{ActorID: 3, TargetUserID: 9, Action: "login.", IP: "203.0.113.7",
+				From: day("2026-03-01"), To: day("2026-04-01"), Limit: 20, Offset: 40}, 3, true},
+		{"RFC 3339 bounds are exact", "from=2026-03-01T12:30:00Z&to=2026-03-01T12:30:00Z",
+			models.AuditEventFilter{From: &instant, To: &instant, Limit: 100}, 1, true},
+		{"invalid actor", "actor_id=admin", models.AuditEventFilter{}, 0, false},
+		{"invalid target", "target_user_id=1.5", models.AuditEventFilter{}, 0, false},
+		{"invalid date", "from=01/03/2026", models.AuditEventFilter{}, 0, false},
+		{"page zero", "page=0", models.AuditEventFilter{}, 0, false},
+		{"page size too large", "page_size=501", models.AuditEventFilter{}, 0, false},
+		{"page size zero", "page_size=0", models.AuditEventFilter{}, 0, false},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/audit-events?"+tt.query, nil)
+
+			got, page, ok := auditEventFilter(c)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v (%s)", ok, tt.wantOK, w.Body)
+			}
+			if !ok {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400", w.Code)
+				}
+				return
+			}
+			if page != tt.wantPage || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("auditEventFilter() = %+v, page %d; want %+v, page %d", got, page, tt.want, tt.wantPage)
+			}
+		})
+	}
+}
@@ -4,14 +4,185 @@ import (
 	cache "auth-backend/cache-management"
 	"auth-backend/models"
 	"auth-backend/utils"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// otpLockSubjects are the keys a lockout is tracked under: the phone and the account
+// (userID 0: phone login before the account is known)
+func otpLockSubjects(userID int, phone string) []string {
+	if userID == 0 {
+		return []string{"phone:" + phone}
+	}
+	return []string{"phone:" + phone, fmt.Sprintf("user:%d", userID)}
+}
+
+// otpLockout returns the remaining lockout for a phone/account pair (0 if not locked)
+func otpLockout(userID int, phone string) time.Duration {
+	var longest time.Duration
+	for _, subject := range otpLockSubjects(userID, phone) {
+		ttl, err := cache.GetOTPLockout(subject)
+		if err != nil {
+			log.Printf("⚠️ [OTP] Failed to read lockout for %s: %v", subject, err)
+			continue
+		}
+		if ttl > longest {
+			longest = ttl
+		}
+	}
+	return longest
+}
+
+// lockOTP locks both the phone and the account out of OTP and returns the lockout duration
+func lockOTP(userID int, phone string) time.Duration {
+	var longest time.Duration
+	for _, subject := range otpLockSubjects(userID, phone) {
+		d, err := cache.LockOTP(subject, settings.OTPLockoutBase, settings.OTPLockoutMax)
+		if err != nil {
+			log.Printf("❌ [OTP] Failed to lock %s: %v", subject, err)
+			continue
+		}
+		if d > longest {
+			longest = d
+		}
+	}
+	return longest
+}
+
+// respondOTPLocked answers 429 with the time left until OTP can be used again
+func respondOTPLocked(c *gin.Context, retryAfter time.Duration, extra gin.H) {
+	seconds := int(retryAfter.Round(time.Second).Seconds())
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	body := gin.H{
+		"error":       "Too many failed OTP attempts, please try again later",
+		"retry_after": seconds,
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.JSON(http.StatusTooManyRequests, body)
+}
+
+// sendPhoneOTP sends a verification code to phone for the user, honouring lockouts and the
+// resend cooldown. On failure it writes the response and returns false.
+// VerifyOTP applies the phone to the account once the code is confirmed.
+// userID 0 sends a phone login code (PhoneLoginVerify).
+func sendPhoneOTP(c *gin.Context, userID int, phone string) bool {
+	// Locked out after too many wrong codes
+	if lockout := otpLockout(userID, phone); lockout > 0 {
+		log.Printf("⚠️ [OTP] OTP locked for user %d / phone %s (%s left)", userID, phone, lockout)
+		respondOTPLocked(c, lockout, nil)
+		return false
+	}
+
+	// Rate limiting check (1 minute cooldown per phone)
+	if !cache.CanRequestOTP(phone, 1*time.Minute) {
+		log.Printf("⚠️ [OTP] OTP request too soon for phone %s", phone)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "OTP recently sent, please wait"})
+		return false
+	}
+
+	// Generate OTP
+	log.Printf("🔑 [OTP] Generating OTP for %s", phone)
+	otp, err := utils.GenerateAndSendOTP(phone)
+	if errors.Is(err, utils.ErrOTPDelivery) {
+		log.Printf("❌ [OTP] SMS delivery to %s failed: %v", phone, err)
+		if dbErr := models.SaveOTPDeliveryFailure(userID, phone, err.Error()); dbErr != nil {
+			log.Printf("⚠️ [OTP] Failed to record delivery failure in DB: %v", dbErr)
+		}
+		cache.ResetOTPCooldown(phone) // let the user retry right away
+		auditOTPRequest(c, userID, phone, false)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to deliver OTP by SMS, please try again"})
+		return false
+	}
+	if err != nil {
+		log.Printf("❌ [OTP] Failed to generate OTP for %s: %v", phone, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send OTP"})
+		return false
+	}
+	log.Println("✅ [OTP] OTP generated and sent")
+
+	// Only the keyed hash of the code is ever stored
+	otpHash := utils.HashOTP(phone, otp)
+
+	// Save OTP history in DB
+	requestID, err := models.SaveOTPRequest(userID, phone, otpHash)
+	if err != nil {
+		log.Printf("⚠️ [OTP] Failed to save OTP request in DB: %v", err)
+	} else {
+		log.Printf("📜 [OTP] OTP history saved in DB (request ID=%d)", requestID)
+	}
+
+	// Save OTP in cache (expires in 5 minutes)
+	if err := cache.SaveOTP(userID, phone, cache.OTPEntry{RequestID: requestID, Hash: otpHash}, 5); err != nil {
+		log.Printf("❌ [OTP] Failed to save OTP in cache: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save OTP"})
+		return false
+	}
+	log.Println("💾 [OTP] OTP saved in cache")
+
+	// A fresh code gets a fresh attempt budget
+	cache.ResetFailedOTP(userID, phone)
+	auditOTPRequest(c, userID, phone, true)
+	return true
+}
+
+// auditOTPRequest records a code sent (or failed to send) to phone; userID 0 is a phone login
+func auditOTPRequest(c *gin.Context, userID int, phone string, delivered bool) {
+	e := &models.AuditEvent{
+		Action: models.AuditOTPRequested,
+		Details: map[string]interface{}{
+			"phone":     phone,
+			"route":     c.FullPath(),
+			"delivered": delivered,
+		},
+	}
+	if userID != 0 {
+		e.TargetUserID = &userID
+	}
+	recordAudit(c, e)
+}
+
+// rejectOTP counts a wrong code; after OTPMaxAttempts it burns the code and locks the phone
+// (and account) out. Writes the 401/429 response.
+func rejectOTP(c *gin.Context, userID int, phone string, cachedOTP *cache.OTPEntry) {
+	_ = models.MarkOTPFailed(cachedOTP.RequestID)
+
+	count, err := cache.IncrementFailedOTP(userID, phone)
+	if err != nil {
+		log.Printf("⚠️ [OTP] Failed to count failed attempt: %v", err)
+	}
+	remaining := settings.OTPMaxAttempts - int(count)
+	if err == nil && remaining <= 0 {
+		// Burn the code and lock the phone + account out
+		if err := cache.DeleteOTP(userID, phone); err != nil {
+			log.Printf("⚠️ [OTP] Failed to delete OTP from cache: %v", err)
+		}
+		cache.ResetFailedOTP(userID, phone)
+		if err := models.MarkOTPLocked(cachedOTP.RequestID); err != nil {
+			log.Printf("⚠️ [OTP] Failed to mark OTP as locked in DB: %v", err)
+		}
+		lockout := lockOTP(userID, phone)
+		log.Printf("🔒 [OTP] Max attempts reached for user %d / phone %s, locked for %s", userID, phone, lockout)
+		respondOTPLocked(c, lockout, gin.H{"remaining_attempts": 0})
+		return
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error":              "Invalid or expired OTP",
+		"remaining_attempts": remaining,
+	})
+}
+
 // ---------------- PhoneAuth → request OTP ----------------
 func PhoneAuth(c *gin.Context) {
 	log.Println("📲 [PhoneAuth] Request received")
@@ -26,8 +197,13 @@ func PhoneAuth(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	req.Phone = strings.TrimSpace(req.Phone)
-	log.Printf("📲 [PhoneAuth] Phone after trim: %s", req.Phone)
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Phone = phone
+	log.Printf("📲 [PhoneAuth] Normalized phone: %s", req.Phone)
 
 	// 🔑 Get user_id from JWT
 	userIDVal, exists := c.Get("user_id")
@@ -67,38 +243,10 @@ func PhoneAuth(c *gin.Context) {
 		return
 	}
 
-	// Rate limiting check (1 minute cooldown per phone)
-	if !cache.CanRequestOTP(req.Phone, 1*time.Minute) {
-		log.Printf("⚠️ [PhoneAuth] OTP request too soon for phone %s", req.Phone)
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": "OTP recently sent, please wait"})
+	if !sendPhoneOTP(c, userID, req.Phone) {
 		return
 	}
 
-	// Generate OTP
-	log.Printf("🔑 [PhoneAuth] Generating OTP for %s", req.Phone)
-	otp, err := utils.GenerateAndSendOTP(req.Phone)
-	if err != nil {
-		log.Printf("❌ [PhoneAuth] Failed to send OTP to %s: %v", req.Phone, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send OTP"})
-		return
-	}
-	log.Printf("✅ [PhoneAuth] OTP generated: %s (not shown to user)", otp)
-
-	// Save OTP in cache (expires in 5 minutes)
-	if err := cache.SaveOTP(userID, req.Phone, otp, 5); err != nil {
-		log.Printf("❌ [PhoneAuth] Failed to save OTP in cache: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save OTP"})
-		return
-	}
-	log.Println("💾 [PhoneAuth] OTP saved in cache")
-
-	// Save OTP history in DB
-	if err := models.SaveOTPRequest(userID, req.Phone, otp); err != nil {
-		log.Printf("⚠️ [PhoneAuth] Failed to save OTP request in DB: %v", err)
-	} else {
-		log.Println("📜 [PhoneAuth] OTP history saved in DB")
-	}
-
 	log.Printf("✅ [PhoneAuth] OTP sent to %s for user ID=%d", req.Phone, user.ID)
 	c.JSON(http.StatusOK, gin.H{"message": "OTP sent for phone verification"})
 }
@@ -118,8 +266,13 @@ func VerifyOTP(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	req.Phone = strings.TrimSpace(req.Phone)
-	log.Printf("📲 [VerifyOTP] Phone=%s, OTP=%s", req.Phone, req.OTP)
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Phone = phone
+	log.Printf("📲 [VerifyOTP] Phone=%s, OTP=[redacted]", req.Phone)
 
 	// 🔑 Get user_id from JWT
 	userIDVal, exists := c.Get("user_id")
@@ -160,18 +313,24 @@ func VerifyOTP(c *gin.Context) {
 		return
 	}
 
+	// Locked out after too many wrong codes
+	if lockout := otpLockout(userID, req.Phone); lockout > 0 {
+		log.Printf("⚠️ [VerifyOTP] OTP locked for user %d / phone %s (%s left)", userID, req.Phone, lockout)
+		respondOTPLocked(c, lockout, gin.H{"remaining_attempts": 0})
+		return
+	}
+
 	// Get OTP from cache
 	cachedOTP, err := cache.GetOTP(userID, req.Phone)
 	if err != nil {
 		log.Printf("❌ [VerifyOTP] Failed to get OTP from cache for phone %s: %v", req.Phone, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired OTP"})
+		return
 	}
-	log.Printf("🔑 [VerifyOTP] Cached OTP=%s, Provided OTP=%s", cachedOTP, req.OTP)
 
-	if err != nil || cachedOTP != req.OTP {
-		log.Println("❌ [VerifyOTP] Invalid or expired OTP")
-		_, _ = cache.IncrementFailedOTP(userID, req.Phone)
-		_ = models.MarkOTPFailed(userID, req.Phone, req.OTP)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired OTP"})
+	if !utils.CheckOTP(req.Phone, req.OTP, cachedOTP.Hash) {
+		log.Printf("❌ [VerifyOTP] Invalid OTP for request ID=%d", cachedOTP.RequestID)
+		rejectOTP(c, userID, req.Phone, cachedOTP)
 		return
 	}
 
@@ -185,17 +344,30 @@ func VerifyOTP(c *gin.Context) {
 
 	// Update user as verified
 	log.Printf("🔄 [VerifyOTP] Updating user %d as verified", user.ID)
+	previousPhone := user.PhoneNumber
 	user.IsVerified = true
 	user.PhoneNumber = &req.Phone
-	if err := models.UpdateUser(user, nil); err != nil { // ✅ pass nil for extra
+	err = models.MarkPhoneVerified(user.ID, req.Phone)
+	if errors.Is(err, models.ErrUserExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number already in use"})
+		return
+	}
+	if err != nil {
 		log.Printf("❌ [VerifyOTP] Failed to update user %d: %v", user.ID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
 	log.Printf("✅ [VerifyOTP] User %d updated as verified", user.ID)
+	if previousPhone != nil && *previousPhone != req.Phone {
+		recordSecurityEvent(&models.SecurityEvent{
+			EventType: models.EventPhoneChanged,
+			UserID:    &user.ID,
+			IP:        c.ClientIP(),
+		})
+	}
 
 	// Mark OTP as verified in DB
-	if err := models.MarkOTPVerified(userID, req.Phone, req.OTP); err != nil {
+	if err := models.MarkOTPVerified(cachedOTP.RequestID); err != nil {
 		log.Printf("⚠️ [VerifyOTP] Failed to mark OTP as verified in DB: %v", err)
 	}
 	log.Println("📜 [VerifyOTP] OTP marked as verified in DB")
@@ -206,15 +378,14 @@ func VerifyOTP(c *gin.Context) {
 	} else {
 		log.Println("🗑️ [VerifyOTP] OTP deleted from cache")
 	}
+	cache.ResetFailedOTP(userID, req.Phone)
 
-	// Issue tokens
-	accessToken, err := utils.GenerateAccessToken(user.ID, user.Role)
+	// Record session + issue tokens
+	accessToken, refreshToken, err := startSession(c, user)
 	if err != nil {
-		log.Printf("⚠️ [VerifyOTP] Failed to generate access token: %v", err)
-	}
-	refreshToken, err := utils.GenerateRefreshToken(user.ID)
-	if err != nil {
-		log.Printf("⚠️ [VerifyOTP] Failed to generate refresh token: %v", err)
+		log.Printf("❌ [VerifyOTP] Failed to start session: %v", err)
+		respondSessionError(c, err)
+		return
 	}
 
 	log.Printf("✅ [VerifyOTP] Phone verification successful for user %d", user.ID)
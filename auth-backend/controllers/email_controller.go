@@ -3,6 +3,7 @@ package controllers
 import (
 	"auth-backend/models"
 	"auth-backend/utils"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
@@ -10,28 +11,51 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// EmailAuth → Login/Signup with email + password
-func EmailAuth(c *gin.Context) {
+// Returned for every failed login so callers can't probe which emails exist
+const invalidCredentialsMsg = "Invalid email or password"
+
+// Returned for every registration attempt, whether or not the email is taken
+const registrationAcceptedMsg = "Registration received. Check your inbox to confirm your email address."
+
+// EmailRegister → Signup with email + password
+func EmailRegister(c *gin.Context) {
 	type Request struct {
-		Name     string `json:"name"` // optional for signup
+		Name     string `json:"name"`
 		Email    string `json:"email" binding:"required,email"`
 		Password string `json:"password" binding:"required"`
 	}
 
 	var req Request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ EmailAuth bind error: %v", err)
+		log.Printf("❌ EmailRegister bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := utils.ValidatePassword(req.Password); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	email := strings.TrimSpace(strings.ToLower(req.Email))
 
-	// Hash password for new users
+	existing, err := models.GetUserByEmail(email)
+	if err != nil {
+		log.Printf("❌ EmailRegister GetUserByEmail error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register"})
+		return
+	}
+	if existing != nil {
+		// Same response as a fresh signup: don't reveal that the account exists
+		log.Printf("ℹ️  EmailRegister email already registered (user ID=%d)", existing.ID)
+		c.JSON(http.StatusCreated, gin.H{"message": registrationAcceptedMsg})
+		return
+	}
+
 	hash, err := utils.HashPassword(req.Password)
 	if err != nil {
-		log.Printf("❌ EmailAuth hash password error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		log.Printf("❌ EmailRegister hash password error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register"})
 		return
 	}
 
@@ -43,51 +67,101 @@ func EmailAuth(c *gin.Context) {
 		IsVerified:   false, // will verify later with phone OTP
 	}
 
-	// For customer, you may want to init loyalty points = 0
+	// For customer, init loyalty points = 0
 	extra := map[string]interface{}{
 		"loyalty_points": 0,
 	}
 
-	user, created, err := models.CreateOrFetchUser(newUser, extra)
+	user, err := models.CreateUser(newUser, extra)
+	if errors.Is(err, models.ErrUserExists) {
+		c.JSON(http.StatusCreated, gin.H{"message": registrationAcceptedMsg})
+		return
+	}
 	if err != nil || user == nil {
-		log.Printf("❌ EmailAuth CreateOrFetchUser error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or fetch user"})
+		log.Printf("❌ EmailRegister CreateUser error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register"})
 		return
 	}
 
-	if !created {
-		// Existing user → check password
-		if user.PasswordHash != "" && !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
-			log.Printf("❌ EmailAuth invalid password for user ID=%d", user.ID)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-			return
-		}
-		log.Printf("ℹ️  EmailAuth existing user login: ID=%d", user.ID)
-	} else {
-		log.Printf("✅ EmailAuth new user created: ID=%d", user.ID)
+	log.Printf("✅ EmailRegister new user created: ID=%d", user.ID)
+
+	if err := sendVerificationEmail(user); err != nil {
+		// Account exists now; the user can ask for a new link via /auth/email/resend
+		log.Printf("⚠️ EmailRegister failed to send verification email to user %d: %v", user.ID, err)
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": registrationAcceptedMsg})
+}
+
+// EmailLogin → Login with email + password (never creates or modifies accounts)
+func EmailLogin(c *gin.Context) {
+	type Request struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
 	}
 
-	// Generate JWT tokens
-	accessToken, err := utils.GenerateAccessToken(user.ID, user.Role)
+	var req Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("❌ EmailLogin bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email := strings.TrimSpace(strings.ToLower(req.Email))
+
+	// Progressive delay / lockout after repeated failures (per account and per IP)
+	if loginBlocked(c, email) {
+		log.Printf("⚠️ EmailLogin blocked for %s from %s", email, c.ClientIP())
+		auditLoginFailure(c, nil, "locked_out", map[string]interface{}{"email": email})
+		return
+	}
+
+	user, err := models.GetUserByEmail(email)
 	if err != nil {
-		log.Printf("❌ EmailAuth GenerateAccessToken error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
+		log.Printf("❌ EmailLogin GetUserByEmail error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+
+	if user == nil || user.PasswordHash == "" {
+		// Unknown email or password-less (e.g. Google-only) account
+		utils.BurnPasswordCheck(req.Password)
+		recordLoginFailure(c, email, nil)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": invalidCredentialsMsg})
+		return
+	}
+	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		log.Printf("❌ EmailLogin invalid password for user ID=%d", user.ID)
+		recordLoginFailure(c, email, user)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": invalidCredentialsMsg})
+		return
+	}
+
+	clearLoginFailures(email)
+
+	if user.PasswordResetRequired {
+		auditLoginFailure(c, user, "password_reset_required", nil)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":                   "Your password must be reset, use the link sent to your email or request a new one",
+			"password_reset_required": true,
+		})
 		return
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user.ID)
+	// Record session + generate JWT tokens
+	accessToken, refreshToken, err := startSession(c, user)
 	if err != nil {
-		log.Printf("❌ EmailAuth GenerateRefreshToken error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		log.Printf("❌ EmailLogin startSession error: %v", err)
+		respondSessionError(c, err)
 		return
 	}
 
+	log.Printf("✅ EmailLogin success for user ID=%d", user.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"access_token":             accessToken,
 		"refresh_token":            refreshToken,
 		"role":                     user.Role,
-		"is_new_user":              created,
 		"is_verified":              user.IsVerified,
+		"email_verified":           user.EmailVerifiedAt != nil,
 		"needs_phone_verification": !user.IsVerified,
 	})
 }
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/mailer"
+	"auth-backend/models"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	emailVerificationTTL      = 24 * time.Hour
+	emailVerificationCooldown = 2 * time.Minute
+)
+
+// Returned for every resend request so callers can't probe which emails exist
+const verificationSentMsg = "If this email belongs to an unverified account, a verification link has been sent."
+
+// sendVerificationEmail issues a fresh verification token and emails the link
+func sendVerificationEmail(user *models.User) error {
+	if user.Email == nil || *user.Email == "" {
+		return fmt.Errorf("user has no email address")
+	}
+
+	token, err := models.CreateUserToken(user.ID, models.TokenEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := mailer.AppURL("/verify-email", url.Values{"token": {token}})
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %d hours.\n\n%s\n\nIf you didn't create an account, you can ignore this email.",
+		user.Name, int(emailVerificationTTL.Hours()), link)
+	return mailer.Send(*user.Email, "Confirm your email address", body)
+}
+
+// ---------------- VerifyEmail → consume the emailed token ----------------
+func VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := models.ConsumeUserToken(models.TokenEmailVerification, req.Token)
+	if err != nil {
+		log.Printf("❌ [VerifyEmail] ConsumeUserToken error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	if err := models.MarkEmailVerified(userID); err != nil {
+		log.Printf("❌ [VerifyEmail] MarkEmailVerified error for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	log.Printf("✅ [VerifyEmail] Email verified for user %d", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified", "email_verified": true})
+}
+
+// ---------------- ResendVerificationEmail → public, with cooldown ----------------
+func ResendVerificationEmail(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email := strings.TrimSpace(strings.ToLower(req.Email))
+
+	if !cache.AllowRequest("email_verification", email, emailVerificationCooldown) {
+		c.Header("Retry-After", fmt.Sprintf("%d", int(emailVerificationCooldown.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Verification email recently sent, please wait"})
+		return
+	}
+
+	user, err := models.GetUserByEmail(email)
+	if err != nil {
+		log.Printf("❌ [ResendVerificationEmail] GetUserByEmail error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+		return
+	}
+	if user == nil || user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": verificationSentMsg})
+		return
+	}
+
+	// A mail failure gets the same answer: an error would tell that an unverified account exists
+	if err := sendVerificationEmail(user); err != nil {
+		log.Printf("❌ [ResendVerificationEmail] Failed to send to user %d: %v", user.ID, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": verificationSentMsg})
+}
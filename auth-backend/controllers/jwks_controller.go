@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"auth-backend/keys"
+	"auth-backend/models"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------- JWKS → public signing keys for the other services ----------------
+func JWKS(c *gin.Context) {
+	// Short cache: verifiers refetch anyway when they see an unknown kid
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keys.Default.JWKS())
+}
+
+// ---------------- ListSigningKeys → key metadata (no key material) ----------------
+func ListSigningKeys(c *gin.Context) {
+	list, err := models.ListSigningKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch signing keys"})
+		return
+	}
+	if list == nil {
+		list = []*models.SigningKey{}
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": list})
+}
+
+// ---------------- RotateSigningKey → rotate now (e.g. suspected compromise) ----------------
+// Tokens signed with the previous key stay valid until they expire; use logout-all to cut them
+func RotateSigningKey(c *gin.Context) {
+	if _, err := keys.Default.Rotate(0); err != nil {
+		log.Printf("❌ [RotateSigningKey] Rotation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate signing key"})
+		return
+	}
+	adminID := c.GetInt("user_id")
+	log.Printf("🔑 [RotateSigningKey] Admin %d rotated the JWT signing key", adminID)
+	c.JSON(http.StatusOK, gin.H{"message": "Signing key rotated", "jwks": keys.Default.JWKS()})
+}
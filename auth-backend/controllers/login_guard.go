@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/models"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	loginScopeAccount = "account"
+	loginScopeIP      = "ip"
+
+	// Progressive delay: 1s, 2s, 4s, ... between attempts once LoginDelayAfter is reached
+	loginDelayBase = time.Second
+	loginDelayMax  = time.Minute
+)
+
+// loginPenalty decides what a failed login costs once the scope has failures failed attempts in
+// the window: a lockout from maxFailures on, a delay doubling from delayAfter on, else nothing.
+// Failures are only counted while the scope isn't blocked, so a count past the threshold (the
+// first lockout expired) locks again.
+func loginPenalty(failures, delayAfter, maxFailures int) (lock bool, delay time.Duration) {
+	if maxFailures > 0 && failures >= maxFailures {
+		return true, 0
+	}
+	if delayAfter <= 0 || failures < delayAfter {
+		return false, 0
+	}
+	delay = loginDelayBase
+	for i := delayAfter; i < failures && delay < loginDelayMax; i++ {
+		delay *= 2
+	}
+	if delay > loginDelayMax {
+		delay = loginDelayMax
+	}
+	return false, delay
+}
+
+// loginBlocked answers 429 if the account or the client IP is delayed or locked out
+func loginBlocked(c *gin.Context, email string) bool {
+	for _, scope := range []struct{ name, key string }{
+		{loginScopeAccount, email},
+		{loginScopeIP, c.ClientIP()},
+	} {
+		wait, locked, err := cache.GetLoginBlock(scope.name, scope.key)
+		if err != nil {
+			log.Printf("⚠️ [LoginGuard] Failed to read %s block: %v", scope.name, err)
+			continue
+		}
+		if wait <= 0 {
+			continue
+		}
+
+		seconds := int(wait.Round(time.Second).Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		msg := "Too many failed login attempts, please wait before trying again"
+		if locked {
+			msg = "Too many failed login attempts, login temporarily locked"
+		}
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": msg, "retry_after": seconds})
+		return true
+	}
+	return false
+}
+
+// recordLoginFailure counts a failed login and applies delays / lockouts.
+// user is nil for unknown emails; they are counted the same way.
+func recordLoginFailure(c *gin.Context, email string, user *models.User) {
+	ip := c.ClientIP()
+	var userID *int
+	if user != nil {
+		userID = &user.ID
+	}
+	auditLoginFailure(c, user, "invalid_credentials", map[string]interface{}{"email": email})
+
+	count, err := cache.RecordLoginFailure(loginScopeAccount, email, settings.LoginFailureWindow)
+	if err != nil {
+		log.Printf("⚠️ [LoginGuard] Failed to count login failure: %v", err)
+	} else if lock, delay := loginPenalty(int(count), settings.LoginDelayAfter, settings.LoginMaxFailures); lock {
+		if err := cache.LockLogin(loginScopeAccount, email, settings.LoginLockout); err != nil {
+			log.Printf("❌ [LoginGuard] Failed to lock account: %v", err)
+		} else {
+			log.Printf("🔒 [LoginGuard] Account locked after %d failed logins (user ID=%v)", count, userID)
+			recordSecurityEvent(&models.SecurityEvent{
+				EventType: models.EventAccountLocked,
+				UserID:    userID,
+				IP:        ip,
+				Details: map[string]interface{}{
+					"email":           email,
+					"failures":        count,
+					"lockout_seconds": int(settings.LoginLockout.Seconds()),
+				},
+			})
+		}
+	} else if delay > 0 {
+		if err := cache.DelayLogin(loginScopeAccount, email, delay); err != nil {
+			log.Printf("⚠️ [LoginGuard] Failed to set login delay: %v", err)
+		}
+	}
+
+	ipCount, err := cache.RecordLoginFailure(loginScopeIP, ip, settings.LoginFailureWindow)
+	if err != nil {
+		log.Printf("⚠️ [LoginGuard] Failed to count login failure for IP: %v", err)
+	} else if lock, _ := loginPenalty(int(ipCount), 0, settings.LoginIPMaxFailures); lock {
+		if err := cache.LockLogin(loginScopeIP, ip, settings.LoginLockout); err != nil {
+			log.Printf("❌ [LoginGuard] Failed to lock IP %s: %v", ip, err)
+		} else {
+			log.Printf("🔒 [LoginGuard] IP %s locked after %d failed logins", ip, ipCount)
+			recordSecurityEvent(&models.SecurityEvent{
+				EventType: models.EventIPLocked,
+				IP:        ip,
+				Details: map[string]interface{}{
+					"failures":        ipCount,
+					"lockout_seconds": int(settings.LoginLockout.Seconds()),
+				},
+			})
+		}
+	}
+}
+
+// clearLoginFailures resets the account counters after a successful login
+func clearLoginFailures(email string) {
+	if err := cache.ResetLoginFailures(loginScopeAccount, email); err != nil {
+		log.Printf("⚠️ [LoginGuard] Failed to reset login failures: %v", err)
+	}
+}
+
+// recordSecurityEvent writes to the security log; failures are logged, never fatal
+func recordSecurityEvent(e *models.SecurityEvent) {
+	if err := models.RecordSecurityEvent(e); err != nil {
+		log.Printf("⚠️ [SecurityEvent] Failed to record %s: %v", e.EventType, err)
+	}
+}
+
+// ---------------- AdminLoginStatus → failed logins / lockout of a user ----------------
+func AdminLoginStatus(c *gin.Context) {
+	user, ok := adminLoadUserWithEmail(c)
+	if !ok {
+		return
+	}
+	email := strings.ToLower(*user.Email)
+
+	failures, err := cache.GetLoginFailures(loginScopeAccount, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read login status"})
+		return
+	}
+	wait, locked, err := cache.GetLoginBlock(loginScopeAccount, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read login status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":         user.ID,
+		"failed_attempts": failures,
+		"locked":          locked,
+		"delayed":         wait > 0 && !locked,
+		"retry_after":     int(wait.Round(time.Second).Seconds()),
+	})
+}
+
+// ---------------- AdminUnlockLogin → clear a user's lockout ----------------
+func AdminUnlockLogin(c *gin.Context) {
+	user, ok := adminLoadUserWithEmail(c)
+	if !ok {
+		return
+	}
+	email := strings.ToLower(*user.Email)
+
+	if err := cache.ResetLoginFailures(loginScopeAccount, email); err != nil {
+		log.Printf("❌ [AdminUnlockLogin] Failed to unlock user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+
+	adminID := c.GetInt("user_id")
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventAccountUnlocked,
+		UserID:    &user.ID,
+		ActorID:   &adminID,
+		IP:        c.ClientIP(),
+	})
+
+	log.Printf("🔓 [AdminUnlockLogin] Admin %d unlocked user %d", adminID, user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
+}
+
+// ---------------- AdminUnlockIP → clear an IP lockout ----------------
+func AdminUnlockIP(c *gin.Context) {
+	ip := strings.TrimSpace(c.Param("ip"))
+	if ip == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IP is required"})
+		return
+	}
+
+	if err := cache.ResetLoginFailures(loginScopeIP, ip); err != nil {
+		log.Printf("❌ [AdminUnlockIP] Failed to unlock IP %s: %v", ip, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock IP"})
+		return
+	}
+
+	adminID := c.GetInt("user_id")
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventIPUnlocked,
+		ActorID:   &adminID,
+		IP:        ip,
+	})
+
+	log.Printf("🔓 [AdminUnlockIP] Admin %d unlocked IP %s", adminID, ip)
+	c.JSON(http.StatusOK, gin.H{"message": "IP unlocked"})
+}
+
+// ---------------- ListSecurityEvents → audit log ----------------
+// Optional filters: ?user_id=&type=&ip=&limit=
+func ListSecurityEvents(c *gin.Context) {
+	filter := models.SecurityEventFilter{
+		EventType: c.Query("type"),
+		IP:        c.Query("ip"),
+	}
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		filter.UserID = id
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	events, err := models.ListSecurityEvents(filter)
+	if err != nil {
+		log.Printf("❌ [ListSecurityEvents] Failed to fetch events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security events"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// adminLoadUserWithEmail loads :id for the login admin endpoints (only email accounts have password logins)
+func adminLoadUserWithEmail(c *gin.Context) (*models.User, bool) {
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return nil, false
+	}
+	if user.Email == nil || *user.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User has no email login"})
+		return nil, false
+	}
+	return user, true
+}
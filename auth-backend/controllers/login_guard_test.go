@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginPenalty(t *testing.T) {
+	tests := []struct {
+		name                              string
+		failures, delayAfter, maxFailures int
+		lock                              bool
+		delay                             time.Duration
+	}{
+		{"below delay threshold", 2, 3, 10, false, 0},
+		{"first delay", 3, 3, 10, false, time.Second},
+		{"delay doubles", 5, 3, 10, false, 4 * time.Second},
+		{"delay capped", 40, 3, 50, false, loginDelayMax},
+		{"lockout threshold", 10, 3, 10, true, 0},
+		{"past threshold after an expired lockout", 11, 3, 10, true, 0},
+		{"well past threshold", 25, 3, 10, true, 0},
+		{"ip: no delays", 49, 0, 50, false, 0},
+		{"ip: lockout threshold", 50, 0, 50, true, 0},
+		{"ip: past threshold after an expired lockout", 51, 0, 50, true, 0},
+		{"lockouts disabled", 100, 0, 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock, delay := loginPenalty(tt.failures, tt.delayAfter, tt.maxFailures)
+			if lock != tt.lock || delay != tt.delay {
+				t.Errorf("loginPenalty(%d, %d, %d) = %v, %s; want %v, %s",
+					tt.failures, tt.delayAfter, tt.maxFailures, lock, delay, tt.lock, tt.delay)
+			}
+		})
+	}
+}
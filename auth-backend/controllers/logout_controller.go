@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/utils"
+	"cinema-shared/auth"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------- Logout → revoke current tokens ----------------
+func Logout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"` // optional, revoked together with the access token
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	// Revoke the access token used for this request
+	claims := auth.ClaimsFrom(c)
+	if err := cache.RevokeToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		log.Printf("❌ [Logout] Failed to revoke access token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	// Revoke the refresh token if it belongs to the same user
+	if req.RefreshToken != "" {
+		refresh, err := utils.VerifyToken(req.RefreshToken, auth.TypeRefresh)
+		if err != nil || refresh.UserID != claims.UserID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		if err := cache.RevokeToken(refresh.ID, refresh.ExpiresAt.Time); err != nil {
+			log.Printf("❌ [Logout] Failed to revoke refresh token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	}
+
+	// End the session so its remaining tokens stop working too
+	if sid := claims.SessionID; sid != "" {
+		if err := revokeSession(sid); err != nil {
+			log.Printf("❌ [Logout] Failed to revoke session %s: %v", sid, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// ---------------- LogoutAll → revoke every token of the user ----------------
+func LogoutAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if err := cache.RevokeAllUserTokens(userID.(int), utils.RefreshTokenTTL); err != nil {
+		log.Printf("❌ [LogoutAll] Failed to revoke tokens for user %v: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out of all devices"})
+		return
+	}
+	if err := revokeSessions(userID.(int), ""); err != nil {
+		log.Printf("⚠️ [LogoutAll] Failed to mark sessions revoked for user %v: %v", userID, err)
+	}
+
+	log.Printf("✅ [LogoutAll] All tokens revoked for user %v", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices"})
+}
+
+// ---------------- RevocationList → consumed by other services ----------------
+func RevocationList(c *gin.Context) {
+	list, err := cache.GetRevocationList(utils.RefreshTokenTTL)
+	if err != nil {
+		log.Printf("❌ [RevocationList] Failed to load revocations: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load revocation list"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/mailer"
+	"auth-backend/models"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Returned for every magic link request so callers can't probe which emails exist
+const magicLinkSentMsg = "If an account exists for this email, a login link has been sent."
+
+// ---------------- RequestMagicLink → email a one-time login link ----------------
+func RequestMagicLink(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email := strings.TrimSpace(strings.ToLower(req.Email))
+
+	// One link per address per minute, MagicLinkMaxPerHour per hour
+	if !cache.AllowRequest("magic_link", email, time.Minute) ||
+		!cache.AllowRequests("magic_link", email, settings.MagicLinkMaxPerHour, time.Hour) {
+		log.Printf("⚠️ [RequestMagicLink] Rate limited for %s from %s", email, c.ClientIP())
+		c.JSON(http.StatusOK, gin.H{"message": magicLinkSentMsg})
+		return
+	}
+
+	user, err := models.GetUserByEmail(email)
+	if err != nil {
+		log.Printf("❌ [RequestMagicLink] GetUserByEmail error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+		return
+	}
+	if user == nil || user.DeactivatedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": magicLinkSentMsg})
+		return
+	}
+
+	// A mail failure gets the same answer: an error would tell that the account exists
+	if err := sendMagicLinkEmail(user); err != nil {
+		log.Printf("❌ [RequestMagicLink] Failed to send login link to user %d: %v", user.ID, err)
+	} else {
+		log.Printf("📧 [RequestMagicLink] Login link sent to user %d", user.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": magicLinkSentMsg})
+}
+
+// sendMagicLinkEmail issues a fresh login token (older links stop working) and emails the link.
+// The token is bound to the current address, so it dies if the email changes meanwhile.
+func sendMagicLinkEmail(user *models.User) error {
+	token, err := models.CreateUserTokenWithPayload(user.ID, models.TokenMagicLink, *user.Email, settings.MagicLinkTTL)
+	if err != nil {
+		return err
+	}
+
+	link := mailer.AppURL("/magic-login", url.Values{"token": {token}})
+	body := fmt.Sprintf("Hi %s,\n\nUse the link below to log in. It expires in %d minutes and can be used once.\n\n%s\n\nIf you didn't ask for this, you can ignore this email.",
+		user.Name, int(settings.MagicLinkTTL.Minutes()), link)
+	return mailer.Send(*user.Email, "Your login link", body)
+}
+
+// ---------------- MagicLinkLogin → exchange the emailed token for tokens ----------------
+// Same response as EmailLogin; users with 2FA get the usual challenge.
+func MagicLinkLogin(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, email, err := models.ConsumeUserTokenWithPayload(models.TokenMagicLink, req.Token)
+	if err != nil {
+		log.Printf("❌ [MagicLinkLogin] ConsumeUserToken error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+	if userID == 0 {
+		auditLoginFailure(c, nil, "invalid_link", nil)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+		return
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+	if user == nil || user.Email == nil || *user.Email != email {
+		auditLoginFailure(c, &models.User{ID: userID}, "invalid_link", nil)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+		return
+	}
+
+	// Opening the link proves the address
+	if user.EmailVerifiedAt == nil {
+		if err := models.MarkEmailVerified(user.ID); err != nil {
+			log.Printf("⚠️ [MagicLinkLogin] MarkEmailVerified error for user %d: %v", user.ID, err)
+		} else {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+	}
+	clearLoginFailures(email)
+
+	// Record session + generate JWT tokens
+	accessToken, refreshToken, err := startSession(c, user)
+	if err != nil {
+		log.Printf("❌ [MagicLinkLogin] startSession error: %v", err)
+		respondSessionError(c, err)
+		return
+	}
+
+	log.Printf("✅ [MagicLinkLogin] Login for user ID=%d", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":             accessToken,
+		"refresh_token":            refreshToken,
+		"role":                     user.Role,
+		"is_verified":              user.IsVerified,
+		"email_verified":           true,
+		"needs_phone_verification": !user.IsVerified,
+	})
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"auth-backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// postJSON serves one request to handler at path and decodes the JSON answer
+func postJSON(t *testing.T, path string, handler gin.HandlerFunc, body string) (int, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST(path, handler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	resp := map[string]interface{}{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	testDB(t)
+	const path = "/api/auth/magic-link/verify"
+	run := time.Now().UnixNano()
+	login := func(token string) int {
+		status, _ := postJSON(t, path, MagicLinkLogin, `{"token": "`+token+`"}`)
+		return status
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		email := fmt.Sprintf("magic-%d-1@example.com", run)
+		id := testUser(t, "customer", email, false)
+		token, err := models.CreateUserTokenWithPayload(id, models.TokenMagicLink, email, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status := login(token); status != http.StatusOK {
+			t.Fatalf("first use: status = %d, want 200", status)
+		}
+		if status := login(token); status != http.StatusUnauthorized {
+			t.Errorf("second use: status = %d, want 401", status)
+		}
+		// Opening the link proved the address
+		if user, _ := models.GetUserByID(id); user == nil || user.EmailVerifiedAt == nil {
+			t.Error("email not marked verified")
+		}
+	})
+
+	t.Run("bound to the address it was sent to", func(t *testing.T) {
+		email := fmt.Sprintf("magic-%d-2@example.com", run)
+		id := testUser(t, "customer", email, true)
+		token, err := models.CreateUserTokenWithPayload(id, models.TokenMagicLink, email, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := models.DB.Exec(context.Background(),
+			`UPDATE users SET email=$2 WHERE id=$1`, id, fmt.Sprintf("magic-%d-2b@example.com", run)); err != nil {
+			t.Fatal(err)
+		}
+
+		if status := login(token); status != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", status)
+		}
+	})
+
+	t.Run("other token purposes", func(t *testing.T) {
+		email := fmt.Sprintf("magic-%d-3@example.com", run)
+		id := testUser(t, "customer", email, true)
+		token, err := models.CreateUserTokenWithPayload(id, models.TokenPasswordReset, email, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status := login(token); status != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", status)
+		}
+	})
+}
+
+func TestRequestMagicLinkDoesNotRevealAccounts(t *testing.T) {
+	testDB(t)
+	run := time.Now().UnixNano()
+	existing := fmt.Sprintf("magic-%d@example.com", run)
+	testUser(t, "customer", existing, true)
+
+	// The mailer isn't initialized in tests: sending to the existing account fails
+	for _, email := range []string{existing, fmt.Sprintf("nobody-%d@example.com", run)} {
+		status, resp := postJSON(t, "/api/auth/magic-link", RequestMagicLink, `{"email": "`+email+`"}`)
+		if status != http.StatusOK || resp["message"] != magicLinkSentMsg {
+			t.Errorf("%s: %d %v, want 200 with the usual message", email, status, resp)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/models"
+	"auth-backend/utils"
+	"cinema-shared/auth"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	recoveryCodeCount = 10
+	loginScopeMFA     = "mfa" // failed second-factor attempts per user
+)
+
+// verifySecondFactor checks a TOTP code or a recovery code for an enrolled user.
+// It counts failures and locks 2FA after OTPMaxAttempts; responds and returns false on failure.
+func verifySecondFactor(c *gin.Context, userID int, code, recoveryCode string) bool {
+	key := strconv.Itoa(userID)
+
+	if wait, _, err := cache.GetLoginBlock(loginScopeMFA, key); err != nil {
+		log.Printf("⚠️ [2FA] Failed to read lockout for user %d: %v", userID, err)
+	} else if wait > 0 {
+		respondOTPLocked(c, wait, nil)
+		return false
+	}
+
+	mfa, err := models.GetUserMFA(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load 2FA settings"})
+		return false
+	}
+	if mfa == nil || mfa.EnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return false
+	}
+
+	ok := false
+	switch {
+	case code != "":
+		secret, err := utils.DecryptSecret(mfa.SecretEnc)
+		if err != nil {
+			log.Printf("❌ [2FA] Failed to decrypt TOTP secret for user %d: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+			return false
+		}
+		if step, valid := utils.ValidateTOTP(secret, code, time.Now()); valid {
+			// Each code is accepted only once
+			ok, err = models.UseTOTPStep(userID, step)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+				return false
+			}
+		}
+	case recoveryCode != "":
+		hash := utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode))
+		ok, err = models.UseRecoveryCode(userID, hash)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+			return false
+		}
+		if ok {
+			remaining, _ := models.CountRecoveryCodes(userID)
+			recordSecurityEvent(&models.SecurityEvent{
+				EventType: models.EventMFARecoveryUsed,
+				UserID:    &userID,
+				IP:        c.ClientIP(),
+				Details:   map[string]interface{}{"remaining": remaining},
+			})
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code is required"})
+		return false
+	}
+
+	if ok {
+		if err := cache.ResetLoginFailures(loginScopeMFA, key); err != nil {
+			log.Printf("⚠️ [2FA] Failed to reset failures for user %d: %v", userID, err)
+		}
+		return true
+	}
+
+	count, err := cache.RecordLoginFailure(loginScopeMFA, key, settings.LoginFailureWindow)
+	if err != nil {
+		log.Printf("⚠️ [2FA] Failed to count failure for user %d: %v", userID, err)
+	}
+	remaining := settings.OTPMaxAttempts - int(count)
+	if err == nil && remaining <= 0 {
+		if err := cache.LockLogin(loginScopeMFA, key, settings.LoginLockout); err != nil {
+			log.Printf("❌ [2FA] Failed to lock user %d: %v", userID, err)
+		}
+		recordSecurityEvent(&models.SecurityEvent{
+			EventType: models.EventMFALocked,
+			UserID:    &userID,
+			IP:        c.ClientIP(),
+			Details:   map[string]interface{}{"failures": count},
+		})
+		log.Printf("🔒 [2FA] Too many wrong codes for user %d, locked", userID)
+		respondOTPLocked(c, settings.LoginLockout, gin.H{"remaining_attempts": 0})
+		return false
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error":              "Invalid authentication code",
+		"remaining_attempts": remaining,
+	})
+	return false
+}
+
+// issueRecoveryCodes replaces a user's recovery codes and returns the plaintext (shown once)
+func issueRecoveryCodes(userID int) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(code)
+	}
+	if err := models.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ---------------- MFAStatus → current user's 2FA state ----------------
+func MFAStatus(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	role := c.GetString("role")
+
+	mfa, err := models.GetUserMFA(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load 2FA settings"})
+		return
+	}
+	required, err := models.RoleRequiresMFA(role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load 2FA policy"})
+		return
+	}
+
+	enabled := mfa != nil && mfa.EnabledAt != nil
+	remaining := 0
+	if enabled {
+		remaining, _ = models.CountRecoveryCodes(userID)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                  enabled,
+		"required":                 required,
+		"recovery_codes_remaining": remaining,
+		"session_verified":         c.GetBool("mfa"),
+	})
+}
+
+// ---------------- MFASetup → start TOTP enrollment ----------------
+// Returns the secret + otpauth:// URI; nothing is enforced until MFAEnable confirms a code
+func MFASetup(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	existing, err := models.GetUserMFA(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load 2FA settings"})
+		return
+	}
+	if existing != nil && existing.EnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+	secretEnc, err := utils.EncryptSecret(secret)
+	if err != nil {
+		log.Printf("❌ [MFASetup] Failed to encrypt secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+	if err := models.SavePendingMFA(userID, secretEnc); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	account := strconv.Itoa(user.ID)
+	if user.Email != nil && *user.Email != "" {
+		account = *user.Email
+	} else if user.PhoneNumber != nil && *user.PhoneNumber != "" {
+		account = *user.PhoneNumber
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": utils.TOTPProvisioningURI(settings.TOTPIssuer, account, secret),
+		"message":          "Scan the QR code, then confirm with a code at /api/auth/2fa/enable",
+	})
+}
+
+// ---------------- MFAEnable → confirm enrollment with a first code ----------------
+// Issues recovery codes and upgrades the current session to 2FA-verified tokens
+func MFAEnable(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := c.GetInt("user_id")
+
+	mfa, err := models.GetUserMFA(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load 2FA settings"})
+		return
+	}
+	if mfa == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start enrollment at /api/auth/2fa/setup first"})
+		return
+	}
+	if mfa.EnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := utils.DecryptSecret(mfa.SecretEnc)
+	if err != nil {
+		log.Printf("❌ [MFAEnable] Failed to decrypt secret for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	step, ok := utils.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+		return
+	}
+	if err := models.EnableMFA(userID, step); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable 2FA"})
+		return
+	}
+
+	codes, err := issueRecoveryCodes(userID)
+	if err != nil {
+		log.Printf("❌ [MFAEnable] Failed to issue recovery codes for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue recovery codes"})
+		return
+	}
+
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventMFAEnabled,
+		UserID:    &userID,
+		IP:        c.ClientIP(),
+	})
+
+	// The user just proved the second factor: upgrade this session
+	response := gin.H{
+		"message":        "Two-factor authentication enabled. Store the recovery codes somewhere safe, they are shown only once.",
+		"recovery_codes": codes,
+	}
+	if sid := c.GetString("session_id"); sid != "" {
+		user, err := models.GetUserByID(userID)
+		if err == nil && user != nil {
+			if claims, err := accessClaims(user, sid, true); err == nil {
+				if accessToken, err := utils.GenerateAccessToken(claims); err == nil {
+					response["access_token"] = accessToken
+				}
+			}
+		}
+		refreshToken, err := utils.GenerateRefreshToken(userID, sid)
+		if err == nil {
+			response["refresh_token"] = refreshToken
+		}
+	}
+
+	log.Printf("✅ [MFAEnable] 2FA enabled for user %d", userID)
+	c.JSON(http.StatusOK, response)
+}
+
+// ---------------- MFADisable → turn 2FA off (needs a current code) ----------------
+func MFADisable(c *gin.Context) {
+	var req struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := c.GetInt("user_id")
+
+	required, err := models.RoleRequiresMFA(c.GetString("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load 2FA policy"})
+		return
+	}
+	if required {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is mandatory for your role"})
+		return
+	}
+
+	if !verifySecondFactor(c, userID, req.Code, req.RecoveryCode) {
+		return
+	}
+	if err := models.DeleteUserMFA(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable 2FA"})
+		return
+	}
+
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventMFADisabled,
+		UserID:    &userID,
+		IP:        c.ClientIP(),
+	})
+	log.Printf("✅ [MFADisable] 2FA disabled for user %d", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// ---------------- MFARegenerateRecoveryCodes → new set, old ones stop working ----------------
+func MFARegenerateRecoveryCodes(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := c.GetInt("user_id")
+
+	if !verifySecondFactor(c, userID, req.Code, "") {
+		return
+	}
+	codes, err := issueRecoveryCodes(userID)
+	if err != nil {
+		log.Printf("❌ [MFARegenerateRecoveryCodes] Failed for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue recovery codes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// ---------------- MFAVerify → second login step ----------------
+// Exchanges the mfa_token from the first step plus a TOTP / recovery code for session tokens
+func MFAVerify(c *gin.Context) {
+	var req struct {
+		MFAToken     string `json:"mfa_token" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verifier also rejects challenges already used (jti on the denylist)
+	claims, err := utils.VerifyToken(req.MFAToken, auth.TypeMFAChallenge)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired 2FA challenge, please log in again"})
+		return
+	}
+	userID := claims.UserID
+
+	if !verifySecondFactor(c, userID, strings.TrimSpace(req.Code), req.RecoveryCode) {
+		auditLoginFailure(c, &models.User{ID: userID}, "invalid_second_factor", nil)
+		return
+	}
+
+	// Challenge tokens are single-use
+	if err := cache.RevokeToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		log.Printf("⚠️ [MFAVerify] Failed to revoke challenge token: %v", err)
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.Set("mfa", true)
+	accessToken, refreshToken, err := startSession(c, user)
+	if err != nil {
+		log.Printf("❌ [MFAVerify] startSession error: %v", err)
+		respondSessionError(c, err)
+		return
+	}
+
+	log.Printf("✅ [MFAVerify] 2FA login success for user ID=%d", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"role":          user.Role,
+		"is_verified":   user.IsVerified,
+	})
+}
+
+// ---------------- AdminResetUserMFA → remove a user's 2FA (lost device) ----------------
+func AdminResetUserMFA(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if err := models.DeleteUserMFA(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset 2FA"})
+		return
+	}
+	// Existing 2FA-verified sessions shouldn't outlive the reset
+	if err := revokeSessions(userID, ""); err != nil {
+		log.Printf("⚠️ [AdminResetUserMFA] Failed to revoke sessions of user %d: %v", userID, err)
+	}
+
+	adminID := c.GetInt("user_id")
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventMFAReset,
+		UserID:    &userID,
+		ActorID:   &adminID,
+		IP:        c.ClientIP(),
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
+
+// ---------------- UpdateRoleMFAPolicy → make 2FA mandatory for a role ----------------
+func UpdateRoleMFAPolicy(c *gin.Context) {
+	var req struct {
+		RequireMFA *bool `json:"require_mfa" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := strings.ToLower(c.Param("role"))
+	found, err := models.SetRoleRequireMFA(role, *req.RequireMFA)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role policy"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role policy updated", "role": role, "require_mfa": *req.RequireMFA})
+}
@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"auth-backend/models"
+	"auth-backend/oidc"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// providerTokenRequest carries the token an external provider issued to the client:
+// an ID token for OIDC providers, an access token for OAuth2 providers
+type providerTokenRequest struct {
+	IDToken     string `json:"id_token"`
+	AccessToken string `json:"access_token"`
+	Nonce       string `json:"nonce"` // checked against the ID token when sent
+	Name        string `json:"name"`  // fallback when the provider doesn't share it (Apple after the first login)
+
+	// Step-up when linking to a signed-in account whose login is older than stepUpMaxAge
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// verifyProviderToken resolves :provider and verifies the request's token.
+// On failure it writes the response and returns false.
+func verifyProviderToken(c *gin.Context, providerName string) (*oidc.Identity, *providerTokenRequest, bool) {
+	provider, ok := oidc.Get(providerName)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return nil, nil, false
+	}
+
+	var req providerTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	token := req.IDToken
+	if provider.Type == "oauth2" {
+		token = req.AccessToken
+	}
+	if token == "" {
+		field := "id_token"
+		if provider.Type == "oauth2" {
+			field = "access_token"
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": field + " is required"})
+		return nil, nil, false
+	}
+
+	identity, err := provider.Verify(c.Request.Context(), token, req.Nonce)
+	if errors.Is(err, oidc.ErrInvalidToken) {
+		log.Printf("❌ [OIDC] %s token rejected: %v", provider.Name, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid " + provider.Name + " token"})
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Printf("❌ [OIDC] %s verification failed: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Could not reach " + provider.Name + ", please try again"})
+		return nil, nil, false
+	}
+	return identity, &req, true
+}
+
+// recordIdentityLink adds an identity link to the merge audit trail and the security log
+func recordIdentityLink(c *gin.Context, kind string, userID int, ident *oidc.Identity) {
+	provider := ident.Provider
+	if err := models.RecordAccountMerge(&models.AccountMerge{
+		Kind:         kind,
+		TargetUserID: userID,
+		Provider:     &provider,
+		IP:           c.ClientIP(),
+		Details:      map[string]interface{}{"email": ident.Email, "email_verified": ident.EmailVerified},
+	}); err != nil {
+		log.Printf("⚠️ [OIDC] Failed to audit %s link of user %d: %v", provider, userID, err)
+	}
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventIdentityLinked,
+		UserID:    &userID,
+		IP:        c.ClientIP(),
+		Details:   map[string]interface{}{"provider": provider, "via": kind},
+	})
+}
+
+// ---------------- ListProviders ----------------
+func ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": oidc.List()})
+}
+
+// ---------------- OIDCLogin → Login/Signup with an external provider ----------------
+func OIDCLogin(c *gin.Context) {
+	providerLogin(c, c.Param("provider"))
+}
+
+// GoogleLogin → Login/Signup with Google (kept for existing clients)
+func GoogleLogin(c *gin.Context) {
+	providerLogin(c, "google")
+}
+
+func providerLogin(c *gin.Context, providerName string) {
+	ident, req, ok := verifyProviderToken(c, providerName)
+	if !ok {
+		if c.Writer.Status() == http.StatusUnauthorized {
+			auditLoginFailure(c, nil, "invalid_provider_token", map[string]interface{}{"provider": providerName})
+		}
+		return
+	}
+	email := optionalString(ident.Email)
+	log.Printf("👉 [OIDCLogin] provider=%s subject=%s email=%v", ident.Provider, ident.Subject, email)
+
+	user, err := models.GetUserByIdentity(ident.Provider, ident.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+
+	created := false
+	if user != nil {
+		if err := models.TouchIdentity(ident.Provider, ident.Subject, email, ident.EmailVerified); err != nil {
+			log.Printf("⚠️ [OIDCLogin] TouchIdentity error: %v", err)
+		}
+	} else {
+		name := ident.Name
+		if name == "" {
+			name = req.Name
+		}
+		if name == "" {
+			name = "Cinema User"
+		}
+		newUser := &models.User{
+			Name:       name,
+			Email:      email,
+			Role:       "customer",
+			IsVerified: false, // will verify later with phone
+		}
+		if ident.EmailVerified && email != nil {
+			now := time.Now()
+			newUser.EmailVerifiedAt = &now
+		}
+		extra := map[string]interface{}{
+			"loyalty_points": 0,
+		}
+		// An existing account is only reused when both sides verified the email
+		user, created, err = models.CreateOrFetchUser(newUser, extra)
+		if errors.Is(err, models.ErrUserExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "An account with this email already exists. Log in to it and link " + ident.Provider + " from your profile",
+				"account_exists": true,
+			})
+			return
+		}
+		if err != nil || user == nil {
+			log.Printf("❌ [OIDCLogin] CreateOrFetchUser error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create or fetch user"})
+			return
+		}
+
+		err = models.LinkIdentity(&models.Identity{
+			UserID:        user.ID,
+			Provider:      ident.Provider,
+			Subject:       ident.Subject,
+			Email:         email,
+			EmailVerified: ident.EmailVerified,
+		})
+		if errors.Is(err, models.ErrProviderLinked) {
+			c.JSON(http.StatusConflict, gin.H{"error": "This account is already linked to another " + ident.Provider + " login"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link login"})
+			return
+		}
+		if !created {
+			recordIdentityLink(c, models.MergeVerifiedEmail, user.ID, ident)
+		}
+	}
+
+	// The provider has already confirmed this address
+	if ident.EmailVerified && email != nil &&
+		user.EmailVerifiedAt == nil && user.Email != nil && *user.Email == *email {
+		if err := models.MarkEmailVerified(user.ID); err != nil {
+			log.Printf("⚠️ [OIDCLogin] MarkEmailVerified error: %v", err)
+		} else {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+	}
+
+	// Record session + generate JWT tokens
+	accessToken, refreshToken, err := startSession(c, user)
+	if err != nil {
+		log.Printf("❌ [OIDCLogin] startSession error: %v", err)
+		respondSessionError(c, err)
+		return
+	}
+
+	log.Printf("✅ [OIDCLogin] %s login for user ID=%d (new=%v)", ident.Provider, user.ID, created)
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":             accessToken,
+		"refresh_token":            refreshToken,
+		"provider":                 ident.Provider,
+		"role":                     user.Role,
+		"is_new_user":              created,
+		"is_verified":              user.IsVerified,
+		"needs_phone_verification": !user.IsVerified,
+	})
+}
+
+// ---------------- ListIdentities ----------------
+func ListIdentities(c *gin.Context) {
+	identities, err := models.ListUserIdentities(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked logins"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+// ---------------- LinkIdentity ----------------
+// Links an external login to the caller's account. Needs a recent login or,
+// failing that, the password / 2FA code (requireRecentAuth).
+func LinkIdentity(c *gin.Context) {
+	ident, req, ok := verifyProviderToken(c, c.Param("provider"))
+	if !ok {
+		return
+	}
+	userID := c.GetInt("user_id")
+	user, err := models.GetUserByID(userID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+	if !requireRecentAuth(c, user, req.Password, req.Code, req.RecoveryCode) {
+		return
+	}
+
+	identity := &models.Identity{
+		UserID:        userID,
+		Provider:      ident.Provider,
+		Subject:       ident.Subject,
+		Email:         optionalString(ident.Email),
+		EmailVerified: ident.EmailVerified,
+	}
+	err = models.LinkIdentity(identity)
+	switch {
+	case errors.Is(err, models.ErrIdentityTaken):
+		owner, _ := models.GetUserByIdentity(ident.Provider, ident.Subject)
+		if owner != nil && owner.ID == userID {
+			c.JSON(http.StatusOK, gin.H{"message": "Already linked", "provider": ident.Provider})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "This " + ident.Provider + " login belongs to another account"})
+		return
+	case errors.Is(err, models.ErrProviderLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": "Another " + ident.Provider + " login is already linked, unlink it first"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link login"})
+		return
+	}
+
+	recordIdentityLink(c, models.MergeIdentityLinked, userID, ident)
+	log.Printf("✅ [LinkIdentity] %s linked to user %d", ident.Provider, userID)
+	c.JSON(http.StatusCreated, gin.H{"message": "Login linked", "identity": identity})
+}
+
+// ---------------- UnlinkIdentity ----------------
+// Refuses to remove the last way to log in
+func UnlinkIdentity(c *gin.Context) {
+	provider := c.Param("provider")
+	user, err := models.GetUserByID(c.GetInt("user_id"))
+	if err != nil || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+
+	identities, err := models.CountUserIdentities(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked logins"})
+		return
+	}
+	hasPassword := user.PasswordHash != "" && user.Email != nil
+	if identities <= 1 && !hasPassword {
+		c.JSON(http.StatusConflict, gin.H{"error": "Set a password or link another login before removing this one"})
+		return
+	}
+
+	found, err := models.UnlinkIdentity(user.ID, provider)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink login"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No " + provider + " login linked"})
+		return
+	}
+
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventIdentityUnlinked,
+		UserID:    &user.ID,
+		IP:        c.ClientIP(),
+		Details:   map[string]interface{}{"provider": provider},
+	})
+	log.Printf("✅ [UnlinkIdentity] %s unlinked from user %d", provider, user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Login unlinked", "provider": provider})
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"auth-backend/config"
+	"auth-backend/models"
+	"auth-backend/oidc"
+	"auth-backend/oidc/oidctest"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mockProvider registers a mock OIDC provider as "mock", accepting tokens issued to "cinema"
+func mockProvider(t *testing.T) *oidctest.Server {
+	t.Helper()
+	srv := oidctest.NewServer(t)
+	err := oidc.Init(&config.Config{OIDCProviders: []config.OIDCProvider{
+		{Name: "mock", Type: "oidc", Issuer: srv.URL, ClientIDs: []string{"cinema"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { oidc.Init(&config.Config{}) })
+	return srv
+}
+
+// oidcLogin posts idToken to POST /api/auth/oidc/mock and decodes the response
+func oidcLogin(t *testing.T, idToken string) (int, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/auth/oidc/:provider", OIDCLogin)
+
+	body, _ := json.Marshal(gin.H{"id_token": idToken})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/auth/oidc/mock", bytes.NewReader(body)))
+	resp := map[string]interface{}{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestProviderLoginLinking(t *testing.T) {
+	testDB(t)
+	srv := mockProvider(t)
+	run := time.Now().UnixNano()
+
+	tests := []struct {
+		name            string
+		accountEmail    bool // an account with the token's email exists
+		accountVerified bool
+		linked          bool // the token's subject is already linked to that account
+		tokenVerified   bool
+		wantStatus      int
+		wantAccount     bool // logged into the existing account
+	}{
+		{"new email signs up", false, false, false, true, http.StatusOK, false},
+		{"both verified links to the account", true, true, false, true, http.StatusOK, true},
+		{"unverified provider email", true, true, false, false, http.StatusConflict, false},
+		{"unverified account email", true, false, false, true, http.StatusConflict, false},
+		{"linked login needs no verified email", true, false, true, false, http.StatusOK, true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := fmt.Sprintf("oidc-%d-%d@example.com", run, i)
+			subject := fmt.Sprintf("subject-%d-%d", run, i)
+			accountID := 0
+			if tt.accountEmail {
+				accountID = testUser(t, "customer", email, tt.accountVerified)
+			}
+			if tt.linked {
+				if err := models.LinkIdentity(&models.Identity{UserID: accountID, Provider: "mock", Subject: subject}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			claims := srv.Claims(subject, "cinema")
+			claims["email"], claims["email_verified"] = email, tt.tokenVerified
+			status, resp := oidcLogin(t, srv.Sign(t, claims))
+
+			owner, err := models.GetUserByIdentity("mock", subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner != nil && owner.ID != accountID {
+				t.Cleanup(func() { models.DB.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, owner.ID) })
+			}
+
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, resp)
+			}
+			switch {
+			case tt.wantStatus == http.StatusConflict:
+				if resp["account_exists"] != true || owner != nil {
+					t.Errorf("response = %v, login linked to %v; want account_exists and no link", resp, owner)
+				}
+			case tt.wantAccount:
+				if owner == nil || owner.ID != accountID || resp["is_new_user"] != false {
+					t.Errorf("login linked to %v (new user: %v), want account %d", owner, resp["is_new_user"], accountID)
+				}
+			default:
+				if owner == nil || owner.ID == accountID || resp["is_new_user"] != true {
+					t.Errorf("login linked to %v (new user: %v), want a new account", owner, resp["is_new_user"])
+				}
+			}
+		})
+	}
+}
+
+func TestProviderLoginRejectsInvalidToken(t *testing.T) {
+	testDB(t)
+	srv := mockProvider(t)
+
+	status, _ := oidcLogin(t, srv.Sign(t, srv.Claims("alice", "another-app")))
+	if status != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
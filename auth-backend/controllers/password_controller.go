@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/mailer"
+	"auth-backend/models"
+	"auth-backend/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+// Returned for every forgot-password request so callers can't probe which emails exist
+const passwordResetSentMsg = "If an account exists for this email, a password reset link has been sent."
+
+// ---------------- ForgotPassword → email a reset link ----------------
+func ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email := strings.TrimSpace(strings.ToLower(req.Email))
+
+	// One reset email per address per minute
+	if !cache.AllowRequest("password_reset", email, 1*time.Minute) {
+		c.JSON(http.StatusOK, gin.H{"message": passwordResetSentMsg})
+		return
+	}
+
+	user, err := models.GetUserByEmail(email)
+	if err != nil {
+		log.Printf("❌ [ForgotPassword] GetUserByEmail error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusOK, gin.H{"message": passwordResetSentMsg})
+		return
+	}
+
+	// A mail failure gets the same answer: an error would tell that the account exists
+	if err := sendPasswordResetEmail(user); err != nil {
+		log.Printf("❌ [ForgotPassword] Failed to send reset email to user %d: %v", user.ID, err)
+	} else {
+		log.Printf("📧 [ForgotPassword] Reset link sent to user %d", user.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": passwordResetSentMsg})
+}
+
+// sendPasswordResetEmail issues a fresh reset token and emails the link
+func sendPasswordResetEmail(user *models.User) error {
+	if user.Email == nil || *user.Email == "" {
+		return fmt.Errorf("user has no email address")
+	}
+
+	token, err := models.CreateUserToken(user.ID, models.TokenPasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := mailer.AppURL("/reset-password", url.Values{"token": {token}})
+	body := fmt.Sprintf("Hi %s,\n\nUse the link below to reset your password. It expires in %d minutes and can be used once.\n\n%s\n\nIf you didn't request this, you can ignore this email.",
+		user.Name, int(passwordResetTTL.Minutes()), link)
+	return mailer.Send(*user.Email, "Reset your password", body)
+}
+
+// ---------------- ResetPassword → consume token, set new password ----------------
+func ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := utils.ValidatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := models.ConsumeUserToken(models.TokenPasswordReset, req.Token)
+	if err != nil {
+		log.Printf("❌ [ResetPassword] ConsumeUserToken error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if err := models.UpdatePassword(userID, hash); err != nil {
+		log.Printf("❌ [ResetPassword] UpdatePassword error for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	// Whoever had the old password must not stay logged in
+	if err := revokeSessions(userID, ""); err != nil {
+		log.Printf("⚠️ [ResetPassword] Failed to revoke sessions for user %d: %v", userID, err)
+	}
+	if err := cache.RevokeAllUserTokens(userID, utils.RefreshTokenTTL); err != nil {
+		log.Printf("⚠️ [ResetPassword] Failed to revoke tokens for user %d: %v", userID, err)
+	}
+
+	recordAudit(c, &models.AuditEvent{
+		Action:       models.AuditPasswordReset,
+		ActorID:      &userID, // whoever holds the emailed link
+		TargetUserID: &userID,
+		Diff:         map[string]models.AuditChange{"password": {From: models.AuditRedacted, To: models.AuditRedacted}},
+	})
+	log.Printf("✅ [ResetPassword] Password reset for user %d", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. Please log in."})
+}
+
+// ---------------- ChangePassword → authenticated, requires current password ----------------
+func ChangePassword(c *gin.Context) {
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	user, err := models.GetUserByID(userID.(int))
+	if err != nil || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+
+	if user.PasswordHash == "" || !utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	if err := utils.ValidatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+	if err := models.UpdatePassword(user.ID, hash); err != nil {
+		log.Printf("❌ [ChangePassword] UpdatePassword error for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	// Keep this device logged in, sign out every other one
+	currentSID, _ := c.Get("session_id")
+	sid, _ := currentSID.(string)
+	if err := revokeSessions(user.ID, sid); err != nil {
+		log.Printf("⚠️ [ChangePassword] Failed to revoke other sessions for user %d: %v", user.ID, err)
+	}
+
+	recordAudit(c, &models.AuditEvent{
+		Action:       models.AuditPasswordChanged,
+		TargetUserID: &user.ID,
+		Diff:         map[string]models.AuditChange{"password": {From: models.AuditRedacted, To: models.AuditRedacted}},
+		Details:      map[string]interface{}{"other_sessions_revoked": true},
+	})
+	log.Printf("✅ [ChangePassword] Password changed for user %d", user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed. Other sessions have been signed out."})
+}
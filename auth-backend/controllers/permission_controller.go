@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"auth-backend/models"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------- Escalation guards ----------------
+// Admins can only hand out what they hold themselves. Both helpers rely on
+// "permissions" being set by middleware.RequirePermission.
+
+// callerHolds aborts with 403 unless the caller holds every permission in perms
+func callerHolds(c *gin.Context, perms []string) bool {
+	held := c.GetStringSlice("permissions")
+	for _, p := range perms {
+		found := false
+		for _, h := range held {
+			if h == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot grant a permission you don't hold: " + p})
+			return false
+		}
+	}
+	return true
+}
+
+// canAssignRole aborts unless the role exists and the caller holds all of its permissions
+func canAssignRole(c *gin.Context, role string) bool {
+	perms, err := models.GetRolePermissions(role)
+	if errors.Is(err, models.ErrUnknownRole) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role: " + role})
+		return false
+	}
+	if err != nil {
+		log.Printf("❌ GetRolePermissions error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load role permissions"})
+		return false
+	}
+	return callerHolds(c, perms)
+}
+
+// ---------------- ListPermissions ----------------
+func ListPermissions(c *gin.Context) {
+	perms, err := models.ListPermissions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch permissions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"permissions": perms})
+}
+
+// ---------------- CreateRole ----------------
+func CreateRole(c *gin.Context) {
+	var req struct {
+		Name                 string   `json:"name" binding:"required"`
+		RequireVerifiedEmail bool     `json:"require_verified_email"`
+		RequireMFA           bool     `json:"require_mfa"`
+		Permissions          []string `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !callerHolds(c, req.Permissions) {
+		return
+	}
+
+	role := &models.RolePolicy{
+		Name:                 strings.ToLower(strings.TrimSpace(req.Name)),
+		RequireVerifiedEmail: req.RequireVerifiedEmail,
+		RequireMFA:           req.RequireMFA,
+	}
+	err := models.CreateRole(role, req.Permissions)
+	switch {
+	case errors.Is(err, models.ErrRoleExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Role already exists"})
+		return
+	case errors.Is(err, models.ErrUnknownPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown permission"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create role"})
+		return
+	}
+
+	log.Printf("✅ [CreateRole] Role %q created by user %d", role.Name, c.GetInt("user_id"))
+	c.JSON(http.StatusCreated, gin.H{"role": role, "permissions": req.Permissions})
+}
+
+// ---------------- GetRolePermissions ----------------
+func GetRolePermissions(c *gin.Context) {
+	role := strings.ToLower(c.Param("role"))
+	perms, err := models.GetRolePermissions(role)
+	if errors.Is(err, models.ErrUnknownRole) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch role permissions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"role": role, "permissions": perms})
+}
+
+// ---------------- UpdateRolePermissions ----------------
+// Replaces the role's permission set. Issued access tokens keep their
+// permissions until they expire (15 min); auth-backend itself applies the change immediately.
+func UpdateRolePermissions(c *gin.Context) {
+	var req struct {
+		Permissions []string `json:"permissions" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := strings.ToLower(c.Param("role"))
+	// Both what is added and what is removed must be held by the caller
+	if !canAssignRole(c, role) || !callerHolds(c, req.Permissions) {
+		return
+	}
+
+	err := models.SetRolePermissions(role, req.Permissions)
+	switch {
+	case errors.Is(err, models.ErrLastManager):
+		respondLastManager(c)
+		return
+	case errors.Is(err, models.ErrUnknownPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown permission"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role permissions"})
+		return
+	}
+
+	log.Printf("✅ [UpdateRolePermissions] Role %q now has %v (by user %d)", role, req.Permissions, c.GetInt("user_id"))
+	c.JSON(http.StatusOK, gin.H{"message": "Role permissions updated", "role": role, "permissions": req.Permissions})
+}
+
+// ---------------- GetUserPermissions ----------------
+// Returns the effective permissions of a user and the overrides behind them
+func GetUserPermissions(c *gin.Context) {
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	effective, err := models.GetEffectivePermissions(user.ID, user.RoleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch permissions"})
+		return
+	}
+	overrides, err := models.ListUserPermissionOverrides(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch permission overrides"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     user.ID,
+		"role":        user.Role,
+		"permissions": effective,
+		"overrides":   overrides,
+	})
+}
+
+// ---------------- SetUserPermission ----------------
+// Grants (granted=true) or denies (granted=false) one permission to a user regardless of role
+func SetUserPermission(c *gin.Context) {
+	var req struct {
+		Granted *bool `json:"granted" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	perm := c.Param("perm")
+	if !callerHolds(c, []string{perm}) {
+		return
+	}
+	user, ok := adminLoadUser(c)
+	if !ok || !guardTargetUser(c, user, false) {
+		return
+	}
+
+	err := models.SetUserPermissionOverride(user.ID, perm, *req.Granted)
+	switch {
+	case errors.Is(err, models.ErrLastManager):
+		respondLastManager(c)
+		return
+	case errors.Is(err, models.ErrUnknownPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown permission"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update permission"})
+		return
+	}
+
+	// A denial must not wait for the user's tokens to expire
+	if !*req.Granted {
+		if err := revokeSessions(user.ID, ""); err != nil {
+			log.Printf("⚠️ [SetUserPermission] Failed to revoke sessions of user %d: %v", user.ID, err)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Permission override saved", "permission": perm, "granted": *req.Granted})
+}
+
+// ---------------- DeleteUserPermission ----------------
+// Removes an override so the user falls back to their role's permissions
+func DeleteUserPermission(c *gin.Context) {
+	perm := c.Param("perm")
+	if !callerHolds(c, []string{perm}) {
+		return
+	}
+	user, ok := adminLoadUser(c)
+	if !ok || !guardTargetUser(c, user, false) {
+		return
+	}
+
+	found, err := models.DeleteUserPermissionOverride(user.ID, perm)
+	if errors.Is(err, models.ErrLastManager) {
+		respondLastManager(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove permission override"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No override for this permission"})
+		return
+	}
+	// Dropping a grant can shrink the user's permissions
+	if err := revokeSessions(user.ID, ""); err != nil {
+		log.Printf("⚠️ [DeleteUserPermission] Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Permission override removed", "permission": perm})
+}
+
+// respondLastManager answers 409 for a change models refused with ErrLastManager
+func respondLastManager(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error": "No active user would be left with " + strings.Join(models.ManagementPermissions, " or "),
+	})
+}
+
+// adminLoadUser loads the :id user, writing 400/404/500 itself
+func adminLoadUser(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+	user, err := models.GetUserByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return nil, false
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"auth-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUserPermissionOverridesGuardTarget(t *testing.T) {
+	testDB(t)
+	const path = "/api/admin/users/:id/permissions/:perm"
+	admin, adminID := asCaller(t, "admin", http.MethodPut, path, SetUserPermission)
+	manager, _ := asCaller(t, "manager", http.MethodPut, path, SetUserPermission)
+	customerID := testUser(t, "customer", "", false)
+	otherAdminID := testUser(t, "admin", "", false)
+
+	tests := []struct {
+		name       string
+		caller     http.Handler
+		targetID   int
+		wantStatus int
+	}{
+		{"own account", admin, adminID, http.StatusBadRequest},
+		{"user whose role permissions the caller lacks", manager, otherAdminID, http.StatusForbidden},
+		{"user whose role permissions the caller holds", manager, customerID, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.caller.ServeHTTP(w, httptest.NewRequest(http.MethodPut,
+				"/api/admin/users/"+strconv.Itoa(tt.targetID)+"/permissions/"+models.PermMoviesWrite,
+				strings.NewReader(`{"granted": true}`)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			overrides, err := models.ListUserPermissionOverrides(tt.targetID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if granted := len(overrides) > 0; granted != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("overrides = %v after status %d", overrides, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/models"
+	"auth-backend/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Phone login codes are cached under user 0: the account may not exist yet
+const phoneLoginOTPUser = 0
+
+// respondRateLimited answers 429 for an hourly request cap
+func respondRateLimited(c *gin.Context, msg string) {
+	c.Header("Retry-After", strconv.Itoa(int(time.Hour.Seconds())))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": msg})
+}
+
+// ---------------- PhoneLogin → request a login OTP (no JWT) ----------------
+// Sends a code to any valid number; the answer doesn't tell whether an account exists.
+func PhoneLogin(c *gin.Context) {
+	var req struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !allowPhoneLoginCode(c, phone) {
+		return
+	}
+	if !sendPhoneOTP(c, phoneLoginOTPUser, phone) {
+		return
+	}
+
+	log.Printf("✅ [PhoneLogin] Login OTP sent to %s", phone)
+	c.JSON(http.StatusOK, gin.H{"message": "OTP sent", "phone": phone})
+}
+
+// allowPhoneLoginCode applies the hourly caps on login codes per client IP and per number.
+// On false it has answered 429.
+func allowPhoneLoginCode(c *gin.Context, phone string) bool {
+	if !cache.AllowRequests("phone_login_ip", c.ClientIP(), settings.PhoneLoginMaxPerIP, time.Hour) {
+		log.Printf("⚠️ [PhoneLogin] Too many codes requested from %s", c.ClientIP())
+		respondRateLimited(c, "Too many codes requested, please try again later")
+		return false
+	}
+	if !cache.AllowRequests("phone_login", phone, settings.PhoneLoginMaxPerPhone, time.Hour) {
+		log.Printf("⚠️ [PhoneLogin] Too many codes requested for %s", phone)
+		respondRateLimited(c, "Too many codes requested for this number, please try again later")
+		return false
+	}
+	return true
+}
+
+// ---------------- PhoneLoginVerify → log in or sign up with the code ----------------
+func PhoneLoginVerify(c *gin.Context) {
+	var req struct {
+		Phone string `json:"phone" binding:"required"`
+		OTP   string `json:"otp" binding:"required"`
+		Name  string `json:"name"` // used when the number has no account yet
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Locked out after too many wrong codes
+	if lockout := otpLockout(phoneLoginOTPUser, phone); lockout > 0 {
+		log.Printf("⚠️ [PhoneLoginVerify] OTP locked for phone %s (%s left)", phone, lockout)
+		auditLoginFailure(c, nil, "locked_out", map[string]interface{}{"phone": phone})
+		respondOTPLocked(c, lockout, gin.H{"remaining_attempts": 0})
+		return
+	}
+
+	cachedOTP, err := cache.GetOTP(phoneLoginOTPUser, phone)
+	if err != nil {
+		auditLoginFailure(c, nil, "invalid_otp", map[string]interface{}{"phone": phone})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired OTP"})
+		return
+	}
+	if !utils.CheckOTP(phone, req.OTP, cachedOTP.Hash) {
+		log.Printf("❌ [PhoneLoginVerify] Invalid OTP for request ID=%d", cachedOTP.RequestID)
+		auditLoginFailure(c, nil, "invalid_otp", map[string]interface{}{"phone": phone})
+		rejectOTP(c, phoneLoginOTPUser, phone, cachedOTP)
+		return
+	}
+
+	// The code is spent whatever happens next
+	if err := cache.DeleteOTP(phoneLoginOTPUser, phone); err != nil {
+		log.Printf("⚠️ [PhoneLoginVerify] Failed to delete OTP from cache: %v", err)
+	}
+	cache.ResetFailedOTP(phoneLoginOTPUser, phone)
+	if err := models.MarkOTPVerified(cachedOTP.RequestID); err != nil {
+		log.Printf("⚠️ [PhoneLoginVerify] Failed to mark OTP as verified in DB: %v", err)
+	}
+
+	user, err := models.GetUserByPhone(phone)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	created := false
+	switch {
+	case user == nil:
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = "Cinema User"
+		}
+		user, created, err = models.CreateOrFetchUser(&models.User{
+			Name:        name,
+			PhoneNumber: &phone,
+			Role:        "customer",
+			IsVerified:  true, // the code proved the number
+		}, map[string]interface{}{"loyalty_points": 0})
+		if errors.Is(err, models.ErrUserExists) {
+			// Someone registered the number in the meantime
+			c.JSON(http.StatusConflict, gin.H{"error": "Phone number already in use, please request a new code"})
+			return
+		}
+		if err != nil || user == nil {
+			log.Printf("❌ [PhoneLoginVerify] CreateOrFetchUser error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+			return
+		}
+	case !user.IsVerified:
+		// The number was typed into this account but never confirmed there: owning the number
+		// doesn't prove owning the account
+		log.Printf("⚠️ [PhoneLoginVerify] Phone %s is not verified on user %d, login refused", phone, user.ID)
+		auditLoginFailure(c, user, "phone_not_verified", map[string]interface{}{"phone": phone})
+		c.JSON(http.StatusConflict, gin.H{
+			"error":              "This number isn't verified on its account. Log in another way and verify it from your profile",
+			"phone_not_verified": true,
+		})
+		return
+	}
+	if err := models.AssignOTPRequest(cachedOTP.RequestID, user.ID); err != nil {
+		log.Printf("⚠️ [PhoneLoginVerify] Failed to attach OTP history to user %d: %v", user.ID, err)
+	}
+
+	// Record session + generate JWT tokens
+	accessToken, refreshToken, err := startSession(c, user)
+	if err != nil {
+		log.Printf("❌ [PhoneLoginVerify] startSession error: %v", err)
+		respondSessionError(c, err)
+		return
+	}
+
+	log.Printf("✅ [PhoneLoginVerify] Phone login for user ID=%d (new=%v)", user.ID, created)
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":   accessToken,
+		"refresh_token":  refreshToken,
+		"role":           user.Role,
+		"is_new_user":    created,
+		"is_verified":    true,
+		"email_verified": user.EmailVerifiedAt != nil,
+	})
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/models"
+	"auth-backend/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testPhone returns a number no other test run uses
+func testPhone(t *testing.T) string {
+	t.Helper()
+	phone, err := utils.NormalizePhone(fmt.Sprintf("+2547%08d", rand.Intn(100000000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return phone
+}
+
+func TestAllowPhoneLoginCode(t *testing.T) {
+	testRedis(t)
+	saved := settings
+	cfg := *settings
+	cfg.PhoneLoginMaxPerPhone, cfg.PhoneLoginMaxPerIP = 2, 3
+	settings = &cfg
+	t.Cleanup(func() { settings = saved })
+
+	ip, otherIP := fmt.Sprintf("10.%d.%d.1", rand.Intn(256), rand.Intn(256)), fmt.Sprintf("10.%d.%d.2", rand.Intn(256), rand.Intn(256))
+	first, second, third := testPhone(t), testPhone(t), testPhone(t)
+
+	// Requests count against both caps, even when one of them refuses
+	steps := []struct {
+		name  string
+		ip    string
+		phone string
+		want  bool
+	}{
+		{"first code", ip, first, true},
+		{"second code for the number", ip, first, true},
+		{"number cap reached", ip, first, false},
+		{"IP cap reached", ip, second, false},
+		{"number cap holds from another IP", otherIP, first, false},
+		{"other number from another IP", otherIP, third, true},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, step := range steps {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/phone/login", nil)
+		c.Request.RemoteAddr = step.ip + ":40000"
+
+		if got := allowPhoneLoginCode(c, step.phone); got != step.want {
+			t.Fatalf("%s: allowPhoneLoginCode() = %v, want %v", step.name, got, step.want)
+		}
+		if !step.want && (w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "") {
+			t.Errorf("%s: status = %d, want 429 with Retry-After", step.name, w.Code)
+		}
+	}
+}
+
+func TestPhoneLoginVerify(t *testing.T) {
+	testDB(t)
+	testRedis(t)
+	const code = "123456"
+
+	tests := []struct {
+		name       string
+		account    bool // the number belongs to an account
+		verified   bool // and is verified on it
+		otp        string
+		wantStatus int
+		wantNew    bool
+	}{
+		{"new number signs up", false, false, code, http.StatusOK, true},
+		{"verified number logs in", true, true, code, http.StatusOK, false},
+		{"unverified number is refused", true, false, code, http.StatusConflict, false},
+		{"wrong code", true, true, "654321", http.StatusUnauthorized, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phone := testPhone(t)
+			accountID := 0
+			if tt.account {
+				accountID = testUser(t, "customer", "", false)
+				if _, err := models.DB.Exec(context.Background(),
+					`UPDATE users SET phone=$2, is_verified=$3 WHERE id=$1`, accountID, phone, tt.verified); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := cache.SaveOTP(phoneLoginOTPUser, phone, cache.OTPEntry{Hash: utils.HashOTP(phone, code)}, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { cache.DeleteOTP(phoneLoginOTPUser, phone) })
+
+			r := gin.New()
+			r.POST("/api/auth/phone/login/verify", PhoneLoginVerify)
+			body, _ := json.Marshal(gin.H{"phone": phone, "otp": tt.otp})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/auth/phone/login/verify", bytes.NewReader(body)))
+			resp := map[string]interface{}{}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+
+			owner, err := models.GetUserByPhone(phone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner != nil && owner.ID != accountID {
+				t.Cleanup(func() { models.DB.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, owner.ID) })
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", w.Code, tt.wantStatus, resp)
+			}
+			switch {
+			case tt.wantStatus != http.StatusOK:
+				var sessions int
+				models.DB.QueryRow(context.Background(),
+					`SELECT COUNT(*) FROM user_sessions WHERE user_id=$1`, accountID).Scan(&sessions)
+				if sessions != 0 || resp["access_token"] != nil {
+					t.Errorf("refused login started %d sessions (%v)", sessions, resp)
+				}
+				if tt.wantStatus == http.StatusConflict && resp["phone_not_verified"] != true {
+					t.Errorf("response = %v, want phone_not_verified", resp)
+				}
+			case tt.wantNew:
+				if owner == nil || !owner.IsVerified || resp["is_new_user"] != true {
+					t.Errorf("number belongs to %+v (new user: %v), want a new verified account", owner, resp["is_new_user"])
+				}
+			default:
+				if owner == nil || owner.ID != accountID || resp["is_new_user"] != false {
+					t.Errorf("number belongs to %+v (new user: %v), want account %d", owner, resp["is_new_user"], accountID)
+				}
+			}
+		})
+	}
+}
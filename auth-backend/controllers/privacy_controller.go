@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"archive/zip"
+	cache "auth-backend/cache-management"
+	"auth-backend/mailer"
+	"auth-backend/models"
+	"auth-backend/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const dataExportCooldown = time.Minute
+
+var bookingClient = &http.Client{Timeout: 10 * time.Second}
+
+// fetchUserBookings asks booking-movie for all bookings of a user (raw JSON array)
+func fetchUserBookings(userID int) (json.RawMessage, error) {
+	if settings.BookingServiceURL == "" {
+		return nil, errors.New("BOOKING_SERVICE_URL not set")
+	}
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("%s/api/internal/users/%d/bookings", strings.TrimRight(settings.BookingServiceURL, "/"), userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Internal-Key", settings.InternalAPIKey)
+
+	resp, err := bookingClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("booking service: status %d: %s", resp.StatusCode, body)
+	}
+
+	var out struct {
+		Bookings json.RawMessage `json:"bookings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out.Bookings, nil
+}
+
+// ---------------- ExportMyData ----------------
+// Everything we hold about the caller: profile, OTP history, loyalty points and bookings.
+// ?format=zip returns one JSON file per section instead of a single JSON document.
+func ExportMyData(c *gin.Context) {
+	user, profile, pending, ok := loadProfile(c)
+	if !ok {
+		return
+	}
+	if !cache.AllowRequest("data_export", strconv.Itoa(user.ID), dataExportCooldown) {
+		c.Header("Retry-After", strconv.Itoa(int(dataExportCooldown.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Export recently generated, please wait"})
+		return
+	}
+
+	account := profileView(user, profile, pending)
+	account["created_at"] = user.CreatedAt
+	account["updated_at"] = user.UpdatedAt
+
+	identities, err := models.ListUserIdentities(user.ID)
+	if err != nil {
+		log.Printf("❌ [ExportMyData] ListUserIdentities error for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+	account["identities"] = identities
+
+	otpHistory, err := models.ListOTPHistory(user.ID)
+	if err != nil {
+		log.Printf("❌ [ExportMyData] ListOTPHistory error for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+	otps := make([]gin.H, 0, len(otpHistory))
+	for _, o := range otpHistory {
+		otps = append(otps, gin.H{
+			"phone":           o.Phone,
+			"status":          o.Status,
+			"failed_attempts": o.FailedAttempts,
+			"delivery_error":  o.DeliveryError,
+			"created_at":      o.CreatedAt,
+			"verified_at":     o.VerifiedAt,
+		})
+	}
+
+	var loyaltyPoints *int
+	if points, err := models.GetCustomerPoints(user.ID); err == nil {
+		loyaltyPoints = &points
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("❌ [ExportMyData] GetCustomerPoints error for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+
+	bookings, err := fetchUserBookings(user.ID)
+	if err != nil {
+		log.Printf("❌ [ExportMyData] Failed to fetch bookings of user %d: %v", user.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch bookings, please try again later"})
+		return
+	}
+
+	generatedAt := time.Now().UTC()
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"account", account},
+		{"otp_history", otps},
+		{"loyalty", gin.H{"loyalty_points": loyaltyPoints}},
+		{"bookings", bookings},
+	}
+
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventDataExported,
+		UserID:    &user.ID,
+		IP:        c.ClientIP(),
+		Details:   map[string]interface{}{"format": c.DefaultQuery("format", "json")},
+	})
+
+	filename := fmt.Sprintf("cinema-data-%d-%s", user.ID, generatedAt.Format("20060102"))
+	if c.Query("format") != "zip" {
+		export := gin.H{"generated_at": generatedAt}
+		for _, s := range sections {
+			export[s.name] = s.data
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	c.Status(http.StatusOK)
+	zw := zip.NewWriter(c.Writer)
+	for _, s := range sections {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: s.name + ".json", Method: zip.Deflate, Modified: generatedAt})
+		if err == nil {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(s.data)
+		}
+		if err != nil {
+			log.Printf("❌ [ExportMyData] Failed to write %s.json for user %d: %v", s.name, user.ID, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("❌ [ExportMyData] Failed to finish archive for user %d: %v", user.ID, err)
+	}
+}
+
+// ---------------- RequestAccountDeletion ----------------
+// Deactivates the account and signs it out everywhere right away; personal data is
+// anonymized once the grace period is over (jobs.RunAccountAnonymization). Until then
+// the emailed link cancels the deletion.
+func RequestAccountDeletion(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := models.GetUserByID(c.GetInt("user_id"))
+	if err != nil || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+	// Accounts with a password must confirm it
+	if user.PasswordHash != "" && !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+	if user.Role == "admin" {
+		admins, err := models.CountActiveUsersWithRole("admin")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admins"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "The last active admin cannot delete their account"})
+			return
+		}
+	}
+
+	deleteAt := time.Now().Add(settings.AccountDeletionGrace)
+	// Re-checked with the admin rows locked: two admins may delete their accounts at once
+	err = models.ScheduleAccountDeletion(user.ID, deleteAt)
+	if errors.Is(err, models.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The last active admin cannot delete their account"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ [RequestAccountDeletion] Failed to schedule deletion of user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+	if err := revokeSessions(user.ID, ""); err != nil {
+		log.Printf("⚠️ [RequestAccountDeletion] Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventDeletionRequested,
+		UserID:    &user.ID,
+		IP:        c.ClientIP(),
+		Details:   map[string]interface{}{"delete_at": deleteAt.UTC()},
+	})
+
+	if user.Email != nil {
+		if err := sendDeletionCancelEmail(user, deleteAt); err != nil {
+			log.Printf("⚠️ [RequestAccountDeletion] Failed to email cancel link to user %d: %v", user.ID, err)
+		}
+	}
+
+	log.Printf("🗑️ [RequestAccountDeletion] User %d scheduled for deletion at %s", user.ID, deleteAt.Format(time.RFC3339))
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "Account deactivated and scheduled for deletion",
+		"delete_at": deleteAt.UTC(),
+	})
+}
+
+// sendDeletionCancelEmail emails a link that cancels the deletion while the grace period runs
+func sendDeletionCancelEmail(user *models.User, deleteAt time.Time) error {
+	token, err := models.CreateUserToken(user.ID, models.TokenDeletionCancel, time.Until(deleteAt))
+	if err != nil {
+		return err
+	}
+	link := mailer.AppURL("/cancel-account-deletion", url.Values{"token": {token}})
+	body := fmt.Sprintf("Hi %s,\n\nYour account has been deactivated and will be deleted on %s. Your bookings are kept for our financial records without your personal details.\n\nChanged your mind? Open the link below before then:\n\n%s",
+		user.Name, deleteAt.UTC().Format("2 January 2006"), link)
+	return mailer.Send(*user.Email, "Your account will be deleted", body)
+}
+
+// ---------------- CancelAccountDeletion → consume the emailed token ----------------
+func CancelAccountDeletion(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := models.ConsumeUserToken(models.TokenDeletionCancel, req.Token)
+	if err != nil {
+		log.Printf("❌ [CancelAccountDeletion] ConsumeUserToken error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel deletion"})
+		return
+	}
+	if userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+
+	cancelled, err := models.CancelAccountDeletion(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel deletion"})
+		return
+	}
+	if !cancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "No pending deletion for this account"})
+		return
+	}
+
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventDeletionCancelled,
+		UserID:    &userID,
+		IP:        c.ClientIP(),
+	})
+	log.Printf("✅ [CancelAccountDeletion] Deletion of user %d cancelled", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled, you can log in again"})
+}
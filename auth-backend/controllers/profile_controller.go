@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/mailer"
+	"auth-backend/models"
+	"auth-backend/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+// languageTagPattern accepts BCP 47 style tags such as "en", "pt-BR" or "zh-Hant-TW"
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// profileView is what a user sees of their own account
+func profileView(u *models.User, p *models.Profile, pendingEmail string) gin.H {
+	var dob *string
+	if p.DateOfBirth != nil {
+		d := p.DateOfBirth.Format("2006-01-02")
+		dob = &d
+	}
+	view := gin.H{
+		"user_id":              u.ID,
+		"name":                 u.Name,
+		"email":                u.Email,
+		"email_verified":       u.EmailVerifiedAt != nil,
+		"phone":                u.PhoneNumber,
+		"is_verified":          u.IsVerified,
+		"role":                 u.Role,
+		"avatar_url":           p.AvatarURL,
+		"preferred_language":   p.PreferredLanguage,
+		"date_of_birth":        dob,
+		"marketing_consent":    p.MarketingConsent,
+		"marketing_consent_at": p.MarketingConsentAt,
+	}
+	if pendingEmail != "" {
+		view["pending_email"] = pendingEmail
+	}
+	return view
+}
+
+// loadProfile fetches the caller with their profile and pending email change, writing 404/500 itself
+func loadProfile(c *gin.Context) (*models.User, *models.Profile, string, bool) {
+	userID := c.GetInt("user_id")
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return nil, nil, "", false
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, nil, "", false
+	}
+	profile, err := models.GetProfile(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return nil, nil, "", false
+	}
+	pending, err := models.GetPendingTokenPayload(userID, models.TokenEmailChange)
+	if err != nil {
+		log.Printf("⚠️ [Profile] Failed to read pending email change of user %d: %v", userID, err)
+	}
+	return user, profile, pending, true
+}
+
+// ---------------- GetProfile ----------------
+func GetProfile(c *gin.Context) {
+	user, profile, pending, ok := loadProfile(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profile": profileView(user, profile, pending)})
+}
+
+// ---------------- UpdateProfile ----------------
+// Partial update of the caller's profile. Name and profile details apply at once;
+// a new email waits for the emailed confirmation link and a new phone for the SMS code
+// (POST /api/auth/verify-otp).
+func UpdateProfile(c *gin.Context) {
+	var req struct {
+		Name              *string `json:"name"`
+		Email             *string `json:"email" binding:"omitempty,email"`
+		Phone             *string `json:"phone"`
+		AvatarURL         *string `json:"avatar_url"`         // "" removes it
+		PreferredLanguage *string `json:"preferred_language"` // "" removes it
+		DateOfBirth       *string `json:"date_of_birth"`      // YYYY-MM-DD, "" removes it
+		MarketingConsent  *bool   `json:"marketing_consent"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, profile, pending, ok := loadProfile(c)
+	if !ok {
+		return
+	}
+
+	// ---------------- Validate ----------------
+	name := user.Name
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+			return
+		}
+	}
+	if req.AvatarURL != nil {
+		avatar := strings.TrimSpace(*req.AvatarURL)
+		if avatar != "" && !validAvatarURL(avatar) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "avatar_url must be an http(s) URL"})
+			return
+		}
+		profile.AvatarURL = optionalString(avatar)
+	}
+	if req.PreferredLanguage != nil {
+		lang := strings.TrimSpace(*req.PreferredLanguage)
+		if lang != "" && !languageTagPattern.MatchString(lang) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "preferred_language must be a language tag such as 'en' or 'fr-CA'"})
+			return
+		}
+		profile.PreferredLanguage = optionalString(lang)
+	}
+	if req.DateOfBirth != nil {
+		profile.DateOfBirth = nil
+		if v := strings.TrimSpace(*req.DateOfBirth); v != "" {
+			dob, err := time.Parse("2006-01-02", v)
+			if err != nil || dob.After(time.Now()) || dob.Year() < 1900 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "date_of_birth must be a past date (YYYY-MM-DD)"})
+				return
+			}
+			profile.DateOfBirth = &dob
+		}
+	}
+	if req.MarketingConsent != nil {
+		profile.MarketingConsent = *req.MarketingConsent
+	}
+
+	var newEmail, newPhone string
+	if req.Email != nil {
+		newEmail = strings.ToLower(strings.TrimSpace(*req.Email))
+		if newEmail == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Email cannot be removed"})
+			return
+		}
+		if user.Email != nil && *user.Email == newEmail {
+			newEmail = ""
+		}
+	}
+	if req.Phone != nil {
+		if strings.TrimSpace(*req.Phone) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Phone cannot be removed"})
+			return
+		}
+		phone, err := utils.NormalizePhone(*req.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		newPhone = phone
+		if user.PhoneNumber != nil && *user.PhoneNumber == newPhone && user.IsVerified {
+			newPhone = ""
+		}
+	}
+
+	// ---------------- Email: confirm the new address first ----------------
+	if newEmail != "" {
+		if !requestEmailChange(c, user, newEmail) {
+			return
+		}
+		pending = newEmail
+	}
+
+	// ---------------- Phone: re-verify via OTP ----------------
+	if newPhone != "" {
+		if existing, err := models.GetUserByPhone(newPhone); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		} else if existing != nil && existing.ID != user.ID {
+			c.JSON(http.StatusConflict, gin.H{"error": "Phone number already in use"})
+			return
+		}
+		if !sendPhoneOTP(c, user.ID, newPhone) {
+			return
+		}
+	}
+
+	// ---------------- Save ----------------
+	if err := models.SaveProfile(name, profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+	user.Name = name
+	if updated, err := models.GetProfile(user.ID); err == nil {
+		profile = updated
+	}
+
+	resp := gin.H{"message": "Profile updated", "profile": profileView(user, profile, pending)}
+	if newEmail != "" {
+		resp["email_change"] = "confirmation_sent"
+	}
+	if newPhone != "" {
+		resp["phone_verification"] = "otp_sent"
+	}
+	log.Printf("✅ [UpdateProfile] Profile of user %d updated", user.ID)
+	c.JSON(http.StatusOK, resp)
+}
+
+// requestEmailChange emails a confirmation link to the new address and a notice to the old one.
+// On failure it writes the response and returns false.
+func requestEmailChange(c *gin.Context, user *models.User, newEmail string) bool {
+	existing, err := models.GetUserByEmail(newEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if existing != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already belongs to another account"})
+		return false
+	}
+	if !cache.AllowRequest("email_change", strconv.Itoa(user.ID), emailVerificationCooldown) {
+		c.Header("Retry-After", fmt.Sprintf("%d", int(emailVerificationCooldown.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Confirmation email recently sent, please wait"})
+		return false
+	}
+
+	token, err := models.CreateUserTokenWithPayload(user.ID, models.TokenEmailChange, newEmail, emailChangeTTL)
+	if err != nil {
+		log.Printf("❌ [UpdateProfile] Failed to create email change token for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start email change"})
+		return false
+	}
+	link := mailer.AppURL("/confirm-email-change", url.Values{"token": {token}})
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your new email address by opening the link below. It expires in %d hours.\n\n%s\n\nIf you didn't ask for this, you can ignore this email.",
+		user.Name, int(emailChangeTTL.Hours()), link)
+	if err := mailer.Send(newEmail, "Confirm your new email address", body); err != nil {
+		log.Printf("❌ [UpdateProfile] Failed to send email change confirmation for user %d: %v", user.ID, err)
+		_ = models.CancelUserTokens(user.ID, models.TokenEmailChange)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send confirmation email"})
+		return false
+	}
+
+	// Heads-up to the current address; the change still needs the new one
+	if user.Email != nil {
+		notice := fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email address of your account to %s. It only changes once the new address is confirmed.\n\nIf this wasn't you, change your password.",
+			user.Name, newEmail)
+		if err := mailer.Send(*user.Email, "Your email address is being changed", notice); err != nil {
+			log.Printf("⚠️ [UpdateProfile] Failed to notify old email of user %d: %v", user.ID, err)
+		}
+	}
+	return true
+}
+
+// validAvatarURL accepts absolute http(s) URLs up to 2048 characters
+func validAvatarURL(raw string) bool {
+	if len(raw) > 2048 {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ---------------- ConfirmEmailChange → consume the emailed token ----------------
+func ConfirmEmailChange(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, newEmail, err := models.ConsumeUserTokenWithPayload(models.TokenEmailChange, req.Token)
+	if err != nil {
+		log.Printf("❌ [ConfirmEmailChange] ConsumeUserToken error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		return
+	}
+	if userID == 0 || newEmail == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation token"})
+		return
+	}
+
+	err = models.SetConfirmedEmail(userID, newEmail)
+	if errors.Is(err, models.ErrUserExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already belongs to another account"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		return
+	}
+	// Links sent to the old address must not verify the new one
+	if err := models.CancelUserTokens(userID, models.TokenEmailVerification); err != nil {
+		log.Printf("⚠️ [ConfirmEmailChange] Failed to cancel verification tokens of user %d: %v", userID, err)
+	}
+
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: models.EventEmailChanged,
+		UserID:    &userID,
+		IP:        c.ClientIP(),
+	})
+	log.Printf("✅ [ConfirmEmailChange] Email of user %d changed", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Email changed", "email": newEmail, "email_verified": true})
+}
@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/models"
+	"auth-backend/utils"
+	"cinema-shared/auth"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stepUpMaxAge is how long after a login sensitive changes need no re-authentication
+const stepUpMaxAge = 10 * time.Minute
+
+// errEmailNotVerified blocks login for roles that require a confirmed email
+var errEmailNotVerified = errors.New("email address not verified")
+
+// errAccountDeactivated blocks every login of an account an admin deactivated
+var errAccountDeactivated = errors.New("account deactivated")
+
+// mfaChallengeError means the first factor passed and a TOTP / recovery code is needed
+type mfaChallengeError struct {
+	userID int
+}
+
+func (e *mfaChallengeError) Error() string { return "second factor required" }
+
+// ---------------- startSession → record login + issue tokens ----------------
+// Every successful login goes through here so it shows up in /api/sessions.
+// Users with 2FA get a challenge instead, unless the request already passed 2FA ("mfa" in context).
+func startSession(c *gin.Context, user *models.User) (string, string, error) {
+	if user.DeactivatedAt != nil {
+		auditLoginFailure(c, user, "account_deactivated", nil)
+		return "", "", errAccountDeactivated
+	}
+	if user.EmailVerifiedAt == nil {
+		required, err := models.RoleRequiresVerifiedEmail(user.RoleID)
+		if err != nil {
+			return "", "", fmt.Errorf("load role policy: %w", err)
+		}
+		if required {
+			auditLoginFailure(c, user, "email_not_verified", nil)
+			return "", "", errEmailNotVerified
+		}
+	}
+
+	mfa := c.GetBool("mfa")
+	if !mfa {
+		enabled, err := models.IsMFAEnabled(user.ID)
+		if err != nil {
+			return "", "", fmt.Errorf("load 2FA status: %w", err)
+		}
+		if enabled {
+			return "", "", &mfaChallengeError{userID: user.ID}
+		}
+	}
+
+	sessionID, err := utils.NewTokenID()
+	if err != nil {
+		return "", "", fmt.Errorf("generate session id: %w", err)
+	}
+
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+	if err := models.CreateSession(session); err != nil {
+		return "", "", fmt.Errorf("create session: %w", err)
+	}
+
+	claims, err := accessClaims(user, sessionID, mfa)
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err := utils.GenerateAccessToken(claims)
+	if err != nil {
+		return "", "", fmt.Errorf("generate access token: %w", err)
+	}
+	refreshToken, err := utils.GenerateRefreshToken(user.ID, sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("generate refresh token: %w", err)
+	}
+
+	recordAudit(c, &models.AuditEvent{
+		Action:       models.AuditLoginSucceeded,
+		ActorID:      &user.ID,
+		TargetUserID: &user.ID,
+		Details: map[string]interface{}{
+			"route":      c.FullPath(),
+			"session_id": sessionID,
+			"mfa":        mfa,
+		},
+	})
+	return accessToken, refreshToken, nil
+}
+
+// accessClaims builds the access token payload: role, effective permissions and, for staff, department + location.
+// mfa_required carries the role's 2FA policy, so other services refuse the token while the login lacks it.
+func accessClaims(user *models.User, sessionID string, mfa bool) (*auth.Claims, error) {
+	perms, err := models.GetEffectivePermissions(user.ID, user.RoleID)
+	if err != nil {
+		return nil, fmt.Errorf("load permissions: %w", err)
+	}
+	mfaRequired, err := models.RoleRequiresMFA(user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("load role policy: %w", err)
+	}
+	claims := &auth.Claims{
+		UserID:      user.ID,
+		Role:        user.Role,
+		SessionID:   sessionID,
+		MFA:         mfa,
+		MFARequired: mfaRequired,
+		Perms:       perms,
+	}
+	if user.Role == "staff" {
+		staff, err := models.GetStaffRole(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("load staff assignment: %w", err)
+		}
+		if staff != nil && staff.Dept != nil && staff.LocationID != nil {
+			claims.Dept = *staff.Dept
+			claims.LocationID = *staff.LocationID
+		}
+	}
+	return claims, nil
+}
+
+// respondSessionError maps startSession failures to an HTTP response
+func respondSessionError(c *gin.Context, err error) {
+	var challenge *mfaChallengeError
+	if errors.As(err, &challenge) {
+		token, err := utils.GenerateMFAChallengeToken(challenge.userID)
+		if err != nil {
+			log.Printf("❌ Failed to generate 2FA challenge for user %d: %v", challenge.userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+			return
+		}
+		// Not an error: the client continues at /api/auth/2fa/verify
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    token,
+			"methods":      []string{"totp", "recovery_code"},
+		})
+		return
+	}
+	if errors.Is(err, errAccountDeactivated) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This account has been deactivated", "account_deactivated": true})
+		return
+	}
+	if errors.Is(err, errEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":                    "Please verify your email address before logging in",
+			"needs_email_verification": true,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+}
+
+// revokeSessions marks sessions revoked in the DB and on the shared revocation list
+func revokeSessions(userID int, exceptID string) error {
+	ids, err := models.RevokeUserSessions(userID, exceptID)
+	if err != nil {
+		return err
+	}
+	return cache.RevokeSessions(ids, utils.RefreshTokenTTL)
+}
+
+// revokeSession revokes a single session
+func revokeSession(sessionID string) error {
+	if err := models.RevokeSession(sessionID); err != nil {
+		return err
+	}
+	return cache.RevokeSessions([]string{sessionID}, utils.RefreshTokenTTL)
+}
+
+// requireRecentAuth is the step-up check for sensitive account changes: the session must
+// come from a login within stepUpMaxAge, or the caller confirms with a 2FA code (if enabled)
+// or their password. Responds and returns false when the check fails.
+func requireRecentAuth(c *gin.Context, user *models.User, password, code, recoveryCode string) bool {
+	if session, err := models.GetSessionByID(c.GetString("session_id")); err == nil && session != nil &&
+		time.Since(session.CreatedAt) < stepUpMaxAge {
+		return true
+	}
+
+	mfaEnabled, err := models.IsMFAEnabled(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load 2FA settings"})
+		return false
+	}
+	switch {
+	case mfaEnabled && (code != "" || recoveryCode != ""):
+		return verifySecondFactor(c, user.ID, code, recoveryCode)
+	case mfaEnabled:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Confirm with your authentication code", "step_up_required": "2fa"})
+	case user.PasswordHash != "" && password != "":
+		if utils.CheckPasswordHash(password, user.PasswordHash) {
+			return true
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect", "step_up_required": "password"})
+	case user.PasswordHash != "":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Confirm with your password", "step_up_required": "password"})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Please log in again to continue", "step_up_required": "login"})
+	}
+	return false
+}
+
+// ---------------- ListSessions → current user's active sessions ----------------
+func ListSessions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	currentSID, _ := c.Get("session_id")
+
+	sessions, err := models.GetActiveSessionsByUser(userID.(int))
+	if err != nil {
+		log.Printf("❌ [ListSessions] Failed to fetch sessions for user %v: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	response := []gin.H{}
+	for _, s := range sessions {
+		response = append(response, gin.H{
+			"id":           s.ID,
+			"user_agent":   s.UserAgent,
+			"ip":           s.IP,
+			"created_at":   s.CreatedAt,
+			"last_seen_at": s.LastSeenAt,
+			"current":      s.ID == currentSID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": response})
+}
+
+// ---------------- RevokeSession → log out one of the user's devices ----------------
+func RevokeSession(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	session, err := models.GetSessionByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch session"})
+		return
+	}
+	// Don't reveal other users' session IDs
+	if session == nil || session.UserID != userID.(int) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := revokeSession(session.ID); err != nil {
+		log.Printf("❌ [RevokeSession] Failed to revoke session %s: %v", session.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// ---------------- AdminListUserSessions → any user's active sessions ----------------
+func AdminListUserSessions(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	sessions, err := models.GetActiveSessionsByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+	if sessions == nil {
+		sessions = []*models.Session{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "sessions": sessions})
+}
+
+// ---------------- AdminRevokeUserSession → revoke one session of any user ----------------
+func AdminRevokeUserSession(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	session, err := models.GetSessionByID(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch session"})
+		return
+	}
+	if session == nil || session.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := revokeSession(session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// ---------------- AdminRevokeAllUserSessions → sign a user out everywhere ----------------
+func AdminRevokeAllUserSessions(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := revokeSessions(userID, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions revoked"})
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"auth-backend/config"
+	"time"
+)
+
+// settings holds the runtime policy knobs handlers need (OTP limits, ...)
+var settings = &config.Config{
+	OTPMaxAttempts: 5,
+	OTPLockoutBase: time.Minute,
+	OTPLockoutMax:  time.Hour,
+
+	PhoneLoginMaxPerPhone: 5,
+	PhoneLoginMaxPerIP:    20,
+
+	LoginDelayAfter:    3,
+	LoginMaxFailures:   10,
+	LoginIPMaxFailures: 50,
+	LoginFailureWindow: 15 * time.Minute,
+	LoginLockout:       15 * time.Minute,
+
+	TOTPIssuer: "Cinema",
+
+	MagicLinkTTL:        15 * time.Minute,
+	MagicLinkMaxPerHour: 5,
+
+	AccountDeletionGrace: 30 * 24 * time.Hour,
+}
+
+// Configure passes the loaded config to the handlers; call once from main
+func Configure(cfg *config.Config) {
+	settings = cfg
+}
@@ -0,0 +1,614 @@
+package controllers
+
+import (
+	cache "auth-backend/cache-management"
+	"auth-backend/models"
+	"auth-backend/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------- View helpers ----------------
+
+// adminUserView is the admin API representation of a user (never includes the password hash)
+func adminUserView(u *models.User, roleExtra gin.H) gin.H {
+	view := gin.H{
+		"id":                      u.ID,
+		"name":                    u.Name,
+		"email":                   u.Email,
+		"phone":                   u.PhoneNumber,
+		"role":                    u.Role,
+		"is_verified":             u.IsVerified,
+		"email_verified":          u.EmailVerifiedAt != nil,
+		"active":                  u.DeactivatedAt == nil,
+		"deactivated_at":          u.DeactivatedAt,
+		"password_reset_required": u.PasswordResetRequired,
+		"deletion_scheduled_at":   u.DeletionScheduledAt,
+		"anonymized_at":           u.AnonymizedAt,
+		"created_at":              u.CreatedAt,
+		"updated_at":              u.UpdatedAt,
+	}
+	if roleExtra != nil {
+		view["role_extra"] = roleExtra
+	}
+	return view
+}
+
+// roleExtraOf picks the extension columns that belong to the user's current role
+func roleExtraOf(u *models.UserWithExtra) gin.H {
+	extra := gin.H{}
+	switch u.Role {
+	case "admin", "manager":
+		if u.AdminLevel != nil {
+			extra["level"] = *u.AdminLevel
+		}
+	case "staff":
+		extra["dept"] = u.StaffDept
+		extra["location_id"] = u.StaffLocationID
+	case "customer":
+		if u.LoyaltyPoints != nil {
+			extra["loyalty_points"] = *u.LoyaltyPoints
+		}
+	}
+	return extra
+}
+
+// ---------------- Guardrails ----------------
+
+// guardTargetUser checks that the caller may act on target: not on themselves,
+// only on users whose permissions they hold, and never leaving the system without an active admin.
+// removesAdmin is true when the action takes target out of the active admins. The admin count
+// here is only an early answer: the models re-check it with the admin rows locked (models.ErrLastAdmin).
+func guardTargetUser(c *gin.Context, target *models.User, removesAdmin bool) bool {
+	if target.ID == c.GetInt("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot do this to your own account"})
+		return false
+	}
+	if !canAssignRole(c, target.Role) {
+		return false
+	}
+	if removesAdmin && target.Role == "admin" && target.DeactivatedAt == nil {
+		admins, err := models.CountActiveUsersWithRole("admin")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admins"})
+			return false
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last active admin"})
+			return false
+		}
+	}
+	return true
+}
+
+// recordAdminAction logs an account administration event with the calling admin as actor
+func recordAdminAction(c *gin.Context, eventType string, userID int, details map[string]interface{}) {
+	adminID := c.GetInt("user_id")
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	// Kept in details too: user_id is nulled if the account is deleted later
+	details["target_user_id"] = userID
+	recordSecurityEvent(&models.SecurityEvent{
+		EventType: eventType,
+		UserID:    &userID,
+		ActorID:   &adminID,
+		IP:        c.ClientIP(),
+		Details:   details,
+	})
+}
+
+// ---------------- ListUsers ----------------
+// Paginated search, newest first.
+// Filters: ?role=&verified=true|false&status=active|deactivated&created_from=&created_to=&q=&page=&page_size=
+func ListUsers(c *gin.Context) {
+	filter := models.UserFilter{
+		Role:  strings.ToLower(c.Query("role")),
+		Query: strings.TrimSpace(c.Query("q")),
+	}
+	// Full numbers are stored in E.164, so "0912 345 678" must be searched as "+251912345678"
+	if phone, err := utils.NormalizePhone(filter.Query); err == nil {
+		filter.Query = phone
+	}
+
+	if v := c.Query("verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verified"})
+			return
+		}
+		filter.Verified = &verified
+	}
+	switch c.Query("status") {
+	case "":
+	case "active":
+		deactivated := false
+		filter.Deactivated = &deactivated
+	case "deactivated":
+		deactivated := true
+		filter.Deactivated = &deactivated
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'active' or 'deactivated'"})
+		return
+	}
+
+	var ok bool
+	if filter.CreatedAfter, ok = dateQuery(c, "created_from", false); !ok {
+		return
+	}
+	if filter.CreatedBefore, ok = dateQuery(c, "created_to", true); !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if err != nil || pageSize < 1 || pageSize > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page_size must be between 1 and 200"})
+		return
+	}
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+
+	users, total, err := models.SearchUsers(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	response := []gin.H{}
+	for _, u := range users {
+		view := adminUserView(&u.User, roleExtraOf(u))
+		view["identity_providers"] = u.Providers
+		response = append(response, view)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"users":     response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// dateQuery parses an optional YYYY-MM-DD or RFC 3339 query parameter.
+// With endOfDay, a plain date covers that whole day (exclusive upper bound at the next midnight).
+func dateQuery(c *gin.Context, name string, endOfDay bool) (*time.Time, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, true
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + ", use YYYY-MM-DD or RFC 3339"})
+		return nil, false
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, true
+}
+
+// ---------------- GetUser ----------------
+func GetUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	user, err := models.GetUserWithExtra(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	view := adminUserView(&user.User, roleExtraOf(user))
+	view["identity_providers"] = user.Providers
+	if mfa, err := models.IsMFAEnabled(user.ID); err == nil {
+		view["mfa_enabled"] = mfa
+	}
+	c.JSON(http.StatusOK, gin.H{"user": view})
+}
+
+// ---------------- UpdateUserByAdmin ----------------
+// Edits name / email / phone; omitted fields stay, "" clears email or phone
+func UpdateUserByAdmin(c *gin.Context) {
+	var req struct {
+		Name        *string `json:"name"`
+		Email       *string `json:"email" binding:"omitempty,email"`
+		PhoneNumber *string `json:"phone_number"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	if user.ID != c.GetInt("user_id") && !canAssignRole(c, user.Role) {
+		return
+	}
+
+	changed := []string{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+			return
+		}
+		user.Name = name
+		changed = append(changed, "name")
+	}
+	oldEmail := user.Email
+	if req.Email != nil {
+		user.Email = optionalString(strings.ToLower(*req.Email))
+		changed = append(changed, "email")
+	}
+	oldPhone := user.PhoneNumber
+	if req.PhoneNumber != nil {
+		user.PhoneNumber = nil
+		if strings.TrimSpace(*req.PhoneNumber) != "" {
+			phone, err := utils.NormalizePhone(*req.PhoneNumber)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			user.PhoneNumber = &phone
+		}
+		changed = append(changed, "phone")
+	}
+	if user.Email == nil && user.PhoneNumber == nil {
+		identities, err := models.CountUserIdentities(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count linked logins"})
+			return
+		}
+		if identities == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User needs an email, phone or external login"})
+			return
+		}
+	}
+
+	err := models.UpdateUserProfile(user)
+	if errors.Is(err, models.ErrUserExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email or phone already belongs to another account"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+
+	if !sameOptional(oldEmail, user.Email) {
+		// Links mailed to the old address would verify it, confirm a change away from the new one
+		// or reset the password from an inbox the admin took the account away from
+		for _, purpose := range []string{models.TokenEmailVerification, models.TokenEmailChange, models.TokenPasswordReset} {
+			if err := models.CancelUserTokens(user.ID, purpose); err != nil {
+				log.Printf("⚠️ [UpdateUserByAdmin] Failed to cancel %s tokens of user %d: %v", purpose, user.ID, err)
+			}
+		}
+	}
+	if !sameOptional(oldPhone, user.PhoneNumber) {
+		// Codes sent before would verify (and set back) a number the admin replaced
+		if err := models.ExpireUserOTPs(user.ID); err != nil {
+			log.Printf("⚠️ [UpdateUserByAdmin] Failed to expire OTPs of user %d: %v", user.ID, err)
+		}
+		if err := cache.DeleteUserOTPs(user.ID); err != nil {
+			log.Printf("⚠️ [UpdateUserByAdmin] Failed to clear cached OTPs of user %d: %v", user.ID, err)
+		}
+	}
+
+	recordAdminAction(c, models.EventUserUpdated, user.ID, map[string]interface{}{"fields": changed})
+	updated, err := models.GetUserByID(user.ID)
+	if err != nil || updated == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "User updated"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User updated", "user": adminUserView(updated, nil)})
+}
+
+func sameOptional(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func optionalString(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// ---------------- SetUserRole ----------------
+// Moves a user to any role, in either direction
+func SetUserRole(c *gin.Context) {
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	applyRoleChange(c, user, strings.ToLower(req.Role))
+}
+
+// applyRoleChange moves user to role after the guardrails; writes the response itself
+func applyRoleChange(c *gin.Context, user *models.User, role string) {
+	if user.Role == role {
+		c.JSON(http.StatusOK, gin.H{"message": "User already has this role", "user": adminUserView(user, nil)})
+		return
+	}
+	// The caller must hold both the old role's and the new role's permissions
+	if !guardTargetUser(c, user, role != "admin") || !canAssignRole(c, role) {
+		return
+	}
+
+	roleID, err := models.GetRoleID(role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role"})
+		return
+	}
+	err = models.UpdateUserRole(user.ID, roleID)
+	if errors.Is(err, models.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last active admin"})
+		return
+	}
+	if err == nil {
+		err = models.UpdateRoleDetails(user.ID, role, nil)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role"})
+		return
+	}
+	oldRole := user.Role
+	user.Role = role
+	user.RoleID = roleID
+	// Tokens carry the old role and permissions: force a fresh login
+	if err := revokeSessions(user.ID, ""); err != nil {
+		log.Printf("⚠️ [ChangeUserRole] Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+
+	recordAdminAction(c, models.EventRoleChanged, user.ID, map[string]interface{}{"from": oldRole, "to": role})
+	recordAudit(c, &models.AuditEvent{
+		Action:       models.AuditRoleChanged,
+		TargetUserID: &user.ID,
+		Diff:         map[string]models.AuditChange{"role": {From: oldRole, To: role}},
+		Details:      map[string]interface{}{"route": c.FullPath()},
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User role updated successfully",
+		"user":    adminUserView(user, nil),
+	})
+}
+
+// ---------------- DeactivateUser ----------------
+// Blocks every login and signs the user out everywhere; the account and its data stay
+func DeactivateUser(c *gin.Context) {
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	if user.DeactivatedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "User is already deactivated"})
+		return
+	}
+	if !guardTargetUser(c, user, true) {
+		return
+	}
+
+	err := models.SetUserDeactivated(user.ID, true)
+	if errors.Is(err, models.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last active admin"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate user"})
+		return
+	}
+	if err := revokeSessions(user.ID, ""); err != nil {
+		log.Printf("⚠️ [DeactivateUser] Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+
+	recordAdminAction(c, models.EventUserDeactivated, user.ID, nil)
+	log.Printf("✅ [DeactivateUser] User %d deactivated by %d", user.ID, c.GetInt("user_id"))
+	c.JSON(http.StatusOK, gin.H{"message": "User deactivated"})
+}
+
+// ---------------- ReactivateUser ----------------
+func ReactivateUser(c *gin.Context) {
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	if user.DeactivatedAt == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "User is already active"})
+		return
+	}
+	if user.AnonymizedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This account was deleted and its personal data erased"})
+		return
+	}
+	if !guardTargetUser(c, user, false) {
+		return
+	}
+
+	if err := models.SetUserDeactivated(user.ID, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reactivate user"})
+		return
+	}
+
+	recordAdminAction(c, models.EventUserReactivated, user.ID, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "User reactivated"})
+}
+
+// ---------------- ForcePasswordReset ----------------
+// Blocks password login until the user sets a new password, signs them out and emails a reset link
+func ForcePasswordReset(c *gin.Context) {
+	user, ok := adminLoadUserWithEmail(c)
+	if !ok {
+		return
+	}
+	if !guardTargetUser(c, user, false) {
+		return
+	}
+
+	if err := models.RequirePasswordReset(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to force password reset"})
+		return
+	}
+	if err := revokeSessions(user.ID, ""); err != nil {
+		log.Printf("⚠️ [ForcePasswordReset] Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+
+	emailSent := true
+	if err := sendPasswordResetEmail(user); err != nil {
+		// The user can still request a link via /api/auth/password/forgot
+		log.Printf("⚠️ [ForcePasswordReset] Failed to send reset email to user %d: %v", user.ID, err)
+		emailSent = false
+	}
+
+	recordAdminAction(c, models.EventPasswordResetForced, user.ID, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset required", "email_sent": emailSent})
+}
+
+// ---------------- DeleteUser ----------------
+// Deactivates the account and signs it out everywhere, then erases its personal data like a
+// self-service deletion: after the grace period (jobs.RunAccountAnonymization), or right away
+// with ?immediate=true. The users row stays, so bookings remain attributable.
+func DeleteUser(c *gin.Context) {
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	if user.AnonymizedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This account was already deleted and its personal data erased"})
+		return
+	}
+	if !guardTargetUser(c, user, true) {
+		return
+	}
+	immediate := c.Query("immediate") == "true"
+
+	deleteAt := time.Now().Add(settings.AccountDeletionGrace)
+	if immediate {
+		deleteAt = time.Now()
+	}
+	err := models.ScheduleAccountDeletion(user.ID, deleteAt)
+	if errors.Is(err, models.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last active admin"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ [DeleteUser] Failed to schedule deletion of user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	if err := revokeSessions(user.ID, ""); err != nil {
+		log.Printf("⚠️ [DeleteUser] Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+	if immediate {
+		// Already deactivated and due: the anonymization job retries if this fails
+		if err := models.AnonymizeUser(user.ID); err != nil {
+			log.Printf("❌ [DeleteUser] Failed to anonymize user %d: %v", user.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "User deactivated, but erasing their data failed; it will be retried"})
+			return
+		}
+	}
+
+	recordAdminAction(c, models.EventUserDeleted, user.ID, map[string]interface{}{
+		"role":      user.Role,
+		"immediate": immediate,
+		"delete_at": deleteAt.UTC(),
+	})
+	log.Printf("✅ [DeleteUser] User %d deleted by %d (anonymized at %s)", user.ID, c.GetInt("user_id"), deleteAt.Format(time.RFC3339))
+	if immediate {
+		c.JSON(http.StatusOK, gin.H{"message": "User deleted and personal data erased"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "User deactivated and scheduled for deletion",
+		"delete_at": deleteAt.UTC(),
+	})
+}
+
+// ---------------- ListDepartments ----------------
+func ListDepartments(c *gin.Context) {
+	depts, err := models.ListDepartments()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch departments"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"departments": depts})
+}
+
+// ---------------- SetStaffAssignment ----------------
+// Assigns a staffer to a department and a cinema location (null clears either).
+// Staff tokens carry both, so the user is signed out to pick up the new scope.
+func SetStaffAssignment(c *gin.Context) {
+	var req struct {
+		Dept       *string `json:"dept"`
+		LocationID *int    `json:"location_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.LocationID != nil && *req.LocationID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location_id"})
+		return
+	}
+	user, ok := adminLoadUser(c)
+	if !ok {
+		return
+	}
+	if user.Role != "staff" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only staff can be assigned to a department"})
+		return
+	}
+	if !guardTargetUser(c, user, false) {
+		return
+	}
+
+	assignment := &models.StaffRole{UserID: user.ID, Dept: req.Dept, LocationID: req.LocationID}
+	err := models.CreateOrUpdateStaffRole(assignment)
+	if errors.Is(err, models.ErrUnknownDepartment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown department"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save staff assignment"})
+		return
+	}
+	if err := revokeSessions(user.ID, ""); err != nil {
+		log.Printf("⚠️ [SetStaffAssignment] Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+
+	recordAdminAction(c, models.EventStaffAssigned, user.ID, map[string]interface{}{
+		"dept":        req.Dept,
+		"location_id": req.LocationID,
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "Staff assignment updated", "assignment": assignment})
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"auth-backend/models"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// asCaller serves handler at path for a new user of role, with the role's permissions
+func asCaller(t *testing.T, role, method, path string, handler gin.HandlerFunc) (*gin.Engine, int) {
+	t.Helper()
+	perms, err := models.GetRolePermissions(role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callerID := testUser(t, role, "", false)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Handle(method, path, func(c *gin.Context) {
+		c.Set("user_id", callerID)
+		c.Set("permissions", perms)
+	}, handler)
+	return r, callerID
+}
+
+func TestUpdateUserByAdminEmail(t *testing.T) {
+	testDB(t)
+	r, _ := asCaller(t, "admin", http.MethodPatch, "/api/admin/users/:id", UpdateUserByAdmin)
+	run := time.Now().UnixNano()
+
+	tests := []struct {
+		name         string
+		email        string
+		wantStatus   int
+		wantEmail    string
+		wantTokens   bool // links sent to the old address still work
+		wantVerified bool
+	}{
+		{"invalid email", "not-an-email", http.StatusBadRequest, "old-RUN@example.com", true, true},
+		{"same email in other case", "OLD-RUN@example.com", http.StatusOK, "old-RUN@example.com", true, true},
+		{"new email", "New-RUN@Example.com", http.StatusOK, "new-RUN@example.com", false, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unique := func(s string) string { return strings.ReplaceAll(s, "RUN", fmt.Sprint(run+int64(i))) }
+			id := testUser(t, "customer", unique("old-RUN@example.com"), true)
+			for _, purpose := range []string{models.TokenEmailVerification, models.TokenEmailChange, models.TokenPasswordReset} {
+				if _, err := models.CreateUserToken(id, purpose, time.Hour); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/admin/users/"+strconv.Itoa(id),
+				strings.NewReader(`{"email": "`+unique(tt.email)+`"}`)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			user, err := models.GetUserByID(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := unique(tt.wantEmail); user.Email == nil || *user.Email != want {
+				t.Errorf("email = %v, want %s", user.Email, want)
+			}
+			if (user.EmailVerifiedAt != nil) != tt.wantVerified {
+				t.Errorf("email verified = %v, want %v", user.EmailVerifiedAt != nil, tt.wantVerified)
+			}
+			var pending int
+			models.DB.QueryRow(context.Background(),
+				`SELECT COUNT(*) FROM user_tokens WHERE user_id=$1 AND used_at IS NULL`, id).Scan(&pending)
+			if (pending == 3) != tt.wantTokens {
+				t.Errorf("%d of 3 links still pending, want them kept: %v", pending, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestDeleteUserGuardrails(t *testing.T) {
+	testDB(t)
+	r, callerID := asCaller(t, "admin", http.MethodDelete, "/api/admin/users/:id", DeleteUser)
+	// The caller keeps the admin permissions but doesn't count as an active admin
+	if _, err := models.DB.Exec(context.Background(),
+		`UPDATE users SET deactivated_at=NOW() WHERE id=$1`, callerID); err != nil {
+		t.Fatal(err)
+	}
+	lastAdminID := testUser(t, "admin", "", true)
+	if admins, err := models.CountActiveUsersWithRole("admin"); err != nil || admins != 1 {
+		t.Skip("the test database has other active admins")
+	}
+
+	tests := []struct {
+		name       string
+		target     int
+		wantStatus int
+	}{
+		{"own account", callerID, http.StatusBadRequest},
+		{"last active admin", lastAdminID, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/admin/users/"+strconv.Itoa(tt.target), nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			user, err := models.GetUserByID(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.DeletionScheduledAt != nil {
+				t.Error("the account was scheduled for deletion")
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"auth-backend/models"
+	"log"
+	"time"
+)
+
+// RunAccountAnonymization periodically erases the personal data of accounts whose deletion grace period is over
+func RunAccountAnonymization() {
+	ticker := time.NewTicker(1 * time.Hour)
+
+	go func() {
+		for range ticker.C {
+			ids, err := models.ListAccountsDueForDeletion(100)
+			if err != nil {
+				log.Printf("❌ Account anonymization failed: %v", err)
+				continue
+			}
+			for _, id := range ids {
+				if err := models.AnonymizeUser(id); err != nil {
+					log.Printf("❌ Failed to anonymize user %d: %v", id, err)
+					continue
+				}
+				userID := id
+				if err := models.RecordSecurityEvent(&models.SecurityEvent{
+					EventType: models.EventUserAnonymized,
+					UserID:    &userID,
+				}); err != nil {
+					log.Printf("⚠️ Failed to record anonymization of user %d: %v", id, err)
+				}
+			}
+			if len(ids) > 0 {
+				log.Printf("🗑️ Anonymized %d deleted accounts", len(ids))
+			}
+		}
+	}()
+}
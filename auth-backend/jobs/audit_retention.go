@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"auth-backend/models"
+	"log"
+	"time"
+)
+
+// RunAuditRetention deletes audit events older than retention once a day; 0 keeps them forever
+func RunAuditRetention(retention time.Duration) {
+	if retention <= 0 {
+		log.Println("ℹ️ AUDIT_RETENTION_DAYS is 0, audit events are kept forever")
+		return
+	}
+	ticker := time.NewTicker(24 * time.Hour)
+
+	go func() {
+		for range ticker.C {
+			if count, err := models.PruneAuditEvents(time.Now().Add(-retention)); err != nil {
+				log.Printf("❌ Audit retention failed: %v", err)
+			} else if count > 0 {
+				log.Printf("🗑️ Pruned %d audit events older than %d days", count, int(retention.Hours()/24))
+			}
+		}
+	}()
+}
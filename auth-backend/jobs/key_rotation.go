@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"auth-backend/keys"
+	"auth-backend/models"
+	"log"
+	"time"
+)
+
+// RunKeyRotation keeps the JWT keyring fresh:
+// every minute it reloads keys rotated by other instances, every hour it rotates
+// the active key once it is older than rotateAfter and drops keys retired longer than overlap ago.
+func RunKeyRotation(rotateAfter, overlap time.Duration) {
+	reload := time.NewTicker(1 * time.Minute)
+	rotate := time.NewTicker(1 * time.Hour)
+
+	go func() {
+		for {
+			select {
+			case <-reload.C:
+				if err := keys.Default.Reload(); err != nil {
+					log.Printf("❌ Signing key reload failed: %v", err)
+				}
+			case <-rotate.C:
+				if _, err := keys.Default.Rotate(rotateAfter); err != nil {
+					log.Printf("❌ Signing key rotation failed: %v", err)
+				}
+				if count, err := models.DeleteExpiredSigningKeys(overlap); err != nil {
+					log.Printf("❌ Signing key cleanup failed: %v", err)
+				} else if count > 0 {
+					log.Printf("🗑️ Deleted %d retired signing keys", count)
+				}
+			}
+		}
+	}()
+}
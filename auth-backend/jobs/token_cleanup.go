@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"auth-backend/models"
+	"log"
+	"time"
+)
+
+// RunTokenCleanup periodically deletes expired single-use tokens (password reset, ...)
+func RunTokenCleanup() {
+	ticker := time.NewTicker(1 * time.Hour)
+
+	go func() {
+		for range ticker.C {
+			if count, err := models.DeleteExpiredUserTokens(); err != nil {
+				log.Printf("❌ User token cleanup failed: %v", err)
+			} else {
+				log.Printf("🗑️ Deleted %d expired user tokens", count)
+			}
+		}
+	}()
+}
@@ -0,0 +1,223 @@
+package keys
+
+import (
+	"auth-backend/models"
+	"auth-backend/utils"
+	"cinema-shared/auth"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const rsaKeyBits = 2048
+
+// An unknown kid triggers a reload (key rotated by another instance), at most this often
+const minReload = 30 * time.Second
+
+type entry struct {
+	kid     string
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+// Ring holds the signing keys loaded from the DB; index 0 is the active key
+type Ring struct {
+	mu       sync.RWMutex
+	entries  []*entry
+	lastLoad time.Time // last attempt, successful or not
+}
+
+// Default is the process-wide keyring
+var Default = &Ring{}
+
+// Init loads the keyring, creating the first key if none exists
+func Init() error {
+	if err := Default.Reload(); err != nil {
+		return err
+	}
+	if Default.activeKID() == "" {
+		if _, err := Default.Rotate(0); err != nil {
+			return fmt.Errorf("create initial signing key: %w", err)
+		}
+	}
+	log.Printf("🔑 JWT keyring loaded, active key %s", Default.activeKID())
+	return nil
+}
+
+// Reload re-reads all keys from the DB (picks up rotations done by other instances)
+func (r *Ring) Reload() error {
+	r.mu.Lock()
+	r.lastLoad = time.Now()
+	r.mu.Unlock()
+
+	stored, err := models.ListSigningKeys()
+	if err != nil {
+		return fmt.Errorf("load signing keys: %w", err)
+	}
+
+	entries := make([]*entry, 0, len(stored))
+	for _, k := range stored {
+		e, err := decodeKey(k)
+		if err != nil {
+			log.Printf("⚠️ Skipping unreadable signing key %s: %v", k.KID, err)
+			continue
+		}
+		// Only the first non-retired key may sign
+		if k.RetiredAt != nil || len(entries) > 0 {
+			e.private = nil
+		}
+		entries = append(entries, e)
+	}
+
+	r.mu.Lock()
+	r.entries = entries
+	r.mu.Unlock()
+	return nil
+}
+
+// Rotate generates a new active key unless the current one is younger than minAge.
+// Retired keys keep verifying until the cleanup job drops them.
+func (r *Ring) Rotate(minAge time.Duration) (bool, error) {
+	private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return false, err
+	}
+	kid, err := utils.NewTokenID()
+	if err != nil {
+		return false, err
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	privateEnc, err := utils.EncryptSecret(string(privatePEM))
+	if err != nil {
+		return false, fmt.Errorf("encrypt signing key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		return false, err
+	}
+
+	rotated, err := models.RotateSigningKey(&models.SigningKey{
+		KID:           kid,
+		Algorithm:     auth.Algorithm,
+		PrivateKeyEnc: privateEnc,
+		PublicKeyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}, minAge)
+	if err != nil {
+		return false, err
+	}
+	if err := r.Reload(); err != nil {
+		return rotated, err
+	}
+	if rotated {
+		log.Printf("🔑 JWT signing key rotated, new key %s", kid)
+	}
+	return rotated, nil
+}
+
+// SigningKey returns the active key (auth.SigningKeyProvider)
+func (r *Ring) SigningKey() (string, crypto.Signer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.entries) == 0 || r.entries[0].private == nil {
+		return "", nil, errors.New("no active signing key")
+	}
+	return r.entries[0].kid, r.entries[0].private, nil
+}
+
+// VerificationKey returns the public key for a kid, active or retired, reloading the keys
+// once if it is unknown (auth.KeyResolver)
+func (r *Ring) VerificationKey(kid string) (crypto.PublicKey, bool) {
+	r.mu.Lock()
+	key, ok := r.find(kid)
+	stale := time.Since(r.lastLoad) > minReload
+	if !ok && stale {
+		r.lastLoad = time.Now() // claim the reload so concurrent misses don't pile up
+	}
+	r.mu.Unlock()
+	if ok {
+		return key, true
+	}
+	if !stale {
+		return nil, false
+	}
+
+	if err := r.Reload(); err != nil {
+		log.Printf("⚠️ Failed to reload signing keys for kid %q: %v", kid, err)
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(kid)
+}
+
+// find looks a kid up; the caller holds r.mu
+func (r *Ring) find(kid string) (crypto.PublicKey, bool) {
+	for _, e := range r.entries {
+		if e.kid == kid {
+			return e.public, true
+		}
+	}
+	return nil, false
+}
+
+// JWKS returns every published public key
+func (r *Ring) JWKS() auth.JWKSet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	set := auth.JWKSet{Keys: make([]auth.JWK, 0, len(r.entries))}
+	for _, e := range r.entries {
+		set.Keys = append(set.Keys, auth.NewRSAJWK(e.kid, e.public))
+	}
+	return set
+}
+
+func (r *Ring) activeKID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.entries) == 0 || r.entries[0].private == nil {
+		return ""
+	}
+	return r.entries[0].kid
+}
+
+// decodeKey parses a stored key; the private half is decrypted only for the active key
+func decodeKey(k *models.SigningKey) (*entry, error) {
+	block, _ := pem.Decode([]byte(k.PublicKeyPEM))
+	if block == nil {
+		return nil, errors.New("invalid public key PEM")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	e := &entry{kid: k.KID, public: rsaPub}
+
+	if k.RetiredAt != nil {
+		return e, nil
+	}
+	privatePEM, err := utils.DecryptSecret(k.PrivateKeyEnc)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt private key: %w", err)
+	}
+	block, _ = pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, errors.New("invalid private key PEM")
+	}
+	e.private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
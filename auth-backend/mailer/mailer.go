@@ -0,0 +1,98 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"net/url"
+	"strings"
+
+	"auth-backend/config"
+)
+
+// Mailer delivers plain-text emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+var (
+	current    Mailer
+	appBaseURL string
+)
+
+// Init selects the mail backend from config (MAIL_DRIVER=smtp|log)
+func Init(cfg *config.Config) error {
+	appBaseURL = strings.TrimRight(cfg.AppBaseURL, "/")
+
+	switch strings.ToLower(cfg.MailDriver) {
+	case "smtp":
+		if cfg.SMTPHost == "" || cfg.MailFrom == "" {
+			return fmt.Errorf("SMTP_HOST and MAIL_FROM are required for the smtp mail driver")
+		}
+		current = &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+	case "", "log":
+		current = &LogMailer{}
+	default:
+		return fmt.Errorf("unknown MAIL_DRIVER %q", cfg.MailDriver)
+	}
+
+	log.Printf("✅ Mailer initialized (%T)", current)
+	return nil
+}
+
+// Send delivers an email through the configured backend
+func Send(to, subject, body string) error {
+	if current == nil {
+		return fmt.Errorf("mailer not initialized")
+	}
+	return current.Send(to, subject, body)
+}
+
+// AppURL builds a link into the frontend app (APP_BASE_URL) for emails
+func AppURL(path string, query url.Values) string {
+	return appBaseURL + path + "?" + query.Encode()
+}
+
+// ---------------- SMTP backend ----------------
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{to}, []byte(msg))
+}
+
+// ---------------- Log backend (local development) ----------------
+
+type LogMailer struct{}
+
+func (m *LogMailer) Send(to, subject, body string) error {
+	log.Printf("📧 [LogMailer] To=%s Subject=%q\n%s", to, subject, body)
+	return nil
+}
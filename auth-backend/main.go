@@ -3,11 +3,22 @@ package main
 import (
 	cache "auth-backend/cache-management"
 	"auth-backend/config"
+	"auth-backend/controllers"
 	"auth-backend/jobs"
+	"auth-backend/keys"
+	"auth-backend/mailer"
+	"auth-backend/migrations"
 	"auth-backend/models"
+	"auth-backend/oidc"
 	"auth-backend/routes"
+	"auth-backend/sms"
+	"auth-backend/utils"
+	"cinema-shared/health"
+	"cinema-shared/migrate"
 	"context"
 	"log"
+	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -25,22 +36,106 @@ func main() {
 	}
 	log.Println("✅ Connected to Postgres")
 
+	// ---------------- Phone number region (also used by migrations) ----------------
+	if err := utils.SetPhoneRegion(cfg.PhoneDefaultRegion); err != nil {
+		log.Fatalf("❌ Invalid PHONE_DEFAULT_REGION: %v", err)
+	}
+
+	// ---------------- Schema migrations ----------------
+	schema, err := migrate.Load(migrations.FS, migrations.Code...)
+	if err != nil {
+		log.Fatalf("❌ Invalid migrations: %v", err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate.Command(context.Background(), models.DB, schema, os.Args[2:]); err != nil {
+			log.Fatalf("❌ migrate: %v", err)
+		}
+		return
+	}
+	if err := migrate.Startup(context.Background(), models.DB, schema, cfg.DBAutoMigrate); err != nil {
+		log.Fatalf("❌ Database schema not ready: %v", err)
+	}
+
+	// ---------------- One-off commands ----------------
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "normalize-phones":
+			runNormalizePhones(os.Args[2:])
+		default:
+			log.Fatalf("❌ Unknown command %q", os.Args[1])
+		}
+		return
+	}
+
 	// ---------------- Initialize Redis ----------------
 	if err := cache.Init(cfg); err != nil {
 		log.Fatalf("❌ Failed to connect to Redis: %v", err)
 	}
 	log.Println("✅ Connected to Redis")
 
+	// ---------------- Initialize Mailer ----------------
+	if err := mailer.Init(cfg); err != nil {
+		log.Fatalf("❌ Failed to initialize mailer: %v", err)
+	}
+
+	// ---------------- Initialize SMS provider ----------------
+	if err := sms.Init(cfg); err != nil {
+		log.Fatalf("❌ Failed to initialize SMS provider: %v", err)
+	}
+
+	// ---------------- External login providers ----------------
+	if err := oidc.Init(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure login providers: %v", err)
+	}
+
+	// ---------------- OTP hashing key ----------------
+	if cfg.OTPHMACKey == "" {
+		log.Fatal("❌ OTP_HMAC_KEY must be set")
+	}
+	utils.SetOTPKey(cfg.OTPHMACKey)
+
+	// ---------------- Encryption key for secrets at rest ----------------
+	if cfg.SecretsEncryptionKey == "" {
+		log.Fatal("❌ SECRETS_ENCRYPTION_KEY must be set")
+	}
+	utils.SetSecretKey(cfg.SecretsEncryptionKey)
+
+	// ---------------- JWT signing keys ----------------
+	if err := keys.Init(); err != nil {
+		log.Fatalf("❌ Failed to load JWT signing keys: %v", err)
+	}
+	utils.InitTokens(keys.Default, cache.Revocations{})
+	if cfg.JWTKeyOverlap < utils.RefreshTokenTTL {
+		log.Printf("⚠️ JWT_KEY_OVERLAP_HOURS is shorter than the refresh token lifetime, some refresh tokens will stop verifying after a rotation")
+	}
+
 	// ---------------- Start background jobs ----------------
 	go jobs.RunOTPCleanup()
+	go jobs.RunTokenCleanup()
+	go jobs.RunKeyRotation(cfg.JWTKeyRotation, cfg.JWTKeyOverlap)
+	go jobs.RunAccountAnonymization()
+	go jobs.RunAuditRetention(cfg.AuditRetention)
+
+	if cfg.BookingServiceURL == "" {
+		log.Println("⚠️ BOOKING_SERVICE_URL not set, personal data exports will fail")
+	}
 
 	// ---------------- Setup HTTP routes ----------------
+	controllers.Configure(cfg)
 	router := gin.Default()
 	routes.SetupRoutes(router) // no config needed here
 
+	// ---------------- Health probes ----------------
+	probes := health.New()
+	probes.Add("postgres", health.Postgres(models.DB))
+	probes.Add("redis", cache.Ping)
+	router.GET("/healthz", probes.Live)
+	router.GET("/readyz", probes.Ready)
+
 	// ---------------- Run Server ----------------
 	log.Printf("🚀 Server running on port %s", cfg.Port)
-	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: router}
+	if err := health.ListenAndServe(srv, probes, cfg.ShutdownDrain); err != nil {
+		log.Fatalf("❌ Server error: %v", err)
 	}
 }
@@ -2,94 +2,69 @@ package middleware
 
 import (
 	"auth-backend/models"
-	"fmt"
+	"auth-backend/utils"
+	"cinema-shared/auth"
+	"crypto/subtle"
+	"log"
 	"net/http"
 	"os"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
-
-// --------------------- JWT GENERATION ---------------------
-
-// GenerateJWT creates a short-lived access token
-func GenerateJWT(userID int, role string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     time.Now().Add(15 * time.Minute).Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
-
-// GenerateRefreshJWT creates a long-lived refresh token
-func GenerateRefreshJWT(userID int) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"type":    "refresh",
-		"exp":     time.Now().Add(7 * 24 * time.Hour).Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
-
 // --------------------- MIDDLEWARE ---------------------
 
 // AuthMiddleware validates JWT and attaches user info to context
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid Authorization header"})
-			c.Abort()
+		// Shared rules: RS256, issuer/audience, access type, revocation
+		claims := auth.Authenticate(c, utils.TokenVerifier())
+		if claims == nil {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		user, err := models.GetUserByID(claims.UserID)
+		if err != nil || user == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 			c.Abort()
 			return
 		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		if user.DeactivatedAt != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Account deactivated"})
 			c.Abort()
 			return
 		}
 
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user_id in token"})
+		// The DB is authoritative for role / verification state
+		c.Set("role", user.Role)
+		c.Set("role_id", user.RoleID)
+		c.Set("is_verified", user.IsVerified)
+
+		// Track device activity for /api/sessions
+		if claims.SessionID != "" {
+			if err := models.TouchSession(claims.SessionID); err != nil {
+				log.Printf("⚠️ TouchSession error: %v", err)
+			}
+		}
+		c.Next()
+	}
+}
+
+// InternalMiddleware guards service-to-service endpoints with INTERNAL_API_KEY
+func InternalMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := os.Getenv("INTERNAL_API_KEY")
+		if key == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "INTERNAL_API_KEY not configured"})
 			c.Abort()
 			return
 		}
-		userID := int(userIDFloat)
-
-		user, err := models.GetUserByID(userID)
-		if err != nil || user == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		provided := c.GetHeader("X-Internal-Key")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid internal key"})
 			c.Abort()
 			return
 		}
-
-		// Attach user info to context
-		c.Set("user_id", user.ID)
-		c.Set("role", user.Role)
-		c.Set("is_verified", user.IsVerified)
 		c.Next()
 	}
 }
@@ -113,6 +88,9 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 
 		for _, allowed := range allowedRoles {
 			if userRole == allowed {
+				if !mfaSatisfied(c, userRole) {
+					return
+				}
 				c.Next()
 				return
 			}
@@ -123,35 +101,86 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
-// --------------------- REFRESH TOKEN VALIDATION ---------------------
-
-// ValidateRefreshToken verifies a refresh token and returns the associated user ID
-func ValidateRefreshToken(tokenString string) (int, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
+// RequirePermission allows users holding every listed permission (role + per-user overrides).
+// Permissions are read from the DB, not the token, so changes apply immediately here;
+// the effective set is stored as "permissions" for handlers that guard privilege escalation.
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, err := models.GetEffectivePermissions(c.GetInt("user_id"), c.GetInt("role_id"))
+		if err != nil {
+			log.Printf("❌ GetEffectivePermissions error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load permissions"})
+			c.Abort()
+			return
 		}
-		return jwtSecret, nil
-	})
 
-	if err != nil || !token.Valid {
-		return 0, fmt.Errorf("invalid token")
+		for _, p := range perms {
+			if !containsString(granted, p) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + p})
+				c.Abort()
+				return
+			}
+		}
+		if !mfaSatisfied(c, c.GetString("role")) {
+			return
+		}
+		c.Set("permissions", granted)
+		c.Next()
 	}
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, fmt.Errorf("invalid token claims")
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
 	}
+	return false
+}
 
-	userIDFloat, ok := claims["user_id"].(float64)
-	if !ok {
-		return 0, fmt.Errorf("invalid user_id in token")
+// mfaSatisfied enforces the role's 2FA policy; aborts with 403 when the login skipped 2FA
+func mfaSatisfied(c *gin.Context, role string) bool {
+	if c.GetBool("mfa") {
+		return true
+	}
+	required, err := models.RoleRequiresMFA(role)
+	if err != nil {
+		log.Printf("❌ RoleRequiresMFA error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check 2FA policy"})
+		c.Abort()
+		return false
+	}
+	if !required {
+		return true
 	}
 
-	// Optional: Check token type if refresh token only
-	if typ, exists := claims["type"].(string); exists && typ != "refresh" {
-		return 0, fmt.Errorf("token is not a refresh token")
+	enrolled, err := models.IsMFAEnabled(c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("❌ IsMFAEnabled error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check 2FA policy"})
+		c.Abort()
+		return false
+	}
+	msg := "Two-factor authentication required, please log in again with your authenticator code"
+	if !enrolled {
+		msg = "Two-factor authentication is mandatory for this role, please enroll via /api/auth/2fa/setup"
 	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":        msg,
+		"mfa_required": true,
+		"mfa_enrolled": enrolled,
+	})
+	c.Abort()
+	return false
+}
+
+// --------------------- REFRESH TOKEN VALIDATION ---------------------
 
-	return int(userIDFloat), nil
+// ValidateRefreshToken verifies a refresh token and returns the associated user ID
+func ValidateRefreshToken(tokenString string) (int, error) {
+	claims, err := utils.VerifyToken(tokenString, auth.TypeRefresh)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
 }
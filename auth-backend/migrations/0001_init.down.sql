@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS customer_roles;
+DROP TABLE IF EXISTS staff_roles;
+DROP TABLE IF EXISTS admin_roles;
+DROP TABLE IF EXISTS otp_history;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,57 @@
+-- Baseline: the cinema_auth schema as the original db/init.sql created it. Databases that ran
+-- that script already have all of it, so this version is a no-op there; the changes made since
+-- are the following versions.
+
+-- ---------------- Roles Table ----------------
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL -- "admin", "staff", "customer"
+);
+
+-- ---------------- Users Table ----------------
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    phone TEXT UNIQUE,
+    email TEXT UNIQUE,
+    password_hash TEXT NOT NULL,
+    google_id TEXT UNIQUE,
+    is_verified BOOLEAN DEFAULT FALSE,
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ---------------- OTP History Table ----------------
+CREATE TABLE IF NOT EXISTS otp_history (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    phone TEXT NOT NULL,
+    code TEXT NOT NULL,
+    status TEXT NOT NULL, -- "SENT", "VERIFIED", "FAILED", "EXPIRED"
+    failed_attempts INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW(),
+    verified_at TIMESTAMP
+);
+
+-- ---------------- Admin Roles Table ----------------
+CREATE TABLE IF NOT EXISTS admin_roles (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    level TEXT NOT NULL -- "admin", "manager"
+);
+
+-- ---------------- Staff Roles Table ----------------
+CREATE TABLE IF NOT EXISTS staff_roles (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    dept TEXT NOT NULL
+);
+
+-- ---------------- Customer Roles Table ----------------
+CREATE TABLE IF NOT EXISTS customer_roles (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    loyalty_points INT DEFAULT 0
+);
+
+-- ---------------- Seed roles ----------------
+INSERT INTO roles (name) VALUES ('admin'), ('staff'), ('customer')
+    ON CONFLICT (name) DO NOTHING;
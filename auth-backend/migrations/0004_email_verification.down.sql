@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+ALTER TABLE roles DROP COLUMN IF EXISTS require_verified_email;
@@ -0,0 +1,3 @@
+-- Email confirmation links, and roles that can't log in before confirming
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS require_verified_email BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
@@ -0,0 +1 @@
+ALTER TABLE otp_history DROP COLUMN IF EXISTS delivery_error;
@@ -0,0 +1,2 @@
+-- SMS provider error when status = SEND_FAILED
+ALTER TABLE otp_history ADD COLUMN IF NOT EXISTS delivery_error TEXT;
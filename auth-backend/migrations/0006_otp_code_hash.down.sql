@@ -0,0 +1,4 @@
+-- The plain codes are gone for good: old rows get an empty code
+ALTER TABLE otp_history ADD COLUMN IF NOT EXISTS code TEXT NOT NULL DEFAULT '';
+ALTER TABLE otp_history ALTER COLUMN code DROP DEFAULT;
+ALTER TABLE otp_history DROP COLUMN IF EXISTS code_hash;
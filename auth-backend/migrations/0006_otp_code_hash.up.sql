@@ -0,0 +1,5 @@
+-- OTPs are stored only as HMAC-SHA256 (OTP_HMAC_KEY). Plain codes already stored are dropped;
+-- codes still pending are expired, users request a new one.
+ALTER TABLE otp_history ADD COLUMN IF NOT EXISTS code_hash TEXT; -- NULL if never delivered
+UPDATE otp_history SET status = 'EXPIRED' WHERE status IN ('SENT', 'FAILED') AND code_hash IS NULL;
+ALTER TABLE otp_history DROP COLUMN IF EXISTS code;
@@ -0,0 +1,12 @@
+-- Persistent log of security-relevant events (lockouts, unlocks, ...)
+CREATE TABLE IF NOT EXISTS security_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,            -- "account_locked", "account_unlocked", "ip_locked", ...
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    actor_id INT REFERENCES users(id) ON DELETE SET NULL, -- admin who triggered it, if any
+    ip TEXT,
+    details JSONB,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events(user_id);
+CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events(created_at);
@@ -0,0 +1,29 @@
+-- TOTP two-factor authentication; admin and staff routes need a 2FA-verified login. The policy
+-- is only set when the column is new, so a policy already changed by an admin is kept.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+                   WHERE table_schema = current_schema() AND table_name = 'roles' AND column_name = 'require_mfa') THEN
+        ALTER TABLE roles ADD COLUMN require_mfa BOOLEAN NOT NULL DEFAULT FALSE;
+        UPDATE roles SET require_mfa = TRUE WHERE name IN ('admin', 'staff');
+    END IF;
+END $$;
+
+-- TOTP (RFC 6238) enrollment; the secret is AES-GCM encrypted with SECRETS_ENCRYPTION_KEY
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    totp_secret_enc TEXT NOT NULL,
+    enabled_at TIMESTAMP,                -- NULL until the first code is confirmed
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- rejects replays of an accepted code
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- One-time recovery codes; only the SHA-256 is stored
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
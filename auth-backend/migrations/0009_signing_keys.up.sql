@@ -0,0 +1,9 @@
+-- RS256 keys for issued JWTs; the newest non-retired key signs, all listed keys verify (JWKS)
+CREATE TABLE IF NOT EXISTS signing_keys (
+    kid TEXT PRIMARY KEY,
+    alg TEXT NOT NULL,                   -- "RS256"
+    private_key_enc TEXT NOT NULL,       -- PEM, AES-GCM encrypted with SECRETS_ENCRYPTION_KEY
+    public_key_pem TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    retired_at TIMESTAMP                 -- dropped once JWT_KEY_OVERLAP_HOURS have passed
+);
@@ -0,0 +1,8 @@
+-- Managers go back to being admins with admin_roles.level = 'manager'
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'admin')
+    WHERE role_id = (SELECT id FROM roles WHERE name = 'manager');
+DELETE FROM roles WHERE name = 'manager';
+
+DROP TABLE IF EXISTS user_permissions;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
@@ -0,0 +1,60 @@
+-- Fine-grained capabilities, embedded in access tokens ("perms") and checked by every service
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY, -- "<resource>:<action>", e.g. "movies:write"
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission)
+);
+
+-- Per-user overrides on top of the role: granted = TRUE adds, FALSE removes
+CREATE TABLE IF NOT EXISTS user_permissions (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    granted BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, permission)
+);
+
+INSERT INTO roles (name, require_mfa) VALUES ('manager', TRUE)
+    ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('movies:write', 'Create, update and delete movies'),
+    ('genres:write', 'Create, update and delete genres'),
+    ('halls:write', 'Create, update and delete halls'),
+    ('snacks:write', 'Create, update and delete snacks'),
+    ('schedules:write', 'Create, update and delete schedules and their snacks'),
+    ('bookings:create', 'Book seats'),
+    ('bookings:manage', 'Book on behalf of other users'),
+    ('bookings:refund', 'Refund bookings'),
+    ('users:manage', 'Create users, change roles, manage sessions, lockouts and 2FA'),
+    ('roles:manage', 'Manage roles, their policies and permissions'),
+    ('security:manage', 'Security log, IP lockouts and signing keys')
+    ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+    SELECT r.id, p.name FROM roles r, permissions p WHERE r.name = 'admin'
+    ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+    SELECT r.id, p FROM roles r, unnest(ARRAY[
+        'movies:write', 'genres:write', 'halls:write', 'snacks:write', 'schedules:write',
+        'bookings:create', 'bookings:manage', 'bookings:refund'
+    ]) AS p WHERE r.name = 'manager'
+    ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+    SELECT r.id, p FROM roles r, unnest(ARRAY['bookings:create', 'bookings:manage']) AS p WHERE r.name = 'staff'
+    ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+    SELECT r.id, 'bookings:create' FROM roles r WHERE r.name = 'customer'
+    ON CONFLICT DO NOTHING;
+
+-- Admins created with admin_roles.level = 'manager' become real managers
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'manager')
+    WHERE id IN (SELECT user_id FROM admin_roles WHERE level = 'manager')
+    AND role_id = (SELECT id FROM roles WHERE name = 'admin');
@@ -0,0 +1,7 @@
+DELETE FROM permissions WHERE name = 'locations:write';
+
+ALTER TABLE staff_roles DROP COLUMN IF EXISTS location_id;
+ALTER TABLE staff_roles DROP CONSTRAINT IF EXISTS staff_roles_dept_fkey;
+UPDATE staff_roles SET dept = '' WHERE dept IS NULL;
+ALTER TABLE staff_roles ALTER COLUMN dept SET NOT NULL;
+DROP TABLE IF EXISTS departments;
@@ -0,0 +1,28 @@
+-- Staff departments (carried in staff tokens as "dept") and cinema locations
+CREATE TABLE IF NOT EXISTS departments (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL
+);
+INSERT INTO departments (code, name) VALUES
+    ('box_office', 'Box office'),
+    ('concessions', 'Concessions'),
+    ('projection', 'Projection')
+    ON CONFLICT (code) DO NOTHING;
+
+-- Free-text departments already assigned become departments of their own, so no assignment is lost
+ALTER TABLE staff_roles ALTER COLUMN dept DROP NOT NULL; -- NULL until assigned
+UPDATE staff_roles SET dept = NULL WHERE btrim(dept) = '';
+INSERT INTO departments (code, name)
+    SELECT DISTINCT dept, dept FROM staff_roles WHERE dept IS NOT NULL
+    ON CONFLICT (code) DO NOTHING;
+ALTER TABLE staff_roles DROP CONSTRAINT IF EXISTS staff_roles_dept_fkey;
+ALTER TABLE staff_roles ADD CONSTRAINT staff_roles_dept_fkey
+    FOREIGN KEY (dept) REFERENCES departments(code) ON DELETE SET NULL;
+ALTER TABLE staff_roles ADD COLUMN IF NOT EXISTS location_id INT; -- cinema_scheduling.cinema_locations.id
+
+INSERT INTO permissions (name, description) VALUES
+    ('locations:write', 'Create, update and delete cinema locations')
+    ON CONFLICT (name) DO NOTHING;
+INSERT INTO role_permissions (role_id, permission)
+    SELECT r.id, 'locations:write' FROM roles r WHERE r.name IN ('admin', 'manager')
+    ON CONFLICT DO NOTHING;
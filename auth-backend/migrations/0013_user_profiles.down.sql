@@ -0,0 +1,2 @@
+ALTER TABLE user_tokens DROP COLUMN IF EXISTS payload;
+DROP TABLE IF EXISTS user_profiles;
@@ -0,0 +1,13 @@
+-- Self-service profile details; a user without a row has an empty profile
+CREATE TABLE IF NOT EXISTS user_profiles (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    avatar_url TEXT,
+    preferred_language TEXT,             -- BCP 47 tag, e.g. "en", "fr-CA"
+    date_of_birth DATE,
+    marketing_consent BOOLEAN NOT NULL DEFAULT FALSE,
+    marketing_consent_at TIMESTAMP,      -- when consent was last given or withdrawn
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Purpose-specific token data, e.g. the new address of an email change
+ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS payload TEXT;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS google_id TEXT UNIQUE;
+UPDATE users u SET google_id = i.subject FROM user_identities i WHERE i.user_id = u.id AND i.provider = 'google';
+DROP TABLE IF EXISTS user_identities;
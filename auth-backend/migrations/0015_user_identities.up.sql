@@ -0,0 +1,25 @@
+-- External logins (Google, Apple, Microsoft, ...) linked to a user, one per provider
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,              -- registry name from OIDC_PROVIDERS, e.g. "google"
+    subject TEXT NOT NULL,               -- the provider's stable user ID ("sub")
+    email TEXT,                          -- as reported by the provider at the last login
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    last_login_at TIMESTAMP,
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+-- Google logins used to live in users.google_id
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'google_id') THEN
+        INSERT INTO user_identities (user_id, provider, subject, email, email_verified)
+            SELECT id, 'google', google_id, email, email_verified_at IS NOT NULL FROM users WHERE google_id IS NOT NULL
+            ON CONFLICT DO NOTHING;
+        ALTER TABLE users DROP COLUMN google_id;
+    END IF;
+END $$;
@@ -0,0 +1,15 @@
+-- Audit trail of identities linked to accounts and of merged duplicate accounts.
+-- Plain user IDs (no foreign keys): merged source accounts no longer exist.
+CREATE TABLE IF NOT EXISTS account_merges (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,                  -- "identity_linked", "verified_email", "admin_merge"
+    target_user_id INT NOT NULL,         -- the account that remains
+    source_user_id INT,                  -- admin_merge: the account merged into the target
+    provider TEXT,                       -- identity links: the provider
+    actor_id INT,                        -- admin_merge: the admin
+    ip TEXT,
+    details JSONB,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_account_merges_target_user_id ON account_merges(target_user_id);
+CREATE INDEX IF NOT EXISTS idx_account_merges_source_user_id ON account_merges(source_user_id);
@@ -0,0 +1,39 @@
+-- Append-only trail of who did what: logins, OTP requests, account administration, password changes.
+-- Plain user IDs (no foreign keys) so entries outlive deleted accounts. Rows can only be changed or
+-- removed inside a transaction that sets audit.maintenance (retention, anonymization).
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    action TEXT NOT NULL,                -- "login.succeeded", "login.failed", "user.role_changed", ...
+    actor_id INT,                        -- who did it; NULL for anonymous requests
+    target_user_id INT,                  -- whose account it concerns
+    ip TEXT,
+    user_agent TEXT,
+    diff JSONB,                          -- {"field": {"from": ..., "to": ...}}
+    details JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target_user_id ON audit_events(target_user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF current_setting('audit.maintenance', true) = 'on' THEN
+        IF TG_OP = 'DELETE' THEN
+            RETURN OLD;
+        END IF;
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only (% refused)', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
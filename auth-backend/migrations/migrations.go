@@ -0,0 +1,18 @@
+// Package migrations embeds the service's versioned schema migrations (see cinema-shared/migrate).
+// Never edit a migration once it has been applied anywhere: add the next version instead.
+package migrations
+
+import (
+	"auth-backend/models"
+	"cinema-shared/migrate"
+	"embed"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// Code lists the migrations written in Go, numbered together with the SQL files
+var Code = []*migrate.Migration{
+	// Phone numbers stored before normalization, read in PHONE_DEFAULT_REGION
+	{Version: 18, Name: "normalize_phones", Func: models.NormalizeUserPhonesMigration},
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AnonymizedName replaces the name of an erased account
+const AnonymizedName = "Deleted user"
+
+// ---------------- Schedule / Cancel Deletion ----------------
+
+// ScheduleAccountDeletion deactivates the account now and marks it for anonymization at the given time.
+// Returns ErrLastAdmin when the account is the only active admin.
+func ScheduleAccountDeletion(userID int, at time.Time) error {
+	return withAdminsLocked(userID, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`UPDATE users SET deactivated_at=COALESCE(deactivated_at, NOW()), deletion_scheduled_at=$1, updated_at=NOW()
+			 WHERE id=$2 AND anonymized_at IS NULL`, at, userID)
+		return err
+	})
+}
+
+// CancelAccountDeletion re-enables an account whose deletion is still pending; false if none was
+func CancelAccountDeletion(userID int) (bool, error) {
+	cmdTag, err := DB.Exec(context.Background(),
+		`UPDATE users SET deactivated_at=NULL, deletion_scheduled_at=NULL, updated_at=NOW()
+		 WHERE id=$1 AND deletion_scheduled_at IS NOT NULL AND anonymized_at IS NULL`, userID)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// ListAccountsDueForDeletion returns accounts whose grace period is over
+func ListAccountsDueForDeletion(limit int) ([]int, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT id FROM users
+		 WHERE deletion_scheduled_at <= NOW() AND anonymized_at IS NULL
+		 ORDER BY deletion_scheduled_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ---------------- Anonymize ----------------
+// AnonymizeUser erases the personal data of an account but keeps the users row, so
+// bookings (cinema_booking.bookings.user_id) and their amounts stay intact and attributable
+// to an anonymous account. Logins become impossible: no email, phone, external identity or password.
+func AnonymizeUser(userID int) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET name=$1, phone=NULL, email=NULL, password_hash='',
+		        is_verified=FALSE, email_verified_at=NULL, password_reset_required=FALSE,
+		        deactivated_at=COALESCE(deactivated_at, NOW()), deletion_scheduled_at=NULL,
+		        anonymized_at=NOW(), updated_at=NOW()
+		 WHERE id=$2`, AnonymizedName, userID); err != nil {
+		log.Printf("❌ AnonymizeUser error: %v", err)
+		return err
+	}
+
+	// audit_events refuses updates outside maintenance transactions
+	if _, err := tx.Exec(ctx, `SELECT set_config('audit.maintenance', 'on', true)`); err != nil {
+		return err
+	}
+
+	// Personal data held next to the account
+	for _, q := range []string{
+		`DELETE FROM user_profiles WHERE user_id=$1`,
+		`DELETE FROM user_identities WHERE user_id=$1`,
+		`DELETE FROM otp_history WHERE user_id=$1`,
+		`DELETE FROM user_sessions WHERE user_id=$1`,
+		`DELETE FROM user_tokens WHERE user_id=$1`,
+		`DELETE FROM user_mfa WHERE user_id=$1`,
+		`DELETE FROM mfa_recovery_codes WHERE user_id=$1`,
+		`DELETE FROM user_permissions WHERE user_id=$1`,
+		`UPDATE security_events SET ip=NULL WHERE user_id=$1`,
+		`UPDATE account_merges SET ip=NULL WHERE target_user_id=$1`,
+		// The audit trail keeps what happened, not who from where (needs audit.maintenance, set above)
+		`UPDATE audit_events SET ip=NULL, user_agent=NULL,
+		        diff = diff - 'email' - 'phone' - 'name',
+		        details = details - 'email' - 'phone'
+		 WHERE target_user_id=$1 OR actor_id=$1`,
+	} {
+		if _, err := tx.Exec(ctx, q, userID); err != nil {
+			log.Printf("❌ AnonymizeUser error: %v", err)
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
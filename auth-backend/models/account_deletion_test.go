@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAnonymizeUser(t *testing.T) {
+	testDB(t)
+	ctx := context.Background()
+	userID := testUser(t, "customer")
+	email := fmt.Sprintf("erase-%d@example.com", time.Now().UnixNano())
+	phone := fmt.Sprintf("+1555%07d", userID%10_000_000)
+	if _, err := DB.Exec(ctx,
+		`UPDATE users SET name='Jane Doe', email=$1, phone=$2, password_hash='hash', is_verified=TRUE WHERE id=$3`,
+		email, phone, userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkIdentity(&Identity{UserID: userID, Provider: "google", Subject: email, Email: &email, EmailVerified: true}); err != nil {
+		t.Fatal(err)
+	}
+	token, err := CreateUserToken(userID, TokenPasswordReset, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordAuditEvent(&AuditEvent{
+		Action: AuditUserCreated, ActorID: &userID, TargetUserID: &userID, IP: "203.0.113.7", UserAgent: "test",
+		Diff:    map[string]AuditChange{"email": {To: email}, "role": {To: "customer"}},
+		Details: map[string]interface{}{"phone": phone, "source": "test"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AnonymizeUser(userID); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := GetUserByID(userID)
+	if err != nil || user == nil {
+		t.Fatalf("the users row is gone: %v", err)
+	}
+	if user.Name != AnonymizedName || user.Email != nil || user.PhoneNumber != nil || user.PasswordHash != "" || user.IsVerified {
+		t.Errorf("personal data kept: name %q, email %v, phone %v, password set %v, verified %v",
+			user.Name, user.Email, user.PhoneNumber, user.PasswordHash != "", user.IsVerified)
+	}
+	if user.AnonymizedAt == nil || user.DeactivatedAt == nil {
+		t.Errorf("anonymized_at = %v, deactivated_at = %v; want both set", user.AnonymizedAt, user.DeactivatedAt)
+	}
+	if identities, err := CountUserIdentities(userID); err != nil || identities != 0 {
+		t.Errorf("identities = %d, %v; want none", identities, err)
+	}
+	if id, err := ConsumeUserToken(TokenPasswordReset, token); err != nil || id != 0 {
+		t.Errorf("ConsumeUserToken() = %d, %v; a reset link sent before the erasure still works", id, err)
+	}
+
+	events, _, err := ListAuditEvents(AuditEventFilter{TargetUserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("audit events = %d, want the one recorded", len(events))
+	}
+	e := events[0]
+	if e.Action != AuditUserCreated || e.IP != "" || e.UserAgent != "" {
+		t.Errorf("audit event = %s from %q (%q), want the action without ip or user agent", e.Action, e.IP, e.UserAgent)
+	}
+	if _, ok := e.Diff["email"]; ok || e.Diff["role"].To != "customer" {
+		t.Errorf("audit diff = %v, want the role change without the email", e.Diff)
+	}
+	if _, ok := e.Details["phone"]; ok || e.Details["source"] != "test" {
+		t.Errorf("audit details = %v, want them without the phone", e.Details)
+	}
+}
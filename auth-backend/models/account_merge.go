@@ -0,0 +1,222 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Account merge kinds
+const (
+	MergeIdentityLinked = "identity_linked" // user linked an external login from their profile
+	MergeVerifiedEmail  = "verified_email"  // external login attached to the account with the same verified email
+	MergeAdmin          = "admin_merge"     // admin merged a duplicate account into another
+)
+
+// AccountMerge is one entry of the account merge audit trail
+type AccountMerge struct {
+	ID           int64                  `json:"id"`
+	Kind         string                 `json:"kind"`
+	TargetUserID int                    `json:"target_user_id"`
+	SourceUserID *int                   `json:"source_user_id,omitempty"`
+	Provider     *string                `json:"provider,omitempty"`
+	ActorID      *int                   `json:"actor_id,omitempty"`
+	IP           string                 `json:"ip,omitempty"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// MergeResult reports what MergeUsers moved to the target account
+type MergeResult struct {
+	MovedProviders   []string `json:"moved_providers"`   // identities now linked to the target
+	DroppedProviders []string `json:"dropped_providers"` // the target already had one of that provider
+	LoyaltyPoints    *int     `json:"loyalty_points,omitempty"`
+}
+
+// ---------------- Record Account Merge ----------------
+func RecordAccountMerge(m *AccountMerge) error {
+	details, err := json.Marshal(m.Details)
+	if err != nil {
+		return fmt.Errorf("marshal details: %w", err)
+	}
+	err = DB.QueryRow(context.Background(),
+		`INSERT INTO account_merges (kind, target_user_id, source_user_id, provider, actor_id, ip, details, created_at)
+		 VALUES ($1,$2,$3,$4,$5,NULLIF($6,''),$7,NOW())
+		 RETURNING id, created_at`,
+		m.Kind, m.TargetUserID, m.SourceUserID, m.Provider, m.ActorID, m.IP, details,
+	).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		log.Printf("❌ RecordAccountMerge error: %v", err)
+	}
+	return err
+}
+
+// ListAccountMerges returns the merges a user took part in (as target or source), newest first
+func ListAccountMerges(userID int) ([]*AccountMerge, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, kind, target_user_id, source_user_id, provider, actor_id, COALESCE(ip,''), details, created_at
+		 FROM account_merges
+		 WHERE target_user_id=$1 OR source_user_id=$1
+		 ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merges := []*AccountMerge{}
+	for rows.Next() {
+		m := &AccountMerge{}
+		var details []byte
+		if err := rows.Scan(&m.ID, &m.Kind, &m.TargetUserID, &m.SourceUserID, &m.Provider, &m.ActorID, &m.IP, &details, &m.CreatedAt); err != nil {
+			log.Printf("❌ Scan account merge error: %v", err)
+			return nil, err
+		}
+		if len(details) > 0 {
+			_ = json.Unmarshal(details, &m.Details)
+		}
+		merges = append(merges, m)
+	}
+	return merges, rows.Err()
+}
+
+// ---------------- Merge Users ----------------
+
+// MergeUsers folds the source account into the target and deletes the source, in one transaction.
+// The target keeps its own credentials, role and 2FA; it takes the source's identities (one per
+// provider), OTP and security history, loyalty points, and the email/phone/profile it lacks.
+// m is recorded in the audit trail with the result in its details.
+// Bookings live in booking-movie and must be moved by the caller.
+func MergeUsers(target, source *User, m *AccountMerge) (*MergeResult, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// The source account goes away: it must not be the only active admin
+	if err := lockActiveAdmins(ctx, tx, source.ID); err != nil {
+		return nil, err
+	}
+	// Lock both rows so neither changes while they are merged
+	if _, err := tx.Exec(ctx, `SELECT id FROM users WHERE id IN ($1,$2) FOR UPDATE`, target.ID, source.ID); err != nil {
+		return nil, err
+	}
+
+	result := &MergeResult{MovedProviders: []string{}, DroppedProviders: []string{}}
+
+	// Identities: the target keeps its own login of a provider
+	rows, err := tx.Query(ctx,
+		`UPDATE user_identities SET user_id=$1
+		 WHERE user_id=$2 AND provider NOT IN (SELECT provider FROM user_identities WHERE user_id=$1)
+		 RETURNING provider`, target.ID, source.ID)
+	if err != nil {
+		log.Printf("❌ MergeUsers identities error: %v", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.MovedProviders = append(result.MovedProviders, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows, err = tx.Query(ctx, `SELECT provider FROM user_identities WHERE user_id=$1 ORDER BY provider`, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.DroppedProviders = append(result.DroppedProviders, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Loyalty points add up
+	if target.Role == "customer" && source.Role == "customer" {
+		var points int
+		err := tx.QueryRow(ctx,
+			`INSERT INTO customer_roles (user_id, loyalty_points)
+			 VALUES ($1, COALESCE((SELECT loyalty_points FROM customer_roles WHERE user_id=$2), 0))
+			 ON CONFLICT (user_id) DO UPDATE
+			 SET loyalty_points = COALESCE(customer_roles.loyalty_points, 0) + EXCLUDED.loyalty_points
+			 RETURNING loyalty_points`, target.ID, source.ID).Scan(&points)
+		if err != nil {
+			log.Printf("❌ MergeUsers loyalty error: %v", err)
+			return nil, err
+		}
+		result.LoyaltyPoints = &points
+	}
+
+	// History follows the person; profile only if the target has none
+	for _, q := range []string{
+		`UPDATE otp_history SET user_id=$1 WHERE user_id=$2`,
+		`UPDATE security_events SET user_id=$1 WHERE user_id=$2`,
+		`INSERT INTO user_profiles (user_id, avatar_url, preferred_language, date_of_birth, marketing_consent, marketing_consent_at, updated_at)
+		 SELECT $1::int, avatar_url, preferred_language, date_of_birth, marketing_consent, marketing_consent_at, NOW()
+		 FROM user_profiles WHERE user_id=$2
+		 ON CONFLICT (user_id) DO NOTHING`,
+	} {
+		if _, err := tx.Exec(ctx, q, target.ID, source.ID); err != nil {
+			log.Printf("❌ MergeUsers error: %v", err)
+			return nil, err
+		}
+	}
+
+	// Sessions, tokens, 2FA and permission overrides of the source go with it
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id=$1`, source.ID); err != nil {
+		log.Printf("❌ MergeUsers delete source error: %v", err)
+		return nil, err
+	}
+
+	// Contact details the target lacks, now that the source no longer holds them
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET
+		        email_verified_at = CASE WHEN email IS NULL AND $2::text IS NOT NULL THEN $3 ELSE email_verified_at END,
+		        email = COALESCE(email, $2),
+		        is_verified = CASE WHEN phone IS NULL AND $4::text IS NOT NULL THEN $5 ELSE is_verified END,
+		        phone = COALESCE(phone, $4),
+		        updated_at = NOW()
+		 WHERE id=$1`,
+		target.ID, source.Email, source.EmailVerifiedAt, source.PhoneNumber, source.IsVerified); err != nil {
+		log.Printf("❌ MergeUsers contact details error: %v", err)
+		return nil, err
+	}
+
+	if m.Details == nil {
+		m.Details = map[string]interface{}{}
+	}
+	m.Details["moved_providers"] = result.MovedProviders
+	m.Details["dropped_providers"] = result.DroppedProviders
+	details, err := json.Marshal(m.Details)
+	if err != nil {
+		return nil, fmt.Errorf("marshal details: %w", err)
+	}
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO account_merges (kind, target_user_id, source_user_id, provider, actor_id, ip, details, created_at)
+		 VALUES ($1,$2,$3,$4,$5,NULLIF($6,''),$7,NOW())
+		 RETURNING id, created_at`,
+		m.Kind, m.TargetUserID, m.SourceUserID, m.Provider, m.ActorID, m.IP, details,
+	).Scan(&m.ID, &m.CreatedAt); err != nil {
+		log.Printf("❌ MergeUsers audit error: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
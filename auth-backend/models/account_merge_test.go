@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMergeUsers(t *testing.T) {
+	testDB(t)
+	ctx := context.Background()
+	targetID := testUser(t, "customer")
+	sourceID := testUser(t, "customer")
+
+	for _, q := range []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`INSERT INTO customer_roles (user_id, loyalty_points) VALUES ($1, 10), ($2, 5)`, []interface{}{targetID, sourceID}},
+		// Both have a Google login, only the source an Apple one
+		{`INSERT INTO user_identities (user_id, provider, subject) VALUES ($1, 'google', $2)`, []interface{}{targetID, fmt.Sprint("g-", targetID)}},
+		{`INSERT INTO user_identities (user_id, provider, subject) VALUES ($1, 'google', $2), ($1, 'apple', $3)`,
+			[]interface{}{sourceID, fmt.Sprint("g-", sourceID), fmt.Sprint("a-", sourceID)}},
+		{`UPDATE users SET email='merge-source-' || id || '@example.com' WHERE id=$1`, []interface{}{sourceID}},
+	} {
+		if _, err := DB.Exec(ctx, q.sql, q.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target, err := GetUserByID(targetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := GetUserByID(sourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := MergeUsers(target, source, &AccountMerge{Kind: MergeAdmin, TargetUserID: targetID, SourceUserID: &sourceID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.MovedProviders) != 1 || result.MovedProviders[0] != "apple" {
+		t.Errorf("moved providers = %v, want [apple]", result.MovedProviders)
+	}
+	if len(result.DroppedProviders) != 1 || result.DroppedProviders[0] != "google" {
+		t.Errorf("dropped providers = %v, want [google]", result.DroppedProviders)
+	}
+	if result.LoyaltyPoints == nil || *result.LoyaltyPoints != 15 {
+		t.Errorf("loyalty points = %v, want 15", result.LoyaltyPoints)
+	}
+	if gone, _ := GetUserByID(sourceID); gone != nil {
+		t.Error("the source account still exists")
+	}
+	merged, err := GetUserByID(targetID)
+	if err != nil || merged == nil {
+		t.Fatal(err)
+	}
+	if merged.Email == nil || *merged.Email != *source.Email {
+		t.Errorf("target email = %v, want the source's %v", merged.Email, *source.Email)
+	}
+}
+
+func TestMergeUsersKeepsLastAdmin(t *testing.T) {
+	testDB(t)
+	ctx := context.Background()
+	targetID := testUser(t, "admin")
+	sourceID := testUser(t, "admin")
+
+	// The source is the only active admin: every other admin is deactivated
+	if _, err := DB.Exec(ctx,
+		`UPDATE users SET deactivated_at=NOW() WHERE id=$1`, targetID); err != nil {
+		t.Fatal(err)
+	}
+	var others int
+	if err := DB.QueryRow(ctx,
+		`SELECT COUNT(*) FROM users u JOIN roles r ON u.role_id = r.id
+		 WHERE r.name='admin' AND u.deactivated_at IS NULL AND u.id <> $1`, sourceID).Scan(&others); err != nil {
+		t.Fatal(err)
+	}
+	if others > 0 {
+		t.Skip("the test database has other active admins")
+	}
+
+	target, _ := GetUserByID(targetID)
+	source, _ := GetUserByID(sourceID)
+	if _, err := MergeUsers(target, source, &AccountMerge{Kind: MergeAdmin, TargetUserID: targetID, SourceUserID: &sourceID}); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("MergeUsers() error = %v, want ErrLastAdmin", err)
+	}
+	if still, _ := GetUserByID(sourceID); still == nil {
+		t.Error("the last active admin was deleted")
+	}
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditLoginSucceeded  = "login.succeeded"
+	AuditLoginFailed     = "login.failed"
+	AuditOTPRequested    = "otp.requested"
+	AuditUserCreated     = "user.created"      // by an admin
+	AuditRoleChanged     = "user.role_changed" // by an admin
+	AuditPasswordChanged = "password.changed"  // by the user, knowing the current password
+	AuditPasswordReset   = "password.reset"    // through an emailed reset link
+)
+
+// AuditRedacted stands in for secrets in a diff (password hashes, ...)
+const AuditRedacted = "[redacted]"
+
+// AuditChange is one field of an audit diff; From is nil for created values
+type AuditChange struct {
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// AuditEvent is one entry of the append-only audit trail
+type AuditEvent struct {
+	ID           int64                  `json:"id"`
+	Action       string                 `json:"action"`
+	ActorID      *int                   `json:"actor_id,omitempty"`       // who did it; nil when anonymous (failed logins, ...)
+	TargetUserID *int                   `json:"target_user_id,omitempty"` // whose account it concerns
+	IP           string                 `json:"ip,omitempty"`
+	UserAgent    string                 `json:"user_agent,omitempty"`
+	Diff         map[string]AuditChange `json:"diff,omitempty"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// ---------------- Record Audit Event ----------------
+func RecordAuditEvent(e *AuditEvent) error {
+	// Empty maps are stored as NULL, not as a JSON null
+	var diff, details []byte
+	var err error
+	if len(e.Diff) > 0 {
+		if diff, err = json.Marshal(e.Diff); err != nil {
+			return fmt.Errorf("marshal diff: %w", err)
+		}
+	}
+	if len(e.Details) > 0 {
+		if details, err = json.Marshal(e.Details); err != nil {
+			return fmt.Errorf("marshal details: %w", err)
+		}
+	}
+	err = DB.QueryRow(context.Background(),
+		`INSERT INTO audit_events (action, actor_id, target_user_id, ip, user_agent, diff, details, created_at)
+		 VALUES ($1,$2,$3,NULLIF($4,''),NULLIF($5,''),$6,$7,NOW())
+		 RETURNING id, created_at`,
+		e.Action, e.ActorID, e.TargetUserID, e.IP, e.UserAgent, diff, details,
+	).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		log.Printf("❌ RecordAuditEvent error: %v", err)
+	}
+	return err
+}
+
+// AuditEventFilter narrows ListAuditEvents; zero values mean "any"
+type AuditEventFilter struct {
+	ActorID      int
+	TargetUserID int
+	Action       string // exact, or a prefix ending in "." ("login.")
+	IP           string
+	From         *time.Time
+	To           *time.Time
+	Limit        int
+	Offset       int
+}
+
+// ---------------- List Audit Events ----------------
+// Newest first, with the total number of matches
+func ListAuditEvents(f AuditEventFilter) ([]*AuditEvent, int, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, action, actor_id, target_user_id, COALESCE(ip,''), COALESCE(user_agent,''), diff, details, created_at,
+		        COUNT(*) OVER ()
+		 FROM audit_events
+		 WHERE ($1 = 0 OR actor_id = $1)
+		   AND ($2 = 0 OR target_user_id = $2)
+		   AND ($3 = '' OR action = $3 OR (right($3, 1) = '.' AND starts_with(action, $3)))
+		   AND ($4 = '' OR ip = $4)
+		   AND ($5::timestamp IS NULL OR created_at >= $5)
+		   AND ($6::timestamp IS NULL OR created_at < $6)
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $7 OFFSET $8`,
+		f.ActorID, f.TargetUserID, f.Action, f.IP, f.From, f.To, f.Limit, f.Offset)
+	if err != nil {
+		log.Printf("❌ ListAuditEvents error: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []*AuditEvent{}
+	total := 0
+	for rows.Next() {
+		e := &AuditEvent{}
+		var diff, details []byte
+		if err := rows.Scan(&e.ID, &e.Action, &e.ActorID, &e.TargetUserID, &e.IP, &e.UserAgent, &diff, &details, &e.CreatedAt, &total); err != nil {
+			log.Printf("❌ Scan audit event error: %v", err)
+			return nil, 0, err
+		}
+		if len(diff) > 0 {
+			_ = json.Unmarshal(diff, &e.Diff)
+		}
+		if len(details) > 0 {
+			_ = json.Unmarshal(details, &e.Details)
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
+
+// ---------------- Prune Audit Events ----------------
+// The table refuses updates and deletes unless audit.maintenance is on for the transaction;
+// retention is the only way rows leave it.
+func PruneAuditEvents(before time.Time) (int64, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT set_config('audit.maintenance', 'on', true)`); err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM audit_events WHERE created_at < $1`, before)
+	if err != nil {
+		log.Printf("❌ PruneAuditEvents error: %v", err)
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestListAuditEventsFilters(t *testing.T) {
+	testDB(t)
+
+	// Audit events can't be deleted: a random actor keeps this run's apart
+	actor, target := 1_000_000+rand.Intn(1_000_000_000), 7
+	for _, action := range []string{AuditLoginSucceeded, AuditLoginFailed, AuditPasswordReset, "loginx.other"} {
+		if err := RecordAuditEvent(&AuditEvent{Action: action, ActorID: &actor, TargetUserID: &target, IP: "203.0.113.7"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	future, past := time.Now().Add(48*time.Hour), time.Now().Add(-48*time.Hour)
+
+	tests := []struct {
+		name   string
+		filter AuditEventFilter
+		want   []string
+	}{
+		{"actor", AuditEventFilter{ActorID: actor}, []string{"loginx.other", AuditPasswordReset, AuditLoginFailed, AuditLoginSucceeded}},
+		{"exact action", AuditEventFilter{ActorID: actor, Action: AuditLoginFailed}, []string{AuditLoginFailed}},
+		{"action prefix", AuditEventFilter{ActorID: actor, Action: "login."}, []string{AuditLoginFailed, AuditLoginSucceeded}},
+		{"no prefix without the dot", AuditEventFilter{ActorID: actor, Action: "login"}, nil},
+		{"ip", AuditEventFilter{ActorID: actor, IP: "198.51.100.1"}, nil},
+		{"target", AuditEventFilter{ActorID: actor, TargetUserID: target + 1}, nil},
+		{"from in the future", AuditEventFilter{ActorID: actor, From: &future}, nil},
+		{"to in the past", AuditEventFilter{ActorID: actor, To: &past}, nil},
+		{"page", AuditEventFilter{ActorID: actor, Limit: 2, Offset: 1}, []string{AuditPasswordReset, AuditLoginFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, total, err := ListAuditEvents(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range events {
+				got = append(got, e.Action)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("actions = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("actions = %v, want %v", got, tt.want)
+				}
+			}
+			if tt.filter.Limit == 0 && total != len(tt.want) {
+				t.Errorf("total = %d, want %d", total, len(tt.want))
+			}
+		})
+	}
+}
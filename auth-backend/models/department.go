@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrUnknownDepartment is returned when a department code isn't in the departments table
+var ErrUnknownDepartment = errors.New("unknown department")
+
+// Department codes seeded by migrations/0012_departments.up.sql; staff tokens carry them as "dept"
+const (
+	DeptBoxOffice   = "box_office"
+	DeptConcessions = "concessions"
+	DeptProjection  = "projection"
+)
+
+type Department struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func ListDepartments() ([]*Department, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT code, name FROM departments ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	depts := []*Department{}
+	for rows.Next() {
+		d := &Department{}
+		if err := rows.Scan(&d.Code, &d.Name); err != nil {
+			return nil, err
+		}
+		depts = append(depts, d)
+	}
+	return depts, rows.Err()
+}
+
+// DepartmentExists reports whether code is a known department
+func DepartmentExists(code string) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(context.Background(),
+		`SELECT TRUE FROM departments WHERE code=$1`, code).Scan(&exists)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return exists, err
+}
+
+// departmentError maps a foreign key violation on departments(code) to ErrUnknownDepartment
+func departmentError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrUnknownDepartment
+	}
+	return err
+}
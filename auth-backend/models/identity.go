@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrIdentityTaken is returned when the external identity is linked to another user
+	ErrIdentityTaken = errors.New("identity linked to another user")
+	// ErrProviderLinked is returned when the user already has an identity of that provider
+	ErrProviderLinked = errors.New("provider already linked")
+)
+
+// Identity is an external login (OIDC / OAuth2 provider account) linked to a user
+type Identity struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"-"`
+	Provider      string     `json:"provider"`
+	Subject       string     `json:"-"`
+	Email         *string    `json:"email,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	CreatedAt     time.Time  `json:"linked_at"`
+	LastLoginAt   *time.Time `json:"last_login_at,omitempty"`
+}
+
+// ---------------- Lookup ----------------
+
+// GetUserByIdentity returns the user linked to a provider account (nil if none)
+func GetUserByIdentity(provider, subject string) (*User, error) {
+	u := &User{}
+	err := scanUser(DB.QueryRow(context.Background(), userSelect+`
+		JOIN user_identities i ON i.user_id = u.id
+		WHERE i.provider=$1 AND i.subject=$2`, provider, subject), u)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("❌ GetUserByIdentity error: %v", err)
+		return nil, err
+	}
+	return u, nil
+}
+
+func ListUserIdentities(userID int) ([]*Identity, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, user_id, provider, subject, email, email_verified, created_at, last_login_at
+		 FROM user_identities WHERE user_id=$1 ORDER BY provider`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*Identity{}
+	for rows.Next() {
+		i := &Identity{}
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.EmailVerified, &i.CreatedAt, &i.LastLoginAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
+
+func CountUserIdentities(userID int) (int, error) {
+	var n int
+	err := DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM user_identities WHERE user_id=$1`, userID).Scan(&n)
+	return n, err
+}
+
+// ---------------- Link / Unlink ----------------
+
+// LinkIdentity attaches a provider account to a user.
+// Returns ErrIdentityTaken or ErrProviderLinked when a unique constraint is hit.
+func LinkIdentity(i *Identity) error {
+	err := DB.QueryRow(context.Background(),
+		`INSERT INTO user_identities (user_id, provider, subject, email, email_verified, created_at, last_login_at)
+		 VALUES ($1,$2,$3,$4,$5,NOW(),NOW())
+		 RETURNING id, created_at, last_login_at`,
+		i.UserID, i.Provider, i.Subject, i.Email, i.EmailVerified,
+	).Scan(&i.ID, &i.CreatedAt, &i.LastLoginAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			if pgErr.ConstraintName == "user_identities_user_id_provider_key" {
+				return ErrProviderLinked
+			}
+			return ErrIdentityTaken
+		}
+		log.Printf("❌ LinkIdentity error: %v", err)
+	}
+	return err
+}
+
+// TouchIdentity records a login and refreshes what the provider reports about the email
+func TouchIdentity(provider, subject string, email *string, emailVerified bool) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE user_identities SET email=COALESCE($3, email), email_verified=$4, last_login_at=NOW()
+		 WHERE provider=$1 AND subject=$2`, provider, subject, email, emailVerified)
+	return err
+}
+
+// UnlinkIdentity removes the user's identity of a provider; false if there was none
+func UnlinkIdentity(userID int, provider string) (bool, error) {
+	cmdTag, err := DB.Exec(context.Background(),
+		`DELETE FROM user_identities WHERE user_id=$1 AND provider=$2`, userID, provider)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UserMFA holds a user's TOTP enrollment; the secret is encrypted at rest
+type UserMFA struct {
+	UserID       int
+	SecretEnc    string
+	EnabledAt    *time.Time // nil while enrollment is pending confirmation
+	LastUsedStep int64      // last accepted TOTP time step (replay protection)
+	CreatedAt    time.Time
+}
+
+// ---------------- Get MFA enrollment ----------------
+func GetUserMFA(userID int) (*UserMFA, error) {
+	m := &UserMFA{}
+	err := DB.QueryRow(context.Background(),
+		`SELECT user_id, totp_secret_enc, enabled_at, last_used_step, created_at
+		 FROM user_mfa WHERE user_id=$1`, userID,
+	).Scan(&m.UserID, &m.SecretEnc, &m.EnabledAt, &m.LastUsedStep, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("❌ GetUserMFA error: %v", err)
+		return nil, err
+	}
+	return m, nil
+}
+
+// IsMFAEnabled reports whether the user has confirmed a TOTP enrollment
+func IsMFAEnabled(userID int) (bool, error) {
+	var enabled bool
+	err := DB.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM user_mfa WHERE user_id=$1 AND enabled_at IS NOT NULL)`,
+		userID).Scan(&enabled)
+	return enabled, err
+}
+
+// ---------------- Save pending enrollment ----------------
+// Replaces any unconfirmed secret; callers must not call this once MFA is enabled
+func SavePendingMFA(userID int, secretEnc string) error {
+	_, err := DB.Exec(context.Background(),
+		`INSERT INTO user_mfa (user_id, totp_secret_enc, enabled_at, last_used_step, created_at)
+		 VALUES ($1,$2,NULL,0,NOW())
+		 ON CONFLICT (user_id) DO UPDATE
+		 SET totp_secret_enc=$2, enabled_at=NULL, last_used_step=0, created_at=NOW()
+		 WHERE user_mfa.enabled_at IS NULL`, userID, secretEnc)
+	if err != nil {
+		log.Printf("❌ SavePendingMFA error: %v", err)
+	}
+	return err
+}
+
+// ---------------- Enable MFA ----------------
+func EnableMFA(userID int, step int64) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE user_mfa SET enabled_at=NOW(), last_used_step=$2 WHERE user_id=$1`, userID, step)
+	return err
+}
+
+// UseTOTPStep records an accepted code; false if that step (or a later one) was already used
+func UseTOTPStep(userID int, step int64) (bool, error) {
+	cmdTag, err := DB.Exec(context.Background(),
+		`UPDATE user_mfa SET last_used_step=$2 WHERE user_id=$1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// ---------------- Disable MFA ----------------
+// Removes the enrollment and every recovery code
+func DeleteUserMFA(userID int) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_mfa WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ---------------- Recovery codes ----------------
+
+// ReplaceRecoveryCodes invalidates existing codes and stores the new hashes
+func ReplaceRecoveryCodes(userID int, hashes []string) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at) VALUES ($1,$2,NOW())`,
+			userID, h); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// UseRecoveryCode burns a recovery code; false if it doesn't exist or was already used
+func UseRecoveryCode(userID int, hash string) (bool, error) {
+	cmdTag, err := DB.Exec(context.Background(),
+		`UPDATE mfa_recovery_codes SET used_at=NOW()
+		 WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes are left
+func CountRecoveryCodes(userID int) (int, error) {
+	var n int
+	err := DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id=$1 AND used_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
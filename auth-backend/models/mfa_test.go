@@ -0,0 +1,81 @@
+package models
+
+import "testing"
+
+func TestUseRecoveryCode(t *testing.T) {
+	testDB(t)
+	userID := testUser(t, "customer")
+	if err := ReplaceRecoveryCodes(userID, []string{"hash-a", "hash-b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name   string
+		userID int
+		hash   string
+		want   bool
+		left   int
+	}{
+		{"unused code", userID, "hash-a", true, 1},
+		{"same code again", userID, "hash-a", false, 1},
+		{"unknown code", userID, "hash-c", false, 1},
+		{"another user's code", userID + 1_000_000, "hash-b", false, 1},
+		{"last code", userID, "hash-b", true, 0},
+	}
+	for _, s := range steps {
+		ok, err := UseRecoveryCode(s.userID, s.hash)
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if ok != s.want {
+			t.Errorf("%s: UseRecoveryCode = %v, want %v", s.name, ok, s.want)
+		}
+		if left, err := CountRecoveryCodes(userID); err != nil || left != s.left {
+			t.Errorf("%s: %d codes left (%v), want %d", s.name, left, err, s.left)
+		}
+	}
+
+	// New codes invalidate the old ones, used or not
+	if err := ReplaceRecoveryCodes(userID, []string{"hash-d"}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := UseRecoveryCode(userID, "hash-b"); ok {
+		t.Error("a replaced code was accepted")
+	}
+	if ok, _ := UseRecoveryCode(userID, "hash-d"); !ok {
+		t.Error("a new code was refused")
+	}
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	testDB(t)
+	userID := testUser(t, "customer")
+	if err := SavePendingMFA(userID, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	// Enabling consumes the step of the confirmation code
+	if err := EnableMFA(userID, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name string
+		step int64
+		want bool
+	}{
+		{"confirmation code replayed", 100, false},
+		{"next step", 101, true},
+		{"same step again", 101, false},
+		{"earlier step within the window", 100, false},
+		{"later step", 103, true},
+	}
+	for _, s := range steps {
+		ok, err := UseTOTPStep(userID, s.step)
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if ok != s.want {
+			t.Errorf("%s: UseTOTPStep(%d) = %v, want %v", s.name, s.step, ok, s.want)
+		}
+	}
+}
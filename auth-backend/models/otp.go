@@ -11,43 +11,71 @@ type OTP struct {
 	ID             int
 	UserID         int
 	Phone          string
-	Code           string
-	Status         string // "SENT", "VERIFIED", "FAILED", "EXPIRED"
+	CodeHash       *string // HMAC of the code, never the code itself
+	Status         string  // "SENT", "FAILED" (pending); "SEND_FAILED", "VERIFIED", "LOCKED", "EXPIRED" (terminal)
 	FailedAttempts int
+	DeliveryError  *string
 	CreatedAt      time.Time
 	VerifiedAt     *time.Time
 }
 
 // ---------------- Save OTP Request to History ----------------
-func SaveOTPRequest(userID int, phone, code string) error {
+// Returns the otp_history ID used to track this OTP afterwards
+func SaveOTPRequest(userID int, phone, codeHash string) (int, error) {
+	var id int
 	query := `
-        INSERT INTO otp_history (user_id, phone, code, status, failed_attempts, created_at)
-        VALUES ($1, $2, $3, 'SENT', 0, NOW())`
-	_, err := DB.Exec(context.Background(), query, userID, phone, code)
+        INSERT INTO otp_history (user_id, phone, code_hash, status, failed_attempts, created_at)
+        VALUES (NULLIF($1, 0), $2, $3, 'SENT', 0, NOW())
+        RETURNING id`
+	err := DB.QueryRow(context.Background(), query, userID, phone, codeHash).Scan(&id)
+	return id, err
+}
+
+// ---------------- Save failed OTP delivery to History ----------------
+func SaveOTPDeliveryFailure(userID int, phone, reason string) error {
+	query := `
+        INSERT INTO otp_history (user_id, phone, status, failed_attempts, delivery_error, created_at)
+        VALUES (NULLIF($1, 0), $2, 'SEND_FAILED', 0, $3, NOW())`
+	_, err := DB.Exec(context.Background(), query, userID, phone, reason)
+	return err
+}
+
+// ---------------- Assign OTP to a User ----------------
+// Phone login codes are sent before the account is known (user_id NULL)
+func AssignOTPRequest(requestID, userID int) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE otp_history SET user_id=$2 WHERE id=$1 AND user_id IS NULL`, requestID, userID)
 	return err
 }
 
 // ---------------- Mark OTP Verified ----------------
-func MarkOTPVerified(userID int, phone, code string) error {
+func MarkOTPVerified(requestID int) error {
 	query := `
         UPDATE otp_history
         SET status='VERIFIED', verified_at=NOW()
-        WHERE user_id=$1 AND phone=$2 AND code=$3
-        ORDER BY created_at DESC
-        LIMIT 1`
-	_, err := DB.Exec(context.Background(), query, userID, phone, code)
+        WHERE id=$1`
+	_, err := DB.Exec(context.Background(), query, requestID)
 	return err
 }
 
 // ---------------- Mark OTP Failed Attempt ----------------
-func MarkOTPFailed(userID int, phone, code string) error {
+func MarkOTPFailed(requestID int) error {
 	query := `
         UPDATE otp_history
         SET failed_attempts = failed_attempts + 1, status='FAILED'
-        WHERE user_id=$1 AND phone=$2 AND code=$3
-        ORDER BY created_at DESC
-        LIMIT 1`
-	_, err := DB.Exec(context.Background(), query, userID, phone, code)
+        WHERE id=$1`
+	_, err := DB.Exec(context.Background(), query, requestID)
+	return err
+}
+
+// ---------------- Mark OTP Locked ----------------
+// Terminal status once the maximum number of attempts is reached
+func MarkOTPLocked(requestID int) error {
+	query := `
+        UPDATE otp_history
+        SET status='LOCKED'
+        WHERE id=$1`
+	_, err := DB.Exec(context.Background(), query, requestID)
 	return err
 }
 
@@ -58,7 +86,7 @@ func MarkExpiredOTPs(expireMinutes int) (int64, error) {
 	cmdTag, err := DB.Exec(context.Background(), `
         UPDATE otp_history
         SET status = 'EXPIRED'
-        WHERE status = 'SENT' AND created_at < NOW() - $1::INTERVAL
+        WHERE status IN ('SENT', 'FAILED') AND created_at < NOW() - $1::INTERVAL
     `, interval)
 
 	if err != nil {
@@ -67,6 +95,13 @@ func MarkExpiredOTPs(expireMinutes int) (int64, error) {
 	return cmdTag.RowsAffected(), nil
 }
 
+// ExpireUserOTPs expires the codes still pending for a user, e.g. after their phone changed
+func ExpireUserOTPs(userID int) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE otp_history SET status = 'EXPIRED' WHERE user_id = $1 AND status IN ('SENT', 'FAILED')`, userID)
+	return err
+}
+
 // ---------------- Delete Old OTPs ----------------
 // Delete OTP history older than N days
 func DeleteOldOTPs(retentionDays int) (int64, error) {
@@ -80,3 +115,25 @@ func DeleteOldOTPs(retentionDays int) (int64, error) {
 	}
 	return cmdTag.RowsAffected(), nil
 }
+
+// ---------------- List OTP History of a User ----------------
+// Newest first; used for personal data exports
+func ListOTPHistory(userID int) ([]*OTP, error) {
+	rows, err := DB.Query(context.Background(), `
+        SELECT id, user_id, phone, status, failed_attempts, delivery_error, created_at, verified_at
+        FROM otp_history WHERE user_id=$1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []*OTP{}
+	for rows.Next() {
+		o := &OTP{}
+		if err := rows.Scan(&o.ID, &o.UserID, &o.Phone, &o.Status, &o.FailedAttempts, &o.DeliveryError, &o.CreatedAt, &o.VerifiedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, o)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,309 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrUnknownRole is returned when a role name isn't in the roles table
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrRoleExists is returned by CreateRole when the name is taken
+	ErrRoleExists = errors.New("role already exists")
+	// ErrUnknownPermission is returned when a permission isn't in the permissions table
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrLastManager is returned when a change would leave no active user with one of
+	// ManagementPermissions: nobody could undo it
+	ErrLastManager = errors.New("last active holder of a management permission")
+)
+
+// Permission names checked by the services (seeded by the migrations, 0010_permissions onwards)
+const (
+	PermMoviesWrite    = "movies:write"
+	PermGenresWrite    = "genres:write"
+	PermHallsWrite     = "halls:write"
+	PermSnacksWrite    = "snacks:write"
+	PermSchedulesWrite = "schedules:write"
+	PermLocationsWrite = "locations:write"
+	PermBookingsCreate = "bookings:create"
+	PermBookingsManage = "bookings:manage"
+	PermBookingsRefund = "bookings:refund"
+	PermUsersManage    = "users:manage"
+	PermRolesManage    = "roles:manage"
+	PermSecurityManage = "security:manage"
+)
+
+// ManagementPermissions must always stay with at least one active user
+var ManagementPermissions = []string{PermUsersManage, PermRolesManage}
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// PermissionOverride grants (Granted=true) or denies a permission to one user on top of their role
+type PermissionOverride struct {
+	Permission string `json:"permission"`
+	Granted    bool   `json:"granted"`
+}
+
+// --------------------- Permissions ---------------------
+
+func ListPermissions() ([]*Permission, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []*Permission
+	for rows.Next() {
+		p := &Permission{}
+		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+// --------------------- Roles ---------------------
+
+// GetRoleID resolves a role name to roles.id, ErrUnknownRole if it doesn't exist
+func GetRoleID(roleName string) (int, error) {
+	var id int
+	err := DB.QueryRow(context.Background(),
+		`SELECT id FROM roles WHERE name=$1`, roleName).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrUnknownRole
+	}
+	return id, err
+}
+
+// CreateRole inserts a role with the given permissions in one transaction
+func CreateRole(policy *RolePolicy, perms []string) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var roleID int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO roles (name, require_verified_email, require_mfa) VALUES ($1, $2, $3) RETURNING id`,
+		policy.Name, policy.RequireVerifiedEmail, policy.RequireMFA).Scan(&roleID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrRoleExists
+		}
+		log.Printf("❌ CreateRole error: %v", err)
+		return err
+	}
+	if err := insertRolePermissions(ctx, tx, roleID, perms); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetRolePermissions lists the permissions granted by a role
+func GetRolePermissions(roleName string) ([]string, error) {
+	roleID, err := GetRoleID(roleName)
+	if err != nil {
+		return nil, err
+	}
+	return queryPermissionNames(
+		`SELECT permission FROM role_permissions WHERE role_id=$1 ORDER BY permission`, roleID)
+}
+
+// SetRolePermissions replaces the permission set of a role.
+// Returns ErrLastManager when no active user would keep a management permission.
+func SetRolePermissions(roleName string, perms []string) error {
+	roleID, err := GetRoleID(roleName)
+	if err != nil {
+		return err
+	}
+
+	return withManagersKept(func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role_id=$1`, roleID); err != nil {
+			return err
+		}
+		return insertRolePermissions(ctx, tx, roleID, perms)
+	})
+}
+
+func insertRolePermissions(ctx context.Context, tx pgx.Tx, roleID int, perms []string) error {
+	for _, p := range perms {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO role_permissions (role_id, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			roleID, p)
+		if err != nil {
+			return permissionError(err)
+		}
+	}
+	return nil
+}
+
+// --------------------- Effective Permissions ---------------------
+
+// GetEffectivePermissions returns the role's permissions plus the user's grants, minus the user's denials
+func GetEffectivePermissions(userID, roleID int) ([]string, error) {
+	return queryPermissionNames(`
+		SELECT permission FROM role_permissions WHERE role_id=$2
+		UNION
+		SELECT permission FROM user_permissions WHERE user_id=$1 AND granted
+		EXCEPT
+		SELECT permission FROM user_permissions WHERE user_id=$1 AND NOT granted
+		ORDER BY 1`, userID, roleID)
+}
+
+// --------------------- Per-user Overrides ---------------------
+
+func ListUserPermissionOverrides(userID int) ([]*PermissionOverride, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT permission, granted FROM user_permissions WHERE user_id=$1 ORDER BY permission`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []*PermissionOverride{}
+	for rows.Next() {
+		o := &PermissionOverride{}
+		if err := rows.Scan(&o.Permission, &o.Granted); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// SetUserPermissionOverride returns ErrLastManager when a denial takes a management
+// permission away from its last active holder
+func SetUserPermissionOverride(userID int, perm string, granted bool) error {
+	err := withManagersKept(func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO user_permissions (user_id, permission, granted) VALUES ($1, $2, $3)
+			 ON CONFLICT (user_id, permission) DO UPDATE SET granted=$3`,
+			userID, perm, granted)
+		return err
+	})
+	if err != nil && !errors.Is(err, ErrLastManager) {
+		log.Printf("❌ SetUserPermissionOverride error: %v", err)
+		return permissionError(err)
+	}
+	return err
+}
+
+// DeleteUserPermissionOverride returns false if the user had no override for perm,
+// ErrLastManager when dropping a grant leaves a management permission without active holder
+func DeleteUserPermissionOverride(userID int, perm string) (bool, error) {
+	found := false
+	err := withManagersKept(func(ctx context.Context, tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx,
+			`DELETE FROM user_permissions WHERE user_id=$1 AND permission=$2`, userID, perm)
+		found = cmdTag.RowsAffected() > 0
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// --------------------- Last Manager ---------------------
+
+// countManagers returns how many active users effectively hold each of ManagementPermissions
+func countManagers(ctx context.Context, tx pgx.Tx) (map[string]int, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT p.name, COUNT(u.id)
+		FROM unnest($1::text[]) AS p(name)
+		LEFT JOIN users u ON u.deactivated_at IS NULL AND COALESCE(
+			(SELECT up.granted FROM user_permissions up WHERE up.user_id = u.id AND up.permission = p.name),
+			EXISTS (SELECT 1 FROM role_permissions rp WHERE rp.role_id = u.role_id AND rp.permission = p.name))
+		GROUP BY p.name`, ManagementPermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var perm string
+		var n int
+		if err := rows.Scan(&perm, &n); err != nil {
+			return nil, err
+		}
+		counts[perm] = n
+	}
+	return counts, rows.Err()
+}
+
+// withManagersKept runs a permission change in a transaction and rolls it back with
+// ErrLastManager if a management permission had active holders before and has none after.
+// Permission changes are serialized, so each one counts the holders the others left.
+func withManagersKept(change func(ctx context.Context, tx pgx.Tx) error) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Conflicts with itself, not with readers
+	if _, err := tx.Exec(ctx, `LOCK TABLE role_permissions, user_permissions IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	before, err := countManagers(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if err := change(ctx, tx); err != nil {
+		return err
+	}
+	after, err := countManagers(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, perm := range ManagementPermissions {
+		if before[perm] > 0 && after[perm] == 0 {
+			return ErrLastManager
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// --------------------- Helpers ---------------------
+
+func queryPermissionNames(query string, args ...interface{}) ([]string, error) {
+	rows, err := DB.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+// permissionError maps a foreign key violation on permissions(name) to ErrUnknownPermission
+func permissionError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrUnknownPermission
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestManagementPermissionsKeepAHolder(t *testing.T) {
+	testDB(t)
+	ctx := context.Background()
+
+	role := fmt.Sprintf("test-managers-%d", time.Now().UnixNano())
+	if err := CreateRole(&RolePolicy{Name: role}, ManagementPermissions); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.Exec(ctx, `DELETE FROM roles WHERE name=$1`, role) })
+	managerID := testUser(t, role)
+	customerID := testUser(t, "customer")
+
+	// Only meaningful when our manager is the only one
+	var others int
+	if err := DB.QueryRow(ctx,
+		`SELECT COUNT(*) FROM users u WHERE u.deactivated_at IS NULL AND u.id <> $2 AND (
+		   EXISTS (SELECT 1 FROM role_permissions rp WHERE rp.role_id = u.role_id AND rp.permission = ANY($1)) OR
+		   EXISTS (SELECT 1 FROM user_permissions up WHERE up.user_id = u.id AND up.permission = ANY($1) AND up.granted))`,
+		ManagementPermissions, managerID).Scan(&others); err != nil {
+		t.Fatal(err)
+	}
+	if others > 0 {
+		t.Skip("the test database has other active managers")
+	}
+
+	steps := []struct {
+		name   string
+		change func() error
+		want   error
+	}{
+		{"role loses the permissions of its only holder",
+			func() error { return SetRolePermissions(role, []string{PermUsersManage}) }, ErrLastManager},
+		{"only holder denied",
+			func() error { return SetUserPermissionOverride(managerID, PermUsersManage, false) }, ErrLastManager},
+		{"another holder granted",
+			func() error { return SetUserPermissionOverride(customerID, PermRolesManage, true) }, nil},
+		{"role may lose the permission someone else holds",
+			func() error { return SetRolePermissions(role, []string{PermUsersManage}) }, nil},
+		{"grant of the last holder dropped",
+			func() error { _, err := DeleteUserPermissionOverride(customerID, PermRolesManage); return err }, ErrLastManager},
+		{"unrelated permission denied",
+			func() error { return SetUserPermissionOverride(managerID, PermMoviesWrite, false) }, nil},
+	}
+	for _, step := range steps {
+		if err := step.change(); !errors.Is(err, step.want) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.want)
+		}
+	}
+
+	perms, err := GetRolePermissions(role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(perms) != 1 || perms[0] != PermUsersManage {
+		t.Errorf("role permissions = %v, want [%s]", perms, PermUsersManage)
+	}
+	if got, _ := GetEffectivePermissions(customerID, 0); len(got) != 1 || got[0] != PermRolesManage {
+		t.Errorf("refused drop changed the grant: effective permissions = %v", got)
+	}
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"auth-backend/utils"
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PhoneChange is one stored number NormalizeUserPhones rewrote (or would rewrite)
+type PhoneChange struct {
+	UserID int    `json:"user_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// StoredPhone is a user's number as stored
+type StoredPhone struct {
+	UserID int    `json:"user_id"`
+	Phone  string `json:"phone"`
+}
+
+// DuplicatePhone lists accounts whose numbers are the same once normalized.
+// None of them is changed: an admin has to merge or fix them first.
+type DuplicatePhone struct {
+	Phone string        `json:"phone"`
+	Users []StoredPhone `json:"users"`
+}
+
+// PhoneNormalizationReport is the outcome of NormalizeUserPhones
+type PhoneNormalizationReport struct {
+	Checked    int              `json:"checked"`
+	Updated    []PhoneChange    `json:"updated"`
+	Invalid    []StoredPhone    `json:"invalid"` // can't be read as E.164, left as they are
+	Duplicates []DuplicatePhone `json:"duplicates"`
+}
+
+// ---------------- Normalize User Phones ----------------
+
+// NormalizeUserPhones rewrites users.phone to E.164 (utils.NormalizePhone, default region) in
+// one transaction. Unreadable numbers and numbers that collide with another account once
+// normalized are reported and left untouched. With dryRun nothing is written.
+func NormalizeUserPhones(dryRun bool) (*PhoneNormalizationReport, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	report, err := normalizeUserPhones(ctx, tx, dryRun)
+	if err != nil || dryRun {
+		return report, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// NormalizeUserPhonesMigration is the data migration that brings numbers stored before
+// normalization to E.164, so phone lookups (which normalize their input) keep finding them.
+// Numbers it can't rewrite are logged and recorded in the security log for an admin to fix.
+func NormalizeUserPhonesMigration(ctx context.Context, tx pgx.Tx) error {
+	report, err := normalizeUserPhones(ctx, tx, false)
+	if err != nil {
+		return err
+	}
+
+	type unresolved struct {
+		userID  int
+		details map[string]interface{}
+	}
+	var pending []unresolved
+	for _, p := range report.Invalid {
+		log.Printf("⚠️ User %d: %q is not a valid phone number, left unchanged", p.UserID, p.Phone)
+		pending = append(pending, unresolved{p.UserID, map[string]interface{}{"reason": "invalid", "phone": p.Phone}})
+	}
+	for _, d := range report.Duplicates {
+		ids := make([]int, 0, len(d.Users))
+		for _, u := range d.Users {
+			ids = append(ids, u.UserID)
+		}
+		log.Printf("⚠️ %s is shared by users %v once normalized, left unchanged: merge or fix them", d.Phone, ids)
+		for _, u := range d.Users {
+			pending = append(pending, unresolved{u.UserID, map[string]interface{}{
+				"reason": "duplicate", "phone": u.Phone, "normalized": d.Phone, "user_ids": ids,
+			}})
+		}
+	}
+	for _, u := range pending {
+		details, err := json.Marshal(u.details)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO security_events (event_type, user_id, details, created_at) VALUES ($1,$2,$3,NOW())`,
+			EventPhoneNotNormalized, u.userID, details); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("📞 Normalized %d of %d phone numbers, %d invalid, %d duplicated numbers left unchanged",
+		len(report.Updated), report.Checked, len(report.Invalid), len(report.Duplicates))
+	return nil
+}
+
+func normalizeUserPhones(ctx context.Context, tx pgx.Tx, dryRun bool) (*PhoneNormalizationReport, error) {
+	rows, err := tx.Query(ctx, `SELECT id, phone FROM users WHERE phone IS NOT NULL ORDER BY id FOR UPDATE`)
+	if err != nil {
+		log.Printf("❌ NormalizeUserPhones select error: %v", err)
+		return nil, err
+	}
+	report := &PhoneNormalizationReport{Updated: []PhoneChange{}, Invalid: []StoredPhone{}, Duplicates: []DuplicatePhone{}}
+	groups := map[string][]StoredPhone{} // normalized number → accounts with it
+	for rows.Next() {
+		var p StoredPhone
+		if err := rows.Scan(&p.UserID, &p.Phone); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Checked++
+		normalized, err := utils.NormalizePhone(p.Phone)
+		if err != nil {
+			report.Invalid = append(report.Invalid, p)
+			continue
+		}
+		groups[normalized] = append(groups[normalized], p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Normalizing is idempotent, so a number already in E.164 lands in the same group as the
+	// numbers it collides with and a single-account group can be rewritten safely
+	for normalized, users := range groups {
+		if len(users) > 1 {
+			report.Duplicates = append(report.Duplicates, DuplicatePhone{Phone: normalized, Users: users})
+			continue
+		}
+		if users[0].Phone == normalized {
+			continue
+		}
+		report.Updated = append(report.Updated, PhoneChange{UserID: users[0].UserID, From: users[0].Phone, To: normalized})
+	}
+	sort.Slice(report.Updated, func(i, j int) bool { return report.Updated[i].UserID < report.Updated[j].UserID })
+	sort.Slice(report.Duplicates, func(i, j int) bool { return report.Duplicates[i].Phone < report.Duplicates[j].Phone })
+
+	if dryRun {
+		return report, nil
+	}
+	for _, ch := range report.Updated {
+		if _, err := tx.Exec(ctx, `UPDATE users SET phone=$2, updated_at=NOW() WHERE id=$1`, ch.UserID, ch.To); err != nil {
+			log.Printf("❌ NormalizeUserPhones update error for user %d: %v", ch.UserID, err)
+			return nil, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Profile holds the self-service details kept next to a user (user_profiles)
+type Profile struct {
+	UserID             int
+	AvatarURL          *string
+	PreferredLanguage  *string
+	DateOfBirth        *time.Time // date only
+	MarketingConsent   bool
+	MarketingConsentAt *time.Time // when consent was last given or withdrawn
+	UpdatedAt          *time.Time
+}
+
+// ---------------- Get Profile ----------------
+// Users who never saved a profile get an empty one
+func GetProfile(userID int) (*Profile, error) {
+	p := &Profile{UserID: userID}
+	err := DB.QueryRow(context.Background(),
+		`SELECT avatar_url, preferred_language, date_of_birth, marketing_consent, marketing_consent_at, updated_at
+		 FROM user_profiles WHERE user_id=$1`, userID,
+	).Scan(&p.AvatarURL, &p.PreferredLanguage, &p.DateOfBirth, &p.MarketingConsent, &p.MarketingConsentAt, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return p, nil
+	}
+	if err != nil {
+		log.Printf("❌ GetProfile error: %v", err)
+		return nil, err
+	}
+	return p, nil
+}
+
+// ---------------- Save Profile ----------------
+// SaveProfile upserts the profile and the user's name; marketing_consent_at moves only when consent changes
+func SaveProfile(name string, p *Profile) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET name=$1, updated_at=NOW() WHERE id=$2`, name, p.UserID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO user_profiles (user_id, avatar_url, preferred_language, date_of_birth, marketing_consent, marketing_consent_at, updated_at)
+		 VALUES ($1,$2,$3,$4,$5, CASE WHEN $5 THEN NOW() END, NOW())
+		 ON CONFLICT (user_id) DO UPDATE SET
+		     avatar_url=$2, preferred_language=$3, date_of_birth=$4, marketing_consent=$5,
+		     marketing_consent_at = CASE WHEN user_profiles.marketing_consent IS DISTINCT FROM $5
+		                                 THEN NOW() ELSE user_profiles.marketing_consent_at END,
+		     updated_at=NOW()`,
+		p.UserID, p.AvatarURL, p.PreferredLanguage, p.DateOfBirth, p.MarketingConsent)
+	if err != nil {
+		log.Printf("❌ SaveProfile error: %v", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ---------------- Change Email ----------------
+// SetConfirmedEmail replaces the user's email with an address they just confirmed.
+// Returns ErrUserExists if another account took it in the meantime.
+func SetConfirmedEmail(userID int, email string) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE users SET email=$1, email_verified_at=NOW(), updated_at=NOW() WHERE id=$2`, email, userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrUserExists
+		}
+		log.Printf("❌ SetConfirmedEmail error: %v", err)
+	}
+	return err
+}
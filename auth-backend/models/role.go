@@ -2,13 +2,16 @@ package models
 
 import (
 	"context"
+	"errors"
 	"log"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // --------------------- Admin Role ---------------------
 type AdminRole struct {
 	UserID int
-	Level  string // "admin", "manager"
+	Level  string // mirrors the role: "admin" or "manager"
 }
 
 func GetAdminRole(userID int) (*AdminRole, error) {
@@ -35,16 +38,22 @@ func CreateOrUpdateAdminRole(role *AdminRole) error {
 }
 
 // --------------------- Staff Role ---------------------
+// Dept (departments.code) and LocationID (cinema_scheduling.cinema_locations.id) scope what a staffer may do
 type StaffRole struct {
-	UserID int
-	Dept   string
+	UserID     int     `json:"user_id"`
+	Dept       *string `json:"dept"`
+	LocationID *int    `json:"location_id"`
 }
 
+// GetStaffRole returns nil if the user has no staff row
 func GetStaffRole(userID int) (*StaffRole, error) {
 	role := &StaffRole{}
 	err := DB.QueryRow(context.Background(),
-		`SELECT user_id, dept FROM staff_roles WHERE user_id=$1`, userID).
-		Scan(&role.UserID, &role.Dept)
+		`SELECT user_id, dept, location_id FROM staff_roles WHERE user_id=$1`, userID).
+		Scan(&role.UserID, &role.Dept, &role.LocationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
 		log.Printf("❌ GetStaffRole error: %v", err)
 		return nil, err
@@ -52,15 +61,17 @@ func GetStaffRole(userID int) (*StaffRole, error) {
 	return role, nil
 }
 
+// CreateOrUpdateStaffRole saves the assignment; ErrUnknownDepartment if Dept isn't a department code
 func CreateOrUpdateStaffRole(role *StaffRole) error {
 	_, err := DB.Exec(context.Background(),
-		`INSERT INTO staff_roles (user_id, dept) VALUES ($1, $2)
-		 ON CONFLICT (user_id) DO UPDATE SET dept=$2`,
-		role.UserID, role.Dept)
+		`INSERT INTO staff_roles (user_id, dept, location_id) VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id) DO UPDATE SET dept=$2, location_id=$3`,
+		role.UserID, role.Dept, role.LocationID)
 	if err != nil {
 		log.Printf("❌ CreateOrUpdateStaffRole error: %v", err)
+		return departmentError(err)
 	}
-	return err
+	return nil
 }
 
 // --------------------- Customer Role ---------------------
@@ -91,3 +102,69 @@ func CreateOrUpdateCustomerRole(role *CustomerRole) error {
 	}
 	return err
 }
+
+// --------------------- Role Policies ---------------------
+type RolePolicy struct {
+	Name                 string `json:"name"`
+	RequireVerifiedEmail bool   `json:"require_verified_email"`
+	RequireMFA           bool   `json:"require_mfa"`
+}
+
+func ListRolePolicies() ([]*RolePolicy, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT name, require_verified_email, require_mfa FROM roles ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*RolePolicy
+	for rows.Next() {
+		r := &RolePolicy{}
+		if err := rows.Scan(&r.Name, &r.RequireVerifiedEmail, &r.RequireMFA); err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	return roles, nil
+}
+
+func RoleRequiresVerifiedEmail(roleID int) (bool, error) {
+	var required bool
+	err := DB.QueryRow(context.Background(),
+		`SELECT require_verified_email FROM roles WHERE id=$1`, roleID).Scan(&required)
+	return required, err
+}
+
+// SetRoleRequireVerifiedEmail returns false if the role doesn't exist
+func SetRoleRequireVerifiedEmail(roleName string, required bool) (bool, error) {
+	cmdTag, err := DB.Exec(context.Background(),
+		`UPDATE roles SET require_verified_email=$1 WHERE name=$2`, required, roleName)
+	if err != nil {
+		log.Printf("❌ SetRoleRequireVerifiedEmail error: %v", err)
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// RoleRequiresMFA reports whether users of a role must pass 2FA to use role-restricted routes
+func RoleRequiresMFA(roleName string) (bool, error) {
+	var required bool
+	err := DB.QueryRow(context.Background(),
+		`SELECT require_mfa FROM roles WHERE name=$1`, roleName).Scan(&required)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return required, err
+}
+
+// SetRoleRequireMFA returns false if the role doesn't exist
+func SetRoleRequireMFA(roleName string, required bool) (bool, error) {
+	cmdTag, err := DB.Exec(context.Background(),
+		`UPDATE roles SET require_mfa=$1 WHERE name=$2`, required, roleName)
+	if err != nil {
+		log.Printf("❌ SetRoleRequireMFA error: %v", err)
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Security event types
+const (
+	EventAccountLocked      = "account_locked"
+	EventAccountUnlocked    = "account_unlocked"
+	EventIPLocked           = "ip_locked"
+	EventIPUnlocked         = "ip_unlocked"
+	EventMFAEnabled         = "mfa_enabled"
+	EventMFADisabled        = "mfa_disabled"
+	EventMFAReset           = "mfa_reset" // by an admin
+	EventMFARecoveryUsed    = "mfa_recovery_code_used"
+	EventMFALocked          = "mfa_locked"
+	EventEmailChanged       = "email_changed"        // self-service, after confirming the new address
+	EventPhoneChanged       = "phone_changed"        // self-service, after verifying the new number
+	EventPhoneNotNormalized = "phone_not_normalized" // stored number the E.164 migration couldn't rewrite
+	EventIdentityLinked     = "identity_linked"      // external login attached to the account
+	EventIdentityUnlinked   = "identity_unlinked"
+
+	// Personal data
+	EventDataExported      = "data_exported"
+	EventDeletionRequested = "account_deletion_requested"
+	EventDeletionCancelled = "account_deletion_cancelled"
+	EventUserAnonymized    = "user_anonymized" // personal data erased after the grace period
+
+	// Account administration (actor = admin)
+	EventUserUpdated         = "user_updated"
+	EventRoleChanged         = "role_changed"
+	EventStaffAssigned       = "staff_assigned"
+	EventUserDeactivated     = "user_deactivated"
+	EventUserReactivated     = "user_reactivated"
+	EventPasswordResetForced = "password_reset_forced"
+	EventUserDeleted         = "user_deleted"
+	EventAccountsMerged      = "accounts_merged" // duplicate account merged into this one
+)
+
+// SecurityEvent is one entry of the persistent security log
+type SecurityEvent struct {
+	ID        int64                  `json:"id"`
+	EventType string                 `json:"event_type"`
+	UserID    *int                   `json:"user_id,omitempty"`
+	ActorID   *int                   `json:"actor_id,omitempty"` // admin who triggered it, if any
+	IP        string                 `json:"ip,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// ---------------- Record Security Event ----------------
+func RecordSecurityEvent(e *SecurityEvent) error {
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return fmt.Errorf("marshal details: %w", err)
+	}
+	err = DB.QueryRow(context.Background(),
+		`INSERT INTO security_events (event_type, user_id, actor_id, ip, details, created_at)
+		 VALUES ($1,$2,$3,NULLIF($4,''),$5,NOW())
+		 RETURNING id, created_at`,
+		e.EventType, e.UserID, e.ActorID, e.IP, details,
+	).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		log.Printf("❌ RecordSecurityEvent error: %v", err)
+	}
+	return err
+}
+
+// SecurityEventFilter narrows ListSecurityEvents; zero values mean "any"
+type SecurityEventFilter struct {
+	UserID    int
+	EventType string
+	IP        string
+	Limit     int
+}
+
+// ---------------- List Security Events ----------------
+// Newest first
+func ListSecurityEvents(f SecurityEventFilter) ([]*SecurityEvent, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, event_type, user_id, actor_id, COALESCE(ip,''), details, created_at
+		 FROM security_events
+		 WHERE ($1 = 0 OR user_id = $1)
+		   AND ($2 = '' OR event_type = $2)
+		   AND ($3 = '' OR ip = $3)
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $4`, f.UserID, f.EventType, f.IP, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*SecurityEvent{}
+	for rows.Next() {
+		e := &SecurityEvent{}
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.EventType, &e.UserID, &e.ActorID, &e.IP, &details, &e.CreatedAt); err != nil {
+			log.Printf("❌ Scan security event error: %v", err)
+			return nil, err
+		}
+		if len(details) > 0 {
+			_ = json.Unmarshal(details, &e.Details)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Session represents one login of a user on a device
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     int        `json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ---------------- Create Session ----------------
+func CreateSession(s *Session) error {
+	err := DB.QueryRow(context.Background(),
+		`INSERT INTO user_sessions (id, user_id, user_agent, ip, created_at, last_seen_at)
+		 VALUES ($1,$2,$3,$4,NOW(),NOW())
+		 RETURNING created_at, last_seen_at`,
+		s.ID, s.UserID, s.UserAgent, s.IP,
+	).Scan(&s.CreatedAt, &s.LastSeenAt)
+	if err != nil {
+		log.Printf("❌ CreateSession error: %v", err)
+	}
+	return err
+}
+
+// ---------------- Get Session by ID ----------------
+func GetSessionByID(id string) (*Session, error) {
+	s := &Session{}
+	err := DB.QueryRow(context.Background(),
+		`SELECT id, user_id, COALESCE(user_agent,''), COALESCE(ip,''), created_at, last_seen_at, revoked_at
+		 FROM user_sessions WHERE id=$1`, id,
+	).Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("❌ GetSessionByID error: %v", err)
+		return nil, err
+	}
+	return s, nil
+}
+
+// ---------------- List active Sessions of a User ----------------
+func GetActiveSessionsByUser(userID int) ([]*Session, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, user_id, COALESCE(user_agent,''), COALESCE(ip,''), created_at, last_seen_at, revoked_at
+		 FROM user_sessions
+		 WHERE user_id=$1 AND revoked_at IS NULL
+		 ORDER BY last_seen_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		s := &Session{}
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt); err != nil {
+			log.Printf("❌ Scan session error: %v", err)
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// ---------------- Touch Session ----------------
+// Updates last_seen_at at most once per minute to keep writes cheap
+func TouchSession(id string) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE user_sessions SET last_seen_at=NOW()
+		 WHERE id=$1 AND last_seen_at < NOW() - INTERVAL '1 minute'`, id)
+	return err
+}
+
+// ---------------- Revoke Session ----------------
+func RevokeSession(id string) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE user_sessions SET revoked_at=NOW() WHERE id=$1 AND revoked_at IS NULL`, id)
+	return err
+}
+
+// ---------------- Revoke all Sessions of a User ----------------
+// exceptID keeps one session alive (pass "" to revoke all); returns the revoked IDs
+func RevokeUserSessions(userID int, exceptID string) ([]string, error) {
+	rows, err := DB.Query(context.Background(),
+		`UPDATE user_sessions SET revoked_at=NOW()
+		 WHERE user_id=$1 AND revoked_at IS NULL AND id<>$2
+		 RETURNING id`, userID, exceptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
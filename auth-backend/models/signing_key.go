@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// SigningKey is one JWT signing key pair; the private half is encrypted at rest
+type SigningKey struct {
+	KID           string     `json:"kid"`
+	Algorithm     string     `json:"alg"`
+	PrivateKeyEnc string     `json:"-"`
+	PublicKeyPEM  string     `json:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RetiredAt     *time.Time `json:"retired_at,omitempty"` // no longer signs, still verifies until the overlap ends
+}
+
+// signingKeyLockID serializes rotations across auth-backend instances (pg advisory lock)
+const signingKeyLockID = 73125
+
+// ---------------- List Signing Keys ----------------
+// Active key first, then retired keys newest first
+func ListSigningKeys() ([]*SigningKey, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT kid, alg, private_key_enc, public_key_pem, created_at, retired_at
+		 FROM signing_keys
+		 ORDER BY retired_at IS NOT NULL, created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*SigningKey
+	for rows.Next() {
+		k := &SigningKey{}
+		if err := rows.Scan(&k.KID, &k.Algorithm, &k.PrivateKeyEnc, &k.PublicKeyPEM, &k.CreatedAt, &k.RetiredAt); err != nil {
+			log.Printf("❌ Scan signing key error: %v", err)
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// ---------------- Rotate Signing Key ----------------
+// Inserts k as the active key and retires the previous one, unless the active key
+// is younger than minAge (another instance already rotated). Returns whether k was stored.
+func RotateSigningKey(k *SigningKey, minAge time.Duration) (bool, error) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, signingKeyLockID); err != nil {
+		return false, err
+	}
+
+	var fresh bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM signing_keys
+		                WHERE retired_at IS NULL AND created_at > NOW() - $1::INTERVAL)`,
+		minAge.String()).Scan(&fresh)
+	if err != nil {
+		return false, err
+	}
+	if fresh {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE signing_keys SET retired_at=NOW() WHERE retired_at IS NULL`); err != nil {
+		return false, err
+	}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO signing_keys (kid, alg, private_key_enc, public_key_pem, created_at)
+		 VALUES ($1,$2,$3,$4,NOW())
+		 RETURNING created_at`,
+		k.KID, k.Algorithm, k.PrivateKeyEnc, k.PublicKeyPEM,
+	).Scan(&k.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// ---------------- Delete Expired Signing Keys ----------------
+// Drops keys retired longer than overlap ago; no live token can reference them
+func DeleteExpiredSigningKeys(overlap time.Duration) (int64, error) {
+	cmdTag, err := DB.Exec(context.Background(),
+		`DELETE FROM signing_keys WHERE retired_at IS NOT NULL AND retired_at < NOW() - $1::INTERVAL`,
+		overlap.String())
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
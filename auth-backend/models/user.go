@@ -10,40 +10,53 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var DB *pgxpool.Pool
 
+// ErrUserExists is returned by CreateUser when a unique field is already taken
+var ErrUserExists = errors.New("user already exists")
+
 type User struct {
-	ID           int
-	Name         string
-	PhoneNumber  *string // nullable
-	Email        *string // nullable
-	PasswordHash string
-	Role         string // "customer", "staff", "admin"
-	IsVerified   bool
-	GoogleID     *string // nullable
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	RoleID       int
+	ID              int
+	Name            string
+	PhoneNumber     *string // nullable
+	Email           *string // nullable
+	PasswordHash    string
+	Role            string     // roles.name, e.g. "customer", "staff", "manager", "admin"
+	IsVerified      bool       // phone verified via OTP
+	EmailVerifiedAt *time.Time // nullable, set once the email link is confirmed
+	DeactivatedAt   *time.Time // nullable, set while an admin has the account disabled
+	// PasswordResetRequired blocks password login until the password is reset
+	PasswordResetRequired bool
+	DeletionScheduledAt   *time.Time // nullable, self-service deletion pending (account is deactivated meanwhile)
+	AnonymizedAt          *time.Time // nullable, personal data erased
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	RoleID                int
 }
 
 // --------------------- Fetch Users ---------------------
 
+// userSelect lists the columns scanned by scanUser
+const userSelect = `
+	SELECT u.id, u.name, u.phone, u.email, u.password_hash,
+	       u.is_verified, u.email_verified_at, u.deactivated_at, u.password_reset_required,
+	       u.deletion_scheduled_at, u.anonymized_at, u.role_id, r.name, u.created_at, u.updated_at
+	FROM users u
+	JOIN roles r ON u.role_id = r.id`
+
+func scanUser(row pgx.Row, u *User) error {
+	return row.Scan(&u.ID, &u.Name, &u.PhoneNumber, &u.Email, &u.PasswordHash,
+		&u.IsVerified, &u.EmailVerifiedAt, &u.DeactivatedAt, &u.PasswordResetRequired,
+		&u.DeletionScheduledAt, &u.AnonymizedAt, &u.RoleID, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+}
+
 func GetUserByPhone(phone string) (*User, error) {
 	u := &User{}
-	query := `
-		SELECT u.id, u.name, u.phone, u.email, u.password_hash, u.google_id, 
-		       u.is_verified, u.role_id, r.name, u.created_at, u.updated_at
-		FROM users u
-		JOIN roles r ON u.role_id = r.id
-		WHERE u.phone=$1
-	`
-	err := DB.QueryRow(context.Background(), query, phone).Scan(
-		&u.ID, &u.Name, &u.PhoneNumber, &u.Email, &u.PasswordHash, &u.GoogleID,
-		&u.IsVerified, &u.RoleID, &u.Role, &u.CreatedAt, &u.UpdatedAt,
-	)
+	err := scanUser(DB.QueryRow(context.Background(), userSelect+` WHERE u.phone=$1`, phone), u)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -56,14 +69,7 @@ func GetUserByPhone(phone string) (*User, error) {
 
 func GetUserByEmail(email string) (*User, error) {
 	u := &User{}
-	err := DB.QueryRow(context.Background(),
-		`SELECT u.id, u.name, u.phone, u.email, u.password_hash, u.google_id, 
-		        u.is_verified, u.role_id, r.name, u.created_at, u.updated_at
-		 FROM users u
-		 JOIN roles r ON u.role_id = r.id
-		 WHERE u.email=$1`, email).
-		Scan(&u.ID, &u.Name, &u.PhoneNumber, &u.Email, &u.PasswordHash, &u.GoogleID,
-			&u.IsVerified, &u.RoleID, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+	err := scanUser(DB.QueryRow(context.Background(), userSelect+` WHERE u.email=$1`, email), u)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -76,14 +82,7 @@ func GetUserByEmail(email string) (*User, error) {
 
 func GetUserByID(id int) (*User, error) {
 	u := &User{}
-	err := DB.QueryRow(context.Background(),
-		`SELECT u.id, u.name, u.phone, u.email, u.password_hash, u.google_id, 
-		        u.is_verified, u.role_id, r.name, u.created_at, u.updated_at
-		 FROM users u
-		 JOIN roles r ON u.role_id = r.id
-		 WHERE u.id=$1`, id).
-		Scan(&u.ID, &u.Name, &u.PhoneNumber, &u.Email, &u.PasswordHash, &u.GoogleID,
-			&u.IsVerified, &u.RoleID, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+	err := scanUser(DB.QueryRow(context.Background(), userSelect+` WHERE u.id=$1`, id), u)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -95,12 +94,16 @@ func GetUserByID(id int) (*User, error) {
 }
 
 // --------------------- Create / Fetch Users ---------------------
+
+// CreateOrFetchUser inserts a user, or returns the existing account with the same email
+// when both the incoming email (EmailVerifiedAt set by the caller) and the account's
+// email are verified. Any other match on email or phone returns ErrUserExists.
 func CreateOrFetchUser(user *User, extra map[string]interface{}) (*User, bool, error) {
-	log.Printf("👉 CreateOrFetchUser called with Name=%s, Phone=%v, Email=%v, GoogleID=%v, Role=%s",
-		user.Name, user.PhoneNumber, user.Email, user.GoogleID, user.Role)
+	log.Printf("👉 CreateOrFetchUser called with Name=%s, Phone=%v, Email=%v, Role=%s",
+		user.Name, user.PhoneNumber, user.Email, user.Role)
 
-	// Ensure admin/staff is always verified
-	if user.Role == "admin" || user.Role == "staff" {
+	// Accounts created for employees are always verified
+	if user.Role != "customer" {
 		user.IsVerified = true
 	}
 
@@ -116,75 +119,87 @@ func CreateOrFetchUser(user *User, extra map[string]interface{}) (*User, bool, e
 	}
 
 	// Resolve role_id
-	switch user.Role {
-	case "admin":
-		user.RoleID = 1
-	case "staff":
-		user.RoleID = 2
-	default:
-		user.RoleID = 3
-	}
-
-	var existing *User
 	var err error
+	if user.RoleID, err = GetRoleID(user.Role); err != nil {
+		return nil, false, err
+	}
 
-	// Lookup by phone
+	// A phone number is never proven by the callers of this function: no linking on it
 	if user.PhoneNumber != nil && *user.PhoneNumber != "" {
-		existing, err = GetUserByPhone(*user.PhoneNumber)
+		existing, err := GetUserByPhone(*user.PhoneNumber)
 		if err != nil {
 			return nil, false, err
 		}
 		if existing != nil {
-			mergeUserFields(existing, user)
-			if err := UpdateUser(existing, extra); err != nil {
-				log.Printf("❌ UpdateUser error: %v", err)
-			}
-			return existing, false, nil
+			return nil, false, ErrUserExists
 		}
 	}
 
-	// Lookup by email
+	// Link on email only when both sides have proven they own it
 	if user.Email != nil && *user.Email != "" {
-		existing, err = GetUserByEmail(*user.Email)
+		existing, err := GetUserByEmail(*user.Email)
 		if err != nil {
 			return nil, false, err
 		}
 		if existing != nil {
+			if user.EmailVerifiedAt == nil || existing.EmailVerifiedAt == nil || existing.AnonymizedAt != nil {
+				return nil, false, ErrUserExists
+			}
 			mergeUserFields(existing, user)
-			if err := UpdateUser(existing, extra); err != nil {
-				log.Printf("❌ UpdateUser error: %v", err)
+			if err := saveMergedFields(existing); err != nil {
+				log.Printf("❌ saveMergedFields error: %v", err)
 			}
 			return existing, false, nil
 		}
 	}
 
-	// Lookup by GoogleID
-	if user.GoogleID != nil && *user.GoogleID != "" {
-		var id int
-		row := DB.QueryRow(context.Background(), `SELECT id FROM users WHERE google_id=$1`, *user.GoogleID)
-		if err := row.Scan(&id); err == nil {
-			existing, _ := GetUserByID(id)
-			if existing != nil {
-				mergeUserFields(existing, user)
-				if err := UpdateUser(existing, extra); err != nil {
-					log.Printf("❌ UpdateUser error: %v", err)
-				}
-				return existing, false, nil
-			}
+	// Insert new user
+	created, err := insertUser(user, extra)
+	if err != nil {
+		return nil, false, err
+	}
+	return created, true, nil
+}
+
+// --------------------- Create User (no merge) ---------------------
+// CreateUser inserts a brand-new account and never touches existing ones.
+// Returns ErrUserExists if the email/phone is already taken,
+// ErrUnknownRole if user.Role isn't in the roles table.
+func CreateUser(user *User, extra map[string]interface{}) (*User, error) {
+	if user.Role != "customer" {
+		user.IsVerified = true
+	}
+	if user.PasswordHash != "" && !strings.HasPrefix(user.PasswordHash, "$2a$") {
+		hash, err := utils.HashPassword(user.PasswordHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
 		}
+		user.PasswordHash = hash
 	}
+	roleID, err := GetRoleID(user.Role)
+	if err != nil {
+		return nil, err
+	}
+	user.RoleID = roleID
 
-	// Insert new user
-	err = DB.QueryRow(context.Background(),
-		`INSERT INTO users (name, phone, email, password_hash, google_id, role_id, is_verified, created_at, updated_at)
+	return insertUser(user, extra)
+}
+
+func insertUser(user *User, extra map[string]interface{}) (*User, error) {
+	err := DB.QueryRow(context.Background(),
+		`INSERT INTO users (name, phone, email, password_hash, role_id, is_verified, email_verified_at, created_at, updated_at)
 		 VALUES ($1,$2,$3,$4,$5,$6,$7,NOW(),NOW())
 		 RETURNING id`,
-		user.Name, user.PhoneNumber, user.Email, user.PasswordHash, user.GoogleID, user.RoleID, user.IsVerified,
+		user.Name, user.PhoneNumber, user.Email, user.PasswordHash, user.RoleID, user.IsVerified, user.EmailVerifiedAt,
 	).Scan(&user.ID)
 
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrUserExists
+		}
 		log.Printf("❌ Insert user failed: %v", err)
-		return nil, false, fmt.Errorf("insert user failed: %w", err)
+		return nil, fmt.Errorf("insert user failed: %w", err)
 	}
 
 	// Insert role extension data
@@ -193,14 +208,15 @@ func CreateOrFetchUser(user *User, extra map[string]interface{}) (*User, bool, e
 	}
 
 	log.Printf("✅ User created successfully: ID=%d", user.ID)
-	created, _ := GetUserByID(user.ID)
-	return created, true, nil
+	return GetUserByID(user.ID)
 }
 
 // --------------------- Merge Helper ---------------------
+
+// mergeUserFields only fills what the existing account lacks.
+// Credentials (password hash) and role are never taken from the incoming user.
 func mergeUserFields(existing, incoming *User) {
-	// Merge name
-	if incoming.Name != "" {
+	if existing.Name == "" {
 		existing.Name = incoming.Name
 	}
 
@@ -213,23 +229,14 @@ func mergeUserFields(existing, incoming *User) {
 	if incoming.PhoneNumber != nil && (existing.PhoneNumber == nil || *existing.PhoneNumber == "") {
 		existing.PhoneNumber = incoming.PhoneNumber
 	}
+}
 
-	// Merge GoogleID if existing is nil/empty
-	if incoming.GoogleID != nil && (existing.GoogleID == nil || *existing.GoogleID == "") {
-		existing.GoogleID = incoming.GoogleID
-	}
-
-	// Merge password hash safely
-	if incoming.PasswordHash != "" {
-		if existing.PasswordHash == "" || strings.HasPrefix(incoming.PasswordHash, "$2a$") {
-			existing.PasswordHash = incoming.PasswordHash
-		}
-	}
-
-	// Merge verification status: only upgrade to true
-	if incoming.IsVerified {
-		existing.IsVerified = true
-	}
+// saveMergedFields writes back what mergeUserFields may change
+func saveMergedFields(user *User) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE users SET name=$1, phone=$2, email=$3, updated_at=NOW() WHERE id=$4`,
+		user.Name, user.PhoneNumber, user.Email, user.ID)
+	return err
 }
 
 // --------------------- Update User ---------------------
@@ -237,9 +244,9 @@ func mergeUserFields(existing, incoming *User) {
 func UpdateUser(user *User, extra map[string]interface{}) error {
 	// Update main users table
 	_, err := DB.Exec(context.Background(),
-		`UPDATE users SET name=$1, phone=$2, email=$3, password_hash=$4, google_id=$5, role_id=$6, is_verified=$7, updated_at=NOW()
-		 WHERE id=$8`,
-		user.Name, user.PhoneNumber, user.Email, user.PasswordHash, user.GoogleID, user.RoleID, user.IsVerified, user.ID)
+		`UPDATE users SET name=$1, phone=$2, email=$3, password_hash=$4, role_id=$5, is_verified=$6, updated_at=NOW()
+		 WHERE id=$7`,
+		user.Name, user.PhoneNumber, user.Email, user.PasswordHash, user.RoleID, user.IsVerified, user.ID)
 	if err != nil {
 		return err
 	}
@@ -257,13 +264,9 @@ func UpdateUser(user *User, extra map[string]interface{}) error {
 
 func UpdateRoleDetails(userID int, role string, extra map[string]interface{}) error {
 	switch role {
-	case "admin":
-		level := "admin"
-		if extra != nil {
-			if l, ok := extra["level"].(string); ok && l != "" {
-				level = l
-			}
-		}
+	case "admin", "manager":
+		// level mirrors the role: what a manager may do is decided by role_permissions
+		level := role
 		_, err := DB.Exec(context.Background(),
 			`INSERT INTO admin_roles (user_id, level)
 			 VALUES ($1, $2)
@@ -271,17 +274,26 @@ func UpdateRoleDetails(userID int, role string, extra map[string]interface{}) er
 		return err
 
 	case "staff":
-		dept := "general"
-		if extra != nil {
-			if d, ok := extra["dept"].(string); ok && d != "" {
-				dept = d
-			}
+		// Unassigned until given; an omitted value keeps the current assignment
+		var dept *string
+		var locationID *int
+		if d, ok := extra["dept"].(string); ok && d != "" {
+			dept = &d
+		}
+		switch l := extra["location_id"].(type) {
+		case float64: // JSON numbers
+			id := int(l)
+			locationID = &id
+		case int:
+			locationID = &l
 		}
 		_, err := DB.Exec(context.Background(),
-			`INSERT INTO staff_roles (user_id, dept)
-			 VALUES ($1, $2)
-			 ON CONFLICT (user_id) DO UPDATE SET dept=$2`, userID, dept)
-		return err
+			`INSERT INTO staff_roles (user_id, dept, location_id)
+			 VALUES ($1, $2, $3)
+			 ON CONFLICT (user_id) DO UPDATE
+			 SET dept=COALESCE($2, staff_roles.dept), location_id=COALESCE($3, staff_roles.location_id)`,
+			userID, dept, locationID)
+		return departmentError(err)
 
 	case "customer":
 		points := 0
@@ -296,7 +308,8 @@ func UpdateRoleDetails(userID int, role string, extra map[string]interface{}) er
 			 ON CONFLICT (user_id) DO UPDATE SET loyalty_points=$2`, userID, points)
 		return err
 	}
-	return fmt.Errorf("invalid role: %s", role)
+	// Roles created by admins have no extension table
+	return nil
 }
 
 // --------------------- Other Helpers ---------------------
@@ -309,12 +322,7 @@ func UpdateIsVerified(userID int, verified bool) error {
 }
 
 func GetAllUsers() ([]*User, error) {
-	rows, err := DB.Query(context.Background(),
-		`SELECT u.id, u.name, u.phone, u.email, u.password_hash, u.google_id, 
-		        u.is_verified, u.role_id, r.name, u.created_at, u.updated_at
-		 FROM users u
-		 JOIN roles r ON u.role_id = r.id
-		 ORDER BY u.created_at DESC`)
+	rows, err := DB.Query(context.Background(), userSelect+` ORDER BY u.created_at DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -323,8 +331,7 @@ func GetAllUsers() ([]*User, error) {
 	var users []*User
 	for rows.Next() {
 		u := &User{}
-		if err := rows.Scan(&u.ID, &u.Name, &u.PhoneNumber, &u.Email, &u.PasswordHash, &u.GoogleID,
-			&u.IsVerified, &u.RoleID, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		if err := scanUser(rows, u); err != nil {
 			log.Printf("❌ Scan user error: %v", err)
 			return nil, err
 		}
@@ -346,7 +353,7 @@ func GetAdminLevel(userID int) (string, error) {
 func GetStaffDept(userID int) (string, error) {
 	var dept string
 	err := DB.QueryRow(context.Background(),
-		`SELECT dept FROM staff_roles WHERE user_id=$1`, userID).Scan(&dept)
+		`SELECT COALESCE(dept, '') FROM staff_roles WHERE user_id=$1`, userID).Scan(&dept)
 	if err != nil {
 		return "", err
 	}
@@ -362,3 +369,34 @@ func GetCustomerPoints(userID int) (int, error) {
 	}
 	return points, nil
 }
+
+// UpdatePassword sets a new (already hashed) password and lifts a forced reset
+func UpdatePassword(userID int, passwordHash string) error {
+	_, err := DB.Exec(context.Background(),
+		"UPDATE users SET password_hash=$1, password_reset_required=FALSE, updated_at=NOW() WHERE id=$2",
+		passwordHash, userID)
+	return err
+}
+
+// MarkPhoneVerified sets the phone confirmed by OTP and marks the account verified.
+// Returns ErrUserExists if another account holds the number.
+func MarkPhoneVerified(userID int, phone string) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE users SET phone=$2, is_verified=TRUE, updated_at=NOW() WHERE id=$1`, userID, phone)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrUserExists
+		}
+		log.Printf("❌ MarkPhoneVerified error: %v", err)
+	}
+	return err
+}
+
+// MarkEmailVerified records that the user confirmed their email address
+func MarkEmailVerified(userID int) error {
+	_, err := DB.Exec(context.Background(),
+		"UPDATE users SET email_verified_at=NOW(), updated_at=NOW() WHERE id=$1 AND email_verified_at IS NULL",
+		userID)
+	return err
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrLastAdmin is returned when a change would leave the system without an active admin
+var ErrLastAdmin = errors.New("last active admin")
+
+// UserFilter narrows SearchUsers; zero values mean "any"
+type UserFilter struct {
+	Role          string
+	Verified      *bool
+	Deactivated   *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Query         string // substring of name, email or phone
+	Limit         int
+	Offset        int
+}
+
+// UserWithExtra is a user plus its role extension columns (nil when the role has none)
+type UserWithExtra struct {
+	User
+	AdminLevel      *string
+	StaffDept       *string
+	StaffLocationID *int
+	LoyaltyPoints   *int
+	Providers       []string // linked external logins
+}
+
+// ---------------- Search Users ----------------
+// Newest first; returns one page plus the total number of matches
+func SearchUsers(f UserFilter) ([]*UserWithExtra, int, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	rows, err := DB.Query(context.Background(),
+		`SELECT u.id, u.name, u.phone, u.email, u.password_hash,
+		        u.is_verified, u.email_verified_at, u.deactivated_at, u.password_reset_required,
+		        u.deletion_scheduled_at, u.anonymized_at, u.role_id, r.name, u.created_at, u.updated_at,
+		        a.level, s.dept, s.location_id, cr.loyalty_points,
+		        ARRAY(SELECT provider FROM user_identities WHERE user_id = u.id ORDER BY provider),
+		        COUNT(*) OVER ()
+		 FROM users u
+		 JOIN roles r ON u.role_id = r.id
+		 LEFT JOIN admin_roles a ON a.user_id = u.id
+		 LEFT JOIN staff_roles s ON s.user_id = u.id
+		 LEFT JOIN customer_roles cr ON cr.user_id = u.id
+		 WHERE ($1 = '' OR r.name = $1)
+		   AND ($2::boolean IS NULL OR u.is_verified = $2)
+		   AND ($3::boolean IS NULL OR (u.deactivated_at IS NOT NULL) = $3)
+		   AND ($4::timestamp IS NULL OR u.created_at >= $4)
+		   AND ($5::timestamp IS NULL OR u.created_at < $5)
+		   AND ($6 = '' OR u.name ILIKE '%' || $6 || '%' OR u.email ILIKE '%' || $6 || '%' OR u.phone LIKE '%' || $6 || '%')
+		 ORDER BY u.created_at DESC, u.id DESC
+		 LIMIT $7 OFFSET $8`,
+		f.Role, f.Verified, f.Deactivated, f.CreatedAfter, f.CreatedBefore, f.Query, f.Limit, f.Offset)
+	if err != nil {
+		log.Printf("❌ SearchUsers error: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []*UserWithExtra{}
+	total := 0
+	for rows.Next() {
+		u := &UserWithExtra{}
+		if err := rows.Scan(&u.ID, &u.Name, &u.PhoneNumber, &u.Email, &u.PasswordHash,
+			&u.IsVerified, &u.EmailVerifiedAt, &u.DeactivatedAt, &u.PasswordResetRequired,
+			&u.DeletionScheduledAt, &u.AnonymizedAt, &u.RoleID, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+			&u.AdminLevel, &u.StaffDept, &u.StaffLocationID, &u.LoyaltyPoints, &u.Providers, &total); err != nil {
+			log.Printf("❌ Scan user error: %v", err)
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+	return users, total, rows.Err()
+}
+
+// GetUserWithExtra loads one user with its role extension columns (nil if not found)
+func GetUserWithExtra(userID int) (*UserWithExtra, error) {
+	user, err := GetUserByID(userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	u := &UserWithExtra{User: *user}
+	err = DB.QueryRow(context.Background(),
+		`SELECT a.level, s.dept, s.location_id, cr.loyalty_points,
+		        ARRAY(SELECT provider FROM user_identities WHERE user_id = u.id ORDER BY provider)
+		 FROM users u
+		 LEFT JOIN admin_roles a ON a.user_id = u.id
+		 LEFT JOIN staff_roles s ON s.user_id = u.id
+		 LEFT JOIN customer_roles cr ON cr.user_id = u.id
+		 WHERE u.id=$1`, userID).Scan(&u.AdminLevel, &u.StaffDept, &u.StaffLocationID, &u.LoyaltyPoints, &u.Providers)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// ---------------- Profile ----------------
+
+// UpdateUserProfile saves name, email and phone. A changed email must be confirmed again,
+// a changed phone verified again by OTP.
+// Returns ErrUserExists if the email or phone belongs to another account.
+func UpdateUserProfile(user *User) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE users SET name=$1, phone=$2,
+		        is_verified = CASE WHEN phone IS DISTINCT FROM $2 THEN FALSE ELSE is_verified END,
+		        email_verified_at = CASE WHEN email IS DISTINCT FROM $3 THEN NULL ELSE email_verified_at END,
+		        email=$3, updated_at=NOW()
+		 WHERE id=$4`,
+		user.Name, user.PhoneNumber, user.Email, user.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrUserExists
+		}
+		log.Printf("❌ UpdateUserProfile error: %v", err)
+	}
+	return err
+}
+
+// ---------------- Account State ----------------
+
+// SetUserDeactivated disables (true) or re-enables (false) an account.
+// Re-enabling also cancels a pending self-service deletion.
+// Returns ErrLastAdmin when deactivating the only active admin.
+func SetUserDeactivated(userID int, deactivated bool) error {
+	return withAdminsLocked(userID, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`UPDATE users SET deactivated_at = CASE WHEN $1 THEN COALESCE(deactivated_at, NOW()) END,
+			        deletion_scheduled_at = CASE WHEN $1 THEN deletion_scheduled_at END,
+			        updated_at=NOW()
+			 WHERE id=$2`, deactivated, userID)
+		return err
+	})
+}
+
+// UpdateUserRole moves an account to another role; the role extension rows are the caller's.
+// Returns ErrLastAdmin when it would demote the only active admin.
+func UpdateUserRole(userID, roleID int) error {
+	return withAdminsLocked(userID, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE users SET role_id=$1, updated_at=NOW() WHERE id=$2`, roleID, userID)
+		return err
+	})
+}
+
+// RequirePasswordReset blocks password login until the user resets their password
+func RequirePasswordReset(userID int) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE users SET password_reset_required=TRUE, updated_at=NOW() WHERE id=$1`, userID)
+	return err
+}
+
+// CountActiveUsersWithRole counts accounts of a role that aren't deactivated
+func CountActiveUsersWithRole(roleName string) (int, error) {
+	var n int
+	err := DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM users u JOIN roles r ON u.role_id = r.id
+		 WHERE r.name=$1 AND u.deactivated_at IS NULL`, roleName).Scan(&n)
+	return n, err
+}
+
+// ---------------- Last Admin ----------------
+
+// lockActiveAdmins locks the rows of all active admins until tx ends, so demotions,
+// deactivations and deletions run one after the other and each counts the admins the
+// others left. Returns ErrLastAdmin when userID is the only active admin.
+func lockActiveAdmins(ctx context.Context, tx pgx.Tx, userID int) error {
+	// Always locked in id order: two requests never wait on each other's rows
+	rows, err := tx.Query(ctx,
+		`SELECT u.id FROM users u JOIN roles r ON u.role_id = r.id
+		 WHERE r.name='admin' AND u.deactivated_at IS NULL
+		 ORDER BY u.id FOR UPDATE OF u`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	admins, isAdmin := 0, false
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		admins++
+		isAdmin = isAdmin || id == userID
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if isAdmin && admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// withAdminsLocked runs change in a transaction after lockActiveAdmins
+func withAdminsLocked(userID int, change func(ctx context.Context, tx pgx.Tx) error) error {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockActiveAdmins(ctx, tx, userID); err != nil {
+		return err
+	}
+	if err := change(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// onlyActiveAdmin skips the test unless adminID is the database's only active admin
+func onlyActiveAdmin(t *testing.T, adminID int) {
+	t.Helper()
+	var others int
+	if err := DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM users u JOIN roles r ON u.role_id = r.id
+		 WHERE r.name='admin' AND u.deactivated_at IS NULL AND u.id <> $1`, adminID).Scan(&others); err != nil {
+		t.Fatal(err)
+	}
+	if others > 0 {
+		t.Skip("the test database has other active admins")
+	}
+}
+
+func TestLastAdminGuardrails(t *testing.T) {
+	testDB(t)
+	adminID := testUser(t, "admin")
+	onlyActiveAdmin(t, adminID)
+	customerRole, err := GetRoleID("customer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := []struct {
+		name   string
+		change func(userID int) error
+	}{
+		{"deactivate", func(id int) error { return SetUserDeactivated(id, true) }},
+		{"demote", func(id int) error { return UpdateUserRole(id, customerRole) }},
+		{"schedule deletion", func(id int) error { return ScheduleAccountDeletion(id, time.Now()) }},
+	}
+	for _, tt := range changes {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(adminID); !errors.Is(err, ErrLastAdmin) {
+				t.Fatalf("error = %v, want ErrLastAdmin", err)
+			}
+			admin, err := GetUserByID(adminID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if admin.Role != "admin" || admin.DeactivatedAt != nil || admin.DeletionScheduledAt != nil {
+				t.Errorf("the last admin was changed: role %q, deactivated %v, deletion %v",
+					admin.Role, admin.DeactivatedAt, admin.DeletionScheduledAt)
+			}
+		})
+	}
+
+	t.Run("other accounts are not held back", func(t *testing.T) {
+		customerID := testUser(t, "customer")
+		if err := SetUserDeactivated(customerID, true); err != nil {
+			t.Errorf("SetUserDeactivated(customer) error = %v", err)
+		}
+	})
+
+	t.Run("one of two admins", func(t *testing.T) {
+		secondID := testUser(t, "admin")
+		if err := SetUserDeactivated(adminID, true); err != nil {
+			t.Fatalf("SetUserDeactivated(first) error = %v", err)
+		}
+		// The first is no longer active: the second is now the last one
+		if err := UpdateUserRole(secondID, customerRole); !errors.Is(err, ErrLastAdmin) {
+			t.Errorf("UpdateUserRole(second) error = %v, want ErrLastAdmin", err)
+		}
+		if err := SetUserDeactivated(adminID, false); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"auth-backend/utils"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Token purposes stored in user_tokens
+const (
+	TokenPasswordReset     = "password_reset"
+	TokenEmailVerification = "email_verification"
+	TokenEmailChange       = "email_change" // payload: the new email address
+	TokenDeletionCancel    = "deletion_cancel"
+	TokenMagicLink         = "magic_link" // passwordless login
+)
+
+// ---------------- Create User Token ----------------
+// Issues a new single-use token and invalidates older ones of the same purpose.
+// Returns the plaintext token, which is never stored.
+func CreateUserToken(userID int, purpose string, ttl time.Duration) (string, error) {
+	return CreateUserTokenWithPayload(userID, purpose, "", ttl)
+}
+
+// CreateUserTokenWithPayload is CreateUserToken with data returned again on consumption
+func CreateUserTokenWithPayload(userID int, purpose, payload string, ttl time.Duration) (string, error) {
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE user_tokens SET used_at=NOW()
+		 WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL`, userID, purpose); err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO user_tokens (user_id, purpose, token_hash, payload, expires_at, created_at)
+		 VALUES ($1,$2,$3,NULLIF($4,''),$5,NOW())`,
+		userID, purpose, utils.HashToken(token), payload, time.Now().Add(ttl)); err != nil {
+		log.Printf("❌ CreateUserToken error: %v", err)
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ---------------- Consume User Token ----------------
+// Marks a valid token as used and returns its user ID (0 if invalid/expired/used)
+func ConsumeUserToken(purpose, token string) (int, error) {
+	userID, _, err := ConsumeUserTokenWithPayload(purpose, token)
+	return userID, err
+}
+
+// ConsumeUserTokenWithPayload is ConsumeUserToken that also returns the token's payload
+func ConsumeUserTokenWithPayload(purpose, token string) (int, string, error) {
+	var userID int
+	var payload string
+	err := DB.QueryRow(context.Background(),
+		`UPDATE user_tokens SET used_at=NOW()
+		 WHERE token_hash=$1 AND purpose=$2 AND used_at IS NULL AND expires_at > NOW()
+		 RETURNING user_id, COALESCE(payload, '')`,
+		utils.HashToken(token), purpose,
+	).Scan(&userID, &payload)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, "", nil
+		}
+		return 0, "", err
+	}
+	return userID, payload, nil
+}
+
+// ---------------- Pending User Token ----------------
+// GetPendingTokenPayload returns the payload of the user's unused, unexpired token of a purpose ("" if none)
+func GetPendingTokenPayload(userID int, purpose string) (string, error) {
+	var payload string
+	err := DB.QueryRow(context.Background(),
+		`SELECT COALESCE(payload, '') FROM user_tokens
+		 WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL AND expires_at > NOW()
+		 ORDER BY created_at DESC LIMIT 1`, userID, purpose).Scan(&payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return payload, err
+}
+
+// CancelUserTokens invalidates the user's unused tokens of a purpose
+func CancelUserTokens(userID int, purpose string) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE user_tokens SET used_at=NOW() WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL`,
+		userID, purpose)
+	return err
+}
+
+// ---------------- Delete Old User Tokens ----------------
+func DeleteExpiredUserTokens() (int64, error) {
+	cmdTag, err := DB.Exec(context.Background(),
+		`DELETE FROM user_tokens WHERE expires_at < NOW() - INTERVAL '1 day'`)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"auth-backend/models"
+	"flag"
+	"log"
+)
+
+// runNormalizePhones rewrites stored phone numbers to E.164 and reports the ones it can't:
+//
+//	auth-backend normalize-phones [-dry-run]
+//
+// Stored numbers are normalized once by the normalize_phones migration; run this again after
+// changing PHONE_DEFAULT_REGION, or after fixing the numbers the migration reported.
+func runNormalizePhones(args []string) {
+	fs := flag.NewFlagSet("normalize-phones", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report changes without writing them")
+	fs.Parse(args)
+
+	report, err := models.NormalizeUserPhones(*dryRun)
+	if err != nil {
+		log.Fatalf("❌ Failed to normalize phone numbers: %v", err)
+	}
+
+	for _, ch := range report.Updated {
+		log.Printf("📞 User %d: %s → %s", ch.UserID, ch.From, ch.To)
+	}
+	for _, p := range report.Invalid {
+		log.Printf("⚠️ User %d: %q is not a valid phone number, left unchanged", p.UserID, p.Phone)
+	}
+	for _, d := range report.Duplicates {
+		log.Printf("⚠️ Duplicate %s shared by:", d.Phone)
+		for _, u := range d.Users {
+			log.Printf("     user %d (%s)", u.UserID, u.Phone)
+		}
+	}
+
+	verb := "updated"
+	if *dryRun {
+		verb = "would update"
+	}
+	log.Printf("✅ Checked %d phone numbers: %s %d, %d invalid, %d duplicated numbers (left unchanged)",
+		report.Checked, verb, len(report.Updated), len(report.Invalid), len(report.Duplicates))
+}
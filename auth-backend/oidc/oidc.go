@@ -0,0 +1,288 @@
+package oidc
+
+import (
+	"auth-backend/config"
+	"cinema-shared/auth"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned when a provider token fails verification
+var ErrInvalidToken = errors.New("invalid provider token")
+
+// Identity is the external account a verified provider token belongs to
+type Identity struct {
+	Provider      string
+	Subject       string // stable user ID at the provider ("sub")
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is one configured login provider
+type Provider struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // "oidc" or "oauth2"
+	issuer      string
+	clientIDs   []string
+	userInfoURL string
+	appSecret   string
+
+	mu       sync.Mutex
+	issuers  []string // accepted "iss" values (from discovery)
+	jwks     *auth.JWKSClient
+	lastFail time.Time
+}
+
+var (
+	registry   = map[string]*Provider{}
+	httpClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// Discovery failures are retried at most this often
+const discoveryRetry = 30 * time.Second
+
+// Init registers the providers from config; discovery happens on first use
+func Init(cfg *config.Config) error {
+	registry = map[string]*Provider{}
+	for _, pc := range cfg.OIDCProviders {
+		p := &Provider{
+			Name:        pc.Name,
+			Type:        strings.ToLower(pc.Type),
+			issuer:      pc.Issuer,
+			clientIDs:   pc.ClientIDs,
+			userInfoURL: pc.UserInfoURL,
+			appSecret:   pc.AppSecret,
+		}
+		switch p.Type {
+		case "oidc":
+			if p.issuer == "" || len(p.clientIDs) == 0 {
+				return fmt.Errorf("provider %q: OIDC_%s_ISSUER and OIDC_%s_CLIENT_IDS are required",
+					p.Name, strings.ToUpper(p.Name), strings.ToUpper(p.Name))
+			}
+		case "oauth2":
+			// Without the app secret a token issued to any other app would be accepted
+			if p.userInfoURL == "" || p.appSecret == "" {
+				return fmt.Errorf("provider %q: OIDC_%s_USERINFO_URL and OIDC_%s_APP_SECRET are required",
+					p.Name, strings.ToUpper(p.Name), strings.ToUpper(p.Name))
+			}
+		default:
+			return fmt.Errorf("provider %q: unknown type %q", p.Name, pc.Type)
+		}
+		if _, dup := registry[p.Name]; dup {
+			return fmt.Errorf("provider %q configured twice", p.Name)
+		}
+		registry[p.Name] = p
+		log.Printf("✅ Login provider %q registered (%s)", p.Name, p.Type)
+	}
+	return nil
+}
+
+// Get returns a registered provider
+func Get(name string) (*Provider, bool) {
+	p, ok := registry[strings.ToLower(name)]
+	return p, ok
+}
+
+// List returns the registered providers sorted by name
+func List() []*Provider {
+	out := make([]*Provider, 0, len(registry))
+	for _, p := range registry {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Verify checks a provider token and returns the identity behind it.
+// oidc providers take an ID token (nonce is checked when non-empty), oauth2 providers an access token.
+func (p *Provider) Verify(ctx context.Context, token, nonce string) (*Identity, error) {
+	if p.Type == "oauth2" {
+		return p.userInfo(ctx, token)
+	}
+	return p.verifyIDToken(ctx, token, nonce)
+}
+
+// ---------------- OpenID Connect ----------------
+
+// discover loads the issuer's discovery document once (retried after failures)
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.jwks != nil {
+		return nil
+	}
+	if time.Since(p.lastFail) < discoveryRetry {
+		return fmt.Errorf("discovery for %q failed recently", p.Name)
+	}
+
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := getJSON(ctx, p.issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+		p.lastFail = time.Now()
+		return fmt.Errorf("discovery for %q: %w", p.Name, err)
+	}
+	if doc.JWKSURI == "" {
+		p.lastFail = time.Now()
+		return fmt.Errorf("discovery for %q: no jwks_uri", p.Name)
+	}
+
+	p.issuers = []string{p.issuer}
+	if doc.Issuer != "" && doc.Issuer != p.issuer {
+		p.issuers = append(p.issuers, doc.Issuer)
+	}
+	// Google also signs tokens with the scheme-less issuer
+	if p.issuer == "https://accounts.google.com" {
+		p.issuers = append(p.issuers, "accounts.google.com")
+	}
+	p.jwks = auth.NewJWKSClient(doc.JWKSURI)
+	return nil
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, idToken, nonce string) (*Identity, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			key, ok := p.jwks.VerificationKey(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown signing key %q", kid)
+			}
+			return key, nil
+		},
+		jwt.WithValidMethods([]string{auth.Algorithm}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	iss, _ := claims.GetIssuer()
+	if !containsString(p.issuers, iss) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+	}
+	aud, _ := claims.GetAudience()
+	audOK := false
+	for _, a := range aud {
+		if containsString(p.clientIDs, a) {
+			audOK = true
+			break
+		}
+	}
+	if !audOK {
+		return nil, fmt.Errorf("%w: unexpected audience %v", ErrInvalidToken, aud)
+	}
+	if nonce != "" {
+		if got, _ := claims["nonce"].(string); got != nonce {
+			return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+		}
+	}
+
+	return identityFromClaims(p.Name, claims)
+}
+
+// ---------------- OAuth2 (user info endpoint) ----------------
+
+func (p *Provider) userInfo(ctx context.Context, accessToken string) (*Identity, error) {
+	// Proves the token was issued to our app: Graph API rejects proofs made with another app's secret
+	mac := hmac.New(sha256.New, []byte(p.appSecret))
+	mac.Write([]byte(accessToken))
+	endpoint := p.userInfoURL
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	endpoint += sep + "appsecret_proof=" + url.QueryEscape(hex.EncodeToString(mac.Sum(nil)))
+
+	claims := map[string]interface{}{}
+	if err := getJSON(ctx, endpoint, accessToken, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	// Facebook-style APIs return "id" instead of "sub"
+	if _, ok := claims["sub"]; !ok {
+		claims["sub"] = claims["id"]
+	}
+	return identityFromClaims(p.Name, claims)
+}
+
+// ---------------- Helpers ----------------
+
+func identityFromClaims(provider string, claims map[string]interface{}) (*Identity, error) {
+	var subject string
+	switch sub := claims["sub"].(type) {
+	case string:
+		subject = sub
+	case float64: // numeric IDs
+		subject = fmt.Sprintf("%.0f", sub)
+	}
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	id := &Identity{Provider: provider, Subject: subject}
+	id.Email, _ = claims["email"].(string)
+	id.Email = strings.ToLower(strings.TrimSpace(id.Email))
+	// Apple sends email_verified as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = v
+	case string:
+		id.EmailVerified = v == "true"
+	}
+	id.Name, _ = claims["name"].(string)
+	id.Name = strings.TrimSpace(id.Name)
+	return id, nil
+}
+
+// getJSON GETs endpoint (with a bearer token if given) and decodes the JSON response
+func getJSON(ctx context.Context, endpoint, bearer string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package oidc
+
+import (
+	"auth-backend/config"
+	"auth-backend/oidc/oidctest"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider config.OIDCProvider
+		wantErr  bool
+	}{
+		{"oidc", config.OIDCProvider{Name: "google", Type: "oidc", Issuer: "https://accounts.google.com", ClientIDs: []string{"cinema"}}, false},
+		{"oidc without client IDs", config.OIDCProvider{Name: "google", Type: "oidc", Issuer: "https://accounts.google.com"}, true},
+		{"oauth2", config.OIDCProvider{Name: "facebook", Type: "oauth2", UserInfoURL: "https://graph.facebook.com/me", AppSecret: "secret"}, false},
+		{"oauth2 without app secret", config.OIDCProvider{Name: "facebook", Type: "oauth2", UserInfoURL: "https://graph.facebook.com/me"}, true},
+		{"unknown type", config.OIDCProvider{Name: "saml", Type: "saml"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Init(&config.Config{OIDCProviders: []config.OIDCProvider{tt.provider}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Init() error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	srv := oidctest.NewServer(t)
+	p := &Provider{Name: "mock", Type: "oidc", issuer: srv.URL, clientIDs: []string{"cinema"}}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := srv.Claims("alice", "cinema")
+		claims["email"], claims["email_verified"], claims["nonce"] = "Alice@Example.com", true, "n-1"
+		for k, v := range changes {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		wantErr bool
+	}{
+		{"valid", srv.Sign(t, with(nil)), "n-1", false},
+		{"nonce not checked when not sent", srv.Sign(t, with(nil)), "", false},
+		{"another of our client IDs", srv.Sign(t, with(jwt.MapClaims{"aud": []string{"other", "cinema"}})), "n-1", false},
+		{"wrong issuer", srv.Sign(t, with(jwt.MapClaims{"iss": "https://evil.example.com"})), "n-1", true},
+		{"wrong audience", srv.Sign(t, with(jwt.MapClaims{"aud": "another-app"})), "n-1", true},
+		{"wrong nonce", srv.Sign(t, with(nil)), "n-2", true},
+		{"expired", srv.Sign(t, with(jwt.MapClaims{"exp": time.Now().Add(-2 * time.Minute).Unix()})), "n-1", true},
+		{"no expiry", srv.Sign(t, with(jwt.MapClaims{"exp": nil})), "n-1", true},
+		{"unknown kid", oidctest.SignWith(t, otherKey, "rotated-away", with(nil)), "n-1", true},
+		{"known kid, wrong key", oidctest.SignWith(t, otherKey, oidctest.KeyID, with(nil)), "n-1", true},
+		{"no subject", srv.Sign(t, with(jwt.MapClaims{"sub": ""})), "n-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ident, err := p.Verify(context.Background(), tt.token, tt.nonce)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			want := Identity{Provider: "mock", Subject: "alice", Email: "alice@example.com", EmailVerified: true}
+			if *ident != want {
+				t.Errorf("Verify() = %+v, want %+v", *ident, want)
+			}
+		})
+	}
+}
+
+func TestUserInfoSendsAppSecretProof(t *testing.T) {
+	proof := func(token string) string {
+		mac := hmac.New(sha256.New, []byte("app-secret"))
+		mac.Write([]byte(token))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	// Answers like the Graph API: only tokens of our app with a matching proof
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token != "our-app-token" || r.URL.Query().Get("appsecret_proof") != proof(token) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1234567890, "name": "Alice"})
+	}))
+	defer srv.Close()
+
+	p := &Provider{Name: "facebook", Type: "oauth2", userInfoURL: srv.URL + "/me?fields=id,name", appSecret: "app-secret"}
+	ident, err := p.Verify(context.Background(), "our-app-token", "")
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if ident.Subject != "1234567890" || ident.Name != "Alice" {
+		t.Errorf("Verify() = %+v, want subject 1234567890", *ident)
+	}
+	if _, err := p.Verify(context.Background(), "other-app-token", ""); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify(other app's token) error = %v, want ErrInvalidToken", err)
+	}
+}
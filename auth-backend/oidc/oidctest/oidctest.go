@@ -0,0 +1,77 @@
+// Package oidctest runs an in-process OpenID Connect provider for tests:
+// discovery, a JWKS with one key and ID tokens signed with it (see cmd/mockoidc for a standalone one).
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cinema-shared/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyID is the kid of the provider's signing key
+const KeyID = "oidctest"
+
+// Server is a mock provider; its URL is also its issuer
+type Server struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+// NewServer starts a provider that is closed when the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": s.URL, "jwks_uri": s.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(auth.JWKSet{Keys: []auth.JWK{auth.NewRSAJWK(KeyID, &key.PublicKey)}})
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Claims returns valid ID token claims for sub, issued to aud
+func (s *Server) Claims(sub, aud string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss": s.URL,
+		"sub": sub,
+		"aud": aud,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+// Sign returns an ID token with claims, signed with the provider's key
+func (s *Server) Sign(t testing.TB, claims jwt.MapClaims) string {
+	t.Helper()
+	return SignWith(t, s.key, KeyID, claims)
+}
+
+// SignWith signs claims with any key, e.g. one the provider never published
+func SignWith(t testing.TB, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"auth-backend/controllers"
 	"auth-backend/middleware"
+	"auth-backend/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,41 +11,142 @@ import (
 
 // SetupRoutes sets up all HTTP routes
 func SetupRoutes(router *gin.Engine) {
+	// ---------------- Public signing keys ----------------
+	router.GET("/.well-known/jwks.json", controllers.JWKS)
+
 	// ---------------- Public routes ----------------
 	public := router.Group("/api")
 	{
-		public.POST("/auth/email", controllers.EmailAuth)
-		public.POST("/auth/google", controllers.GoogleLogin) // reads GOOGLE_CLIENT_ID internally
+		public.POST("/auth/register", controllers.EmailRegister)
+		public.POST("/auth/login", controllers.EmailLogin)
+		public.POST("/auth/email", controllers.EmailLogin)   // legacy alias, login only
+		public.POST("/auth/google", controllers.GoogleLogin) // same as /auth/oidc/google
+
+		// External login providers (OIDC_PROVIDERS)
+		public.GET("/auth/providers", controllers.ListProviders)
+		public.POST("/auth/oidc/:provider", controllers.OIDCLogin)
+
+		// Passwordless login by email
+		public.POST("/auth/magic-link", controllers.RequestMagicLink)
+		public.POST("/auth/magic-link/verify", controllers.MagicLinkLogin)
+
+		// Phone-first login / signup by SMS code
+		public.POST("/auth/phone/login", controllers.PhoneLogin)
+		public.POST("/auth/phone/login/verify", controllers.PhoneLoginVerify)
+
+		// Password recovery
+		public.POST("/auth/password/forgot", controllers.ForgotPassword)
+		public.POST("/auth/password/reset", controllers.ResetPassword)
+
+		// Email verification
+		public.POST("/auth/email/verify", controllers.VerifyEmail)
+		public.POST("/auth/email/resend", controllers.ResendVerificationEmail)
+		public.POST("/auth/email/change/confirm", controllers.ConfirmEmailChange)
+		public.POST("/auth/account/deletion/cancel", controllers.CancelAccountDeletion)
+
+		// Second login step for accounts with 2FA
+		public.POST("/auth/2fa/verify", controllers.MFAVerify)
 	}
 
 	// ---------------- Protected routes (JWT) ----------------
 	protected := router.Group("/api")
 	protected.Use(middleware.AuthMiddleware()) // reads JWT_SECRET internally
 	{
-		// User profile
-		protected.GET("/profile", func(c *gin.Context) {
-			userID, _ := c.Get("user_id")
-			role, _ := c.Get("role")
-			isVerified, _ := c.Get("is_verified")
-			c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": role, "is_verified": isVerified})
-		})
+		// User profile (a new phone is confirmed through /auth/verify-otp)
+		protected.GET("/profile", controllers.GetProfile)
+		protected.PATCH("/profile", controllers.UpdateProfile)
+
+		// Linked external logins
+		protected.GET("/profile/identities", controllers.ListIdentities)
+		protected.POST("/profile/identities/:provider", controllers.LinkIdentity)
+		protected.DELETE("/profile/identities/:provider", controllers.UnlinkIdentity)
+
+		// Personal data: export and account deletion
+		protected.GET("/profile/export", controllers.ExportMyData)
+		protected.POST("/profile/deletion", controllers.RequestAccountDeletion)
 
 		// Phone OTP endpoints
 		protected.POST("/auth/phone", controllers.PhoneAuth)
 		protected.POST("/auth/verify-otp", controllers.VerifyOTP)
+
+		// Logout / token revocation
+		protected.POST("/auth/logout", controllers.Logout)
+		protected.POST("/auth/logout-all", controllers.LogoutAll)
+
+		// Password change (requires current password)
+		protected.POST("/auth/password/change", controllers.ChangePassword)
+
+		// Sessions / devices
+		protected.GET("/sessions", controllers.ListSessions)
+		protected.DELETE("/sessions/:id", controllers.RevokeSession)
+
+		// TOTP two-factor authentication
+		protected.GET("/auth/2fa", controllers.MFAStatus)
+		protected.POST("/auth/2fa/setup", controllers.MFASetup)
+		protected.POST("/auth/2fa/enable", controllers.MFAEnable)
+		protected.POST("/auth/2fa/disable", controllers.MFADisable)
+		protected.POST("/auth/2fa/recovery-codes", controllers.MFARegenerateRecoveryCodes)
+	}
+
+	// ---------------- Internal routes (service-to-service) ----------------
+	internal := router.Group("/api/internal")
+	internal.Use(middleware.InternalMiddleware()) // reads INTERNAL_API_KEY internally
+	{
+		internal.GET("/revocations", controllers.RevocationList)
 	}
 
 	// ---------------- Admin routes ----------------
+	// Each area needs a permission (role + per-user overrides), not a fixed role
 	admin := router.Group("/api/admin")
-	admin.Use(middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"))
+	admin.Use(middleware.AuthMiddleware())
 	{
-		admin.GET("/dashboard", func(c *gin.Context) {
+		admin.GET("/dashboard", middleware.RoleMiddleware("admin"), func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"message": "Admin dashboard"})
 		})
 
-		admin.POST("/create-user", controllers.CreateUserByAdmin)
-		admin.POST("/change-role", controllers.ChangeUserRole)
-		admin.GET("/users", controllers.ListUsers)
+		// Users, their sessions, lockouts, 2FA and permission overrides
+		users := admin.Group("", middleware.RequirePermission(models.PermUsersManage))
+		users.POST("/create-user", controllers.CreateUserByAdmin)
+		users.POST("/change-role", controllers.ChangeUserRole)
+		users.GET("/users", controllers.ListUsers)
+		users.GET("/users/:id", controllers.GetUser)
+		users.PATCH("/users/:id", controllers.UpdateUserByAdmin)
+		users.DELETE("/users/:id", controllers.DeleteUser)
+		users.POST("/users/:id/merge", controllers.MergeUsers)
+		users.GET("/users/:id/merges", controllers.ListUserMerges)
+		users.PUT("/users/:id/role", controllers.SetUserRole)
+		users.POST("/users/:id/deactivate", controllers.DeactivateUser)
+		users.POST("/users/:id/reactivate", controllers.ReactivateUser)
+		users.POST("/users/:id/password-reset", controllers.ForcePasswordReset)
+		users.PUT("/users/:id/staff-assignment", controllers.SetStaffAssignment)
+		users.GET("/departments", controllers.ListDepartments)
+		users.GET("/users/:id/sessions", controllers.AdminListUserSessions)
+		users.DELETE("/users/:id/sessions", controllers.AdminRevokeAllUserSessions)
+		users.DELETE("/users/:id/sessions/:session_id", controllers.AdminRevokeUserSession)
+		users.GET("/users/:id/login-status", controllers.AdminLoginStatus)
+		users.DELETE("/users/:id/login-lock", controllers.AdminUnlockLogin)
+		users.DELETE("/users/:id/2fa", controllers.AdminResetUserMFA)
+		users.GET("/users/:id/permissions", controllers.GetUserPermissions)
+		users.PUT("/users/:id/permissions/:perm", controllers.SetUserPermission)
+		users.DELETE("/users/:id/permissions/:perm", controllers.DeleteUserPermission)
+
+		// Roles, their policies and permissions
+		roles := admin.Group("", middleware.RequirePermission(models.PermRolesManage))
+		roles.GET("/permissions", controllers.ListPermissions)
+		roles.GET("/roles", controllers.ListRolePolicies)
+		roles.POST("/roles", controllers.CreateRole)
+		roles.PUT("/roles/:role/email-policy", controllers.UpdateRoleEmailPolicy)
+		roles.PUT("/roles/:role/mfa-policy", controllers.UpdateRoleMFAPolicy)
+		roles.GET("/roles/:role/permissions", controllers.GetRolePermissions)
+		roles.PUT("/roles/:role/permissions", controllers.UpdateRolePermissions)
+
+		// Security log, IP lockouts, JWT signing keys
+		security := admin.Group("", middleware.RequirePermission(models.PermSecurityManage))
+		security.DELETE("/login-locks/ip/:ip", controllers.AdminUnlockIP)
+		security.GET("/security-events", controllers.ListSecurityEvents)
+		security.GET("/audit-events", controllers.ListAuditEvents)
+		security.GET("/keys", controllers.ListSigningKeys)
+		security.POST("/keys/rotate", controllers.RotateSigningKey)
 	}
 
 	// ---------------- Staff routes ----------------
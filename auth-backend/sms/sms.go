@@ -0,0 +1,185 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-backend/config"
+)
+
+// SMSSender delivers a text message to a phone number
+type SMSSender interface {
+	Send(to, message string) error
+}
+
+var current SMSSender
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Init selects the SMS provider from config (SMS_PROVIDER=log|twilio|africastalking)
+func Init(cfg *config.Config) error {
+	switch strings.ToLower(cfg.SMSProvider) {
+	case "twilio":
+		if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.TwilioFrom == "" {
+			return fmt.Errorf("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM are required for the twilio provider")
+		}
+		current = &TwilioSender{
+			BaseURL:    cfg.TwilioBaseURL,
+			AccountSID: cfg.TwilioAccountSID,
+			AuthToken:  cfg.TwilioAuthToken,
+			From:       cfg.TwilioFrom,
+		}
+	case "africastalking":
+		if cfg.ATUsername == "" || cfg.ATAPIKey == "" {
+			return fmt.Errorf("AT_USERNAME and AT_API_KEY are required for the africastalking provider")
+		}
+		current = &AfricasTalkingSender{
+			BaseURL:  cfg.ATBaseURL,
+			Username: cfg.ATUsername,
+			APIKey:   cfg.ATAPIKey,
+			SenderID: cfg.ATSenderID,
+		}
+	case "", "log":
+		current = &LogSender{Path: cfg.SMSLogFile}
+		log.Println("⚠️ SMS log provider writes message bodies (including OTP codes); use it for local development only")
+	default:
+		return fmt.Errorf("unknown SMS_PROVIDER %q", cfg.SMSProvider)
+	}
+
+	log.Printf("✅ SMS provider initialized (%T)", current)
+	return nil
+}
+
+// Send delivers a message through the configured provider
+func Send(to, message string) error {
+	if current == nil {
+		return fmt.Errorf("sms provider not initialized")
+	}
+	return current.Send(to, message)
+}
+
+// ---------------- Twilio-style provider ----------------
+
+type TwilioSender struct {
+	BaseURL    string // e.g. https://api.twilio.com
+	AccountSID string
+	AuthToken  string
+	From       string
+}
+
+func (s *TwilioSender) Send(to, message string) error {
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimRight(s.BaseURL, "/"), s.AccountSID)
+	form := url.Values{"To": {to}, "From": {s.From}, "Body": {message}}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.AccountSID, s.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var body struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("twilio: status %d: %s (code %d)", resp.StatusCode, body.Message, body.Code)
+	}
+	return nil
+}
+
+// ---------------- Africa's Talking-style provider ----------------
+
+type AfricasTalkingSender struct {
+	BaseURL  string // e.g. https://api.africastalking.com (sandbox: https://api.sandbox.africastalking.com)
+	Username string
+	APIKey   string
+	SenderID string // optional short code / alphanumeric sender
+}
+
+func (s *AfricasTalkingSender) Send(to, message string) error {
+	form := url.Values{"username": {s.Username}, "to": {to}, "message": {message}}
+	if s.SenderID != "" {
+		form.Set("from", s.SenderID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(s.BaseURL, "/")+"/version1/messaging", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("apiKey", s.APIKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("africastalking: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// A 201 can still carry a per-recipient failure
+	var body struct {
+		SMSMessageData struct {
+			Recipients []struct {
+				Status string `json:"status"`
+			} `json:"Recipients"`
+		} `json:"SMSMessageData"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("africastalking: invalid response: %v", err)
+	}
+	if len(body.SMSMessageData.Recipients) == 0 {
+		return fmt.Errorf("africastalking: message not accepted for %s", to)
+	}
+	if status := body.SMSMessageData.Recipients[0].Status; status != "Success" {
+		return fmt.Errorf("africastalking: delivery status %q", status)
+	}
+	return nil
+}
+
+// ---------------- File / log provider (local development) ----------------
+
+type LogSender struct {
+	Path string // optional file to append messages to; logs only if empty
+
+	mu sync.Mutex
+}
+
+func (s *LogSender) Send(to, message string) error {
+	if s.Path == "" {
+		log.Printf("📱 [LogSender] To=%s Message=%q", to, message)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, message)
+	return err
+}
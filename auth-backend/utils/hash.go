@@ -1,6 +1,10 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -11,3 +15,17 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// BurnPasswordCheck runs a bcrypt comparison that always fails, so that a login
+// for an unknown account takes as long as one with a wrong password
+func BurnPasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword("not-a-real-password")
+	})
+	CheckPasswordHash(password, dummyHash)
+}
@@ -1,55 +1,85 @@
 package utils
 
 import (
-	"fmt"
-	"os"
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"cinema-shared/auth"
 )
 
-var jwtSecret []byte
-
-func init() {
-	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// KeyProvider supplies the RS256 keys tokens are signed and verified with (see package keys)
+type KeyProvider interface {
+	auth.SigningKeyProvider
+	VerificationKey(kid string) (crypto.PublicKey, bool)
 }
 
-// GenerateAccessToken → short-lived (15 min)
-func GenerateAccessToken(userID int, role string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     time.Now().Add(15 * time.Minute).Unix(),
-		"type":    "access",
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
+var (
+	issuer   *auth.Issuer
+	verifier *auth.Verifier
+)
 
-// GenerateRefreshToken → long-lived (7 days)
-func GenerateRefreshToken(userID int) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(7 * 24 * time.Hour).Unix(),
-		"type":    "refresh",
+// InitTokens wires the keyring and the revocation store in; call once from main before serving requests
+func InitTokens(keys KeyProvider, revocations auth.RevocationChecker) {
+	issuer = &auth.Issuer{
+		Keys:     keys,
+		Issuer:   auth.DefaultIssuer,
+		Audience: []string{auth.DefaultAudience},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
-
-// VerifyJWT parses and validates any JWT
-func VerifyJWT(tokenStr string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return jwtSecret, nil
-	})
-	if err != nil {
-		return nil, err
+	verifier = &auth.Verifier{
+		Keys:        keys,
+		Issuer:      auth.DefaultIssuer,
+		Audience:    auth.DefaultAudience,
+		Revocations: revocations,
 	}
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
+}
+
+// TokenVerifier returns the verifier used by the middleware
+func TokenVerifier() *auth.Verifier {
+	return verifier
+}
+
+const (
+	AccessTokenTTL       = 15 * time.Minute
+	RefreshTokenTTL      = 7 * 24 * time.Hour
+	MFAChallengeTokenTTL = 5 * time.Minute
+)
+
+// NewTokenID returns a random identifier (session IDs, key IDs)
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
-	return nil, fmt.Errorf("invalid token: claims are invalid")
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateAccessToken → short-lived (15 min), bound to a login session.
+// claims carries the user, session, 2FA state, permissions and staff scope; the type is set here.
+func GenerateAccessToken(claims *auth.Claims) (string, error) {
+	claims.Type = auth.TypeAccess
+	return issuer.Issue(claims, AccessTokenTTL)
+}
+
+// GenerateRefreshToken → long-lived (7 days), bound to a login session
+func GenerateRefreshToken(userID int, sessionID string) (string, error) {
+	return issuer.Issue(&auth.Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Type:      auth.TypeRefresh,
+	}, RefreshTokenTTL)
+}
+
+// GenerateMFAChallengeToken → short-lived (5 min), proves the first login factor passed
+func GenerateMFAChallengeToken(userID int) (string, error) {
+	return issuer.Issue(&auth.Claims{
+		UserID: userID,
+		Type:   auth.TypeMFAChallenge,
+	}, MFAChallengeTokenTTL)
+}
+
+// VerifyToken validates a token issued by this service and checks its type
+func VerifyToken(tokenString, tokenType string) (*auth.Claims, error) {
+	return verifier.Verify(tokenString, tokenType)
 }
@@ -1,16 +1,65 @@
 package utils
 
 import (
+	"auth-backend/sms"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"math/rand"
-	"time"
+	"math/big"
 )
 
-// GenerateAndSendOTP generates and simulates sending OTP
+// ErrOTPDelivery wraps SMS provider failures
+var ErrOTPDelivery = errors.New("otp delivery failed")
+
+var otpKey []byte
+
+// SetOTPKey sets the secret used to hash OTPs (OTP_HMAC_KEY)
+func SetOTPKey(key string) {
+	otpKey = []byte(key)
+}
+
+// HashOTP returns the keyed hash of a code, bound to the phone it was sent to.
+// Only this hash is stored, in Redis and in otp_history.
+func HashOTP(phone, code string) string {
+	mac := hmac.New(sha256.New, otpKey)
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckOTP compares a submitted code against a stored hash in constant time
+func CheckOTP(phone, code, hash string) bool {
+	expected, err := hex.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, otpKey)
+	mac.Write([]byte(phone + ":" + code))
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// GenerateOTP returns a uniformly random 6-digit code
+func GenerateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// GenerateAndSendOTP generates an OTP and sends it through the SMS provider.
+// On a delivery failure the code is still returned (with ErrOTPDelivery) so the attempt can be recorded.
 func GenerateAndSendOTP(phone string) (string, error) {
-	rand.Seed(time.Now().UnixNano())
-	code := fmt.Sprintf("%06d", rand.Intn(1000000))
-	// In production: integrate SMS provider (Twilio, etc.)
-	fmt.Printf("Sending OTP %s to phone %s\n", code, phone)
+	code, err := GenerateOTP()
+	if err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("Your Cinema verification code is %s. It expires in 5 minutes.", code)
+	if err := sms.Send(phone, message); err != nil {
+		return code, fmt.Errorf("%w: %v", ErrOTPDelivery, err)
+	}
 	return code, nil
 }
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"errors"
+	"unicode"
+)
+
+const (
+	MinPasswordLength = 8
+	MaxPasswordBytes  = 72 // bcrypt ignores anything longer
+)
+
+// ValidatePassword enforces the password strength rules
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return errors.New("password must be at least 8 characters long")
+	}
+	if len(password) > MaxPasswordBytes {
+		return errors.New("password must be at most 72 bytes long")
+	}
+
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasUpper || !hasLower || !hasDigit {
+		return errors.New("password must contain an uppercase letter, a lowercase letter and a digit")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidPhone is returned for numbers that can't be read as a valid E.164 number
+var ErrInvalidPhone = errors.New("invalid phone number, use international format, e.g. +251912345678")
+
+// phoneRegion describes national numbering for one region (ISO 3166-1 alpha-2)
+type phoneRegion struct {
+	code       string // country calling code
+	trunk      string // national prefix dropped in international format ("0" in "0912 ...")
+	minLen     int    // national significant number length
+	maxLen     int
+	intlPrefix string // dialled before a calling code, besides "00"
+}
+
+// phoneRegions covers the regions we operate in and common visitors; numbers with other
+// calling codes are accepted in international format with the generic E.164 checks only.
+var phoneRegions = map[string]phoneRegion{
+	"ET": {code: "251", trunk: "0", minLen: 9, maxLen: 9},
+	"KE": {code: "254", trunk: "0", minLen: 9, maxLen: 9},
+	"UG": {code: "256", trunk: "0", minLen: 9, maxLen: 9},
+	"TZ": {code: "255", trunk: "0", minLen: 9, maxLen: 9},
+	"RW": {code: "250", trunk: "0", minLen: 9, maxLen: 9},
+	"NG": {code: "234", trunk: "0", minLen: 8, maxLen: 10},
+	"GH": {code: "233", trunk: "0", minLen: 9, maxLen: 9},
+	"ZA": {code: "27", trunk: "0", minLen: 9, maxLen: 9},
+	"EG": {code: "20", trunk: "0", minLen: 9, maxLen: 10},
+	"MA": {code: "212", trunk: "0", minLen: 9, maxLen: 9},
+	"DZ": {code: "213", trunk: "0", minLen: 8, maxLen: 9},
+	"TN": {code: "216", minLen: 8, maxLen: 8},
+	"SN": {code: "221", minLen: 9, maxLen: 9},
+	"CI": {code: "225", minLen: 10, maxLen: 10},
+	"CM": {code: "237", minLen: 9, maxLen: 9},
+	"AE": {code: "971", trunk: "0", minLen: 8, maxLen: 9},
+	"SA": {code: "966", trunk: "0", minLen: 9, maxLen: 9},
+	"TR": {code: "90", trunk: "0", minLen: 10, maxLen: 10},
+	"IN": {code: "91", trunk: "0", minLen: 10, maxLen: 10},
+	"PK": {code: "92", trunk: "0", minLen: 9, maxLen: 10},
+	"BD": {code: "880", trunk: "0", minLen: 10, maxLen: 10},
+	"CN": {code: "86", trunk: "0", minLen: 10, maxLen: 11},
+	"JP": {code: "81", trunk: "0", minLen: 9, maxLen: 10},
+	"ID": {code: "62", trunk: "0", minLen: 9, maxLen: 12},
+	"PH": {code: "63", trunk: "0", minLen: 10, maxLen: 10},
+	"AU": {code: "61", trunk: "0", minLen: 9, maxLen: 9},
+	"NZ": {code: "64", trunk: "0", minLen: 8, maxLen: 10},
+	"GB": {code: "44", trunk: "0", minLen: 9, maxLen: 10},
+	"IE": {code: "353", trunk: "0", minLen: 7, maxLen: 9},
+	"FR": {code: "33", trunk: "0", minLen: 9, maxLen: 9},
+	"DE": {code: "49", trunk: "0", minLen: 6, maxLen: 13},
+	"NL": {code: "31", trunk: "0", minLen: 9, maxLen: 9},
+	"BE": {code: "32", trunk: "0", minLen: 8, maxLen: 9},
+	"CH": {code: "41", trunk: "0", minLen: 9, maxLen: 9},
+	"SE": {code: "46", trunk: "0", minLen: 7, maxLen: 9},
+	"ES": {code: "34", minLen: 9, maxLen: 9},
+	"PT": {code: "351", minLen: 9, maxLen: 9},
+	"IT": {code: "39", minLen: 6, maxLen: 11}, // the leading 0 of landlines is kept
+	"RU": {code: "7", trunk: "8", minLen: 10, maxLen: 10, intlPrefix: "810"},
+	"US": {code: "1", trunk: "1", minLen: 10, maxLen: 10, intlPrefix: "011"},
+	"CA": {code: "1", trunk: "1", minLen: 10, maxLen: 10, intlPrefix: "011"},
+	"MX": {code: "52", minLen: 10, maxLen: 10},
+	"BR": {code: "55", trunk: "0", minLen: 10, maxLen: 11},
+	"AR": {code: "54", trunk: "0", minLen: 10, maxLen: 10},
+}
+
+// regionsByCode maps a calling code to one of its regions (for length checks)
+var regionsByCode = func() map[string]phoneRegion {
+	m := map[string]phoneRegion{}
+	for _, r := range phoneRegions {
+		m[r.code] = r
+	}
+	return m
+}()
+
+var defaultPhoneRegion string
+
+// SetPhoneRegion sets the region national numbers are read in (PHONE_DEFAULT_REGION).
+// Empty means only international numbers are accepted.
+func SetPhoneRegion(region string) error {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region != "" {
+		if _, ok := phoneRegions[region]; !ok {
+			return fmt.Errorf("unsupported phone region %q", region)
+		}
+	}
+	defaultPhoneRegion = region
+	return nil
+}
+
+// NormalizePhone returns the E.164 form of a number ("+<calling code><national number>").
+// International numbers may start with "+" or "00"; other numbers are read as national
+// numbers of the default region, so with region ET "0912 345 678" becomes "+251912345678".
+func NormalizePhone(raw string) (string, error) {
+	return NormalizePhoneInRegion(raw, defaultPhoneRegion)
+}
+
+// NormalizePhoneInRegion is NormalizePhone with an explicit default region
+func NormalizePhoneInRegion(raw, region string) (string, error) {
+	phone := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '/', '\t', '\u00a0':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+	if phone == "" {
+		return "", ErrInvalidPhone
+	}
+
+	reg, hasRegion := phoneRegions[strings.ToUpper(region)]
+	var digits string
+	switch {
+	case strings.HasPrefix(phone, "+"):
+		digits = phone[1:]
+	case strings.HasPrefix(phone, "00"):
+		digits = phone[2:]
+	case hasRegion && reg.intlPrefix != "" && strings.HasPrefix(phone, reg.intlPrefix):
+		digits = phone[len(reg.intlPrefix):]
+	case hasRegion:
+		digits = reg.code + strings.TrimPrefix(phone, reg.trunk)
+	default:
+		return "", ErrInvalidPhone
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhone
+		}
+	}
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+
+	// Calling codes are prefix-free: at most one of the 1-3 digit prefixes is known
+	for n := 1; n <= 3; n++ {
+		known, ok := regionsByCode[digits[:n]]
+		if !ok {
+			continue
+		}
+		national := digits[n:]
+		// "+251 0912..." style: the trunk prefix doesn't belong in international format
+		if known.trunk == "0" && strings.HasPrefix(national, "0") {
+			national = national[1:]
+		}
+		if len(national) < known.minLen || len(national) > known.maxLen {
+			return "", ErrInvalidPhone
+		}
+		return "+" + known.code + national, nil
+	}
+	return "+" + digits, nil
+}
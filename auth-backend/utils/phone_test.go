@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhoneInRegion(t *testing.T) {
+	tests := []struct {
+		name, raw, region, want string
+	}{
+		// National numbers read in the region
+		{"ET national", "0912 345 678", "ET", "+251912345678"},
+		{"ET without trunk prefix", "912345678", "ET", "+251912345678"},
+		{"region is case-insensitive", "0912345678", "et", "+251912345678"},
+		{"GB national", "020 7946 0958", "GB", "+442079460958"},
+		{"US national", "(415) 555-2671", "US", "+14155552671"},
+		{"US with trunk prefix", "1-415-555-2671", "US", "+14155552671"},
+		{"RU trunk 8", "8 912 345 67 89", "RU", "+79123456789"},
+		{"IT keeps the leading 0", "06 1234 5678", "IT", "+390612345678"},
+		{"region international prefix", "011 44 20 7946 0958", "US", "+442079460958"},
+
+		// Already in E.164 or international format
+		{"E.164", "+251912345678", "ET", "+251912345678"},
+		{"E.164 without region", "+251912345678", "", "+251912345678"},
+		{"E.164 from another region", "+14155552671", "ET", "+14155552671"},
+		{"00 prefix", "00251912345678", "", "+251912345678"},
+		{"trunk prefix after calling code", "+251 0912 345 678", "", "+251912345678"},
+		{"unknown calling code", "+999 1234 5678", "", "+99912345678"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhoneInRegion(tt.raw, tt.region)
+			if err != nil || got != tt.want {
+				t.Errorf("NormalizePhoneInRegion(%q, %q) = %q, %v; want %q", tt.raw, tt.region, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePhoneInRegionInvalid(t *testing.T) {
+	tests := []struct {
+		name, raw, region string
+	}{
+		{"empty", "", "ET"},
+		{"blank", "  ", "ET"},
+		{"letters", "0912abc678", "ET"},
+		{"national without region", "0912345678", ""},
+		{"national with unknown region", "0912345678", "ZZ"},
+		{"too short for the region", "+251 91234567", ""},
+		{"too long for the region", "09123456789", "ET"},
+		{"too short for E.164", "+1234567", ""},
+		{"too long for E.164", "+1234567890123456", ""},
+		{"calling code starting with 0", "+0123456789", ""},
+		{"plus only", "+", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhoneInRegion(tt.raw, tt.region)
+			if !errors.Is(err, ErrInvalidPhone) {
+				t.Errorf("NormalizePhoneInRegion(%q, %q) = %q, %v; want ErrInvalidPhone", tt.raw, tt.region, got, err)
+			}
+		})
+	}
+}
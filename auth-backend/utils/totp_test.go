@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test key ("12345678901234567890"), base32 encoded
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPVectors(t *testing.T) {
+	// RFC 6238 SHA-1 vectors, last 6 of the 8 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		step, ok := ValidateTOTP(rfcTOTPSecret, tt.code, time.Unix(tt.unix, 0))
+		if !ok || step != tt.unix/totpPeriod {
+			t.Errorf("ValidateTOTP(%q) at %d = %d, %v; want %d, true", tt.code, tt.unix, step, ok, tt.unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	// "081804" is the code of step 37037036 (1111111080s - 1111111109s)
+	const step = 37037036
+	at := func(steps int64) int64 { return step*totpPeriod + 15 + steps*totpPeriod }
+	tests := []struct {
+		name     string
+		secret   string
+		unix     int64
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfcTOTPSecret, at(0), "081804", step, true},
+		{"one step early (clock behind)", rfcTOTPSecret, at(-1), "081804", step, true},
+		{"one step late (clock ahead)", rfcTOTPSecret, at(1), "081804", step, true},
+		{"two steps late", rfcTOTPSecret, at(2), "081804", 0, false},
+		{"two steps early", rfcTOTPSecret, at(-2), "081804", 0, false},
+		{"surrounding whitespace", rfcTOTPSecret, at(0), " 081804 ", step, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", at(0), "081804", step, true},
+		{"invalid secret", "not base32!", at(0), "081804", 0, false},
+		{"wrong code", rfcTOTPSecret, at(0), "081805", 0, false},
+		{"too short", rfcTOTPSecret, at(0), "81804", 0, false},
+		{"too long", rfcTOTPSecret, at(0), "07081804", 0, false},
+		{"empty", rfcTOTPSecret, at(0), "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.unix, 0))
+			if gotStep != tt.wantStep || ok != tt.wantOK {
+				t.Errorf("ValidateTOTP(%q) at %d = %d, %v; want %d, %v", tt.code, tt.unix, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("GenerateTOTPSecret() = %q: %d bytes, %v; want 20 bytes", secret, len(key), err)
+	}
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("a generated secret doesn't validate its own code")
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+	format := regexp.MustCompile(`^[a-hjkmnp-z2-9]{5}-[a-hjkmnp-z2-9]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q issued twice", code)
+		}
+		seen[code] = true
+		if NormalizeRecoveryCode(code) != code {
+			t.Errorf("NormalizeRecoveryCode changes the issued code %q", code)
+		}
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"abcde-fghjk", "abcde-fghjk"},
+		{"ABCDE-FGHJK", "abcde-fghjk"},
+		{"  abcde-fghjk\n", "abcde-fghjk"},
+		{"abcdefghjk", "abcde-fghjk"},
+		{"abcde fghjk", "abcde-fghjk"},
+		{"abc", "abc"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -4,19 +4,27 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port        string
-	DBHost      string
-	DBPort      string
-	DBUser      string
-	DBPassword  string
-	DBName      string
-	JWTSecret   string
-	PostgresURL string
+	Port           string
+	DBHost         string
+	DBPort         string
+	DBUser         string
+	DBPassword     string
+	DBName         string
+	JWTSecret      string
+	PostgresURL    string
+	DBAutoMigrate  bool          // apply pending migrations at startup
+	ShutdownDrain  time.Duration // readiness fails this long before the server stops on SIGTERM
+	AuthServiceURL string
+	InternalAPIKey string
+	// SchedulingServiceURL is where bookings look up the cinema location of a schedule
+	SchedulingServiceURL string
 }
 
 func LoadConfig() *Config {
@@ -28,13 +36,23 @@ func LoadConfig() *Config {
 	}
 
 	cfg := &Config{
-		Port:       port,
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
-		JWTSecret:  os.Getenv("JWT_SECRET"),
+		Port:           port,
+		DBHost:         os.Getenv("DB_HOST"),
+		DBPort:         os.Getenv("DB_PORT"),
+		DBUser:         os.Getenv("DB_USER"),
+		DBPassword:     os.Getenv("DB_PASSWORD"),
+		DBName:         os.Getenv("DB_NAME"),
+		DBAutoMigrate:  os.Getenv("DB_AUTO_MIGRATE") != "false",
+		ShutdownDrain:  5 * time.Second,
+		JWTSecret:      os.Getenv("JWT_SECRET"),
+		AuthServiceURL: os.Getenv("AUTH_SERVICE_URL"),
+		InternalAPIKey: os.Getenv("INTERNAL_API_KEY"),
+
+		SchedulingServiceURL: os.Getenv("SCHEDULING_SERVICE_URL"),
+	}
+
+	if v, err := strconv.Atoi(os.Getenv("SHUTDOWN_DRAIN_SECONDS")); err == nil && v >= 0 {
+		cfg.ShutdownDrain = time.Duration(v) * time.Second
 	}
 
 	cfg.PostgresURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
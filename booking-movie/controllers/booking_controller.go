@@ -3,8 +3,11 @@ package controllers
 import (
 	"booking-movie/models"
 	"booking-movie/utils"
+	"cinema-shared/auth"
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -40,12 +43,24 @@ func CreateBookingHandler(c *gin.Context) {
 		return
 	}
 
-	roleRaw, _ := c.Get("role")
-	role, _ := roleRaw.(string)
-	if req.UserID == 0 || role == "admin" || role == "staff" {
+	// Booking for someone else needs "bookings:manage" (box office)
+	claims := auth.ClaimsFrom(c)
+	if req.UserID == 0 || claims == nil || !claims.HasPermission("bookings:manage") {
 		req.UserID = jwtUserID
 	}
 
+	// Bookings remember the cinema location so box-office staff there can look them up
+	locationID, err := scheduleLocation(req.ScheduleID)
+	if errors.Is(err, errScheduleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ [CreateBooking] Location lookup for schedule %d failed: %v", req.ScheduleID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to look up the schedule"})
+		return
+	}
+
 	// ---------------- Start transaction ----------------
 	tx, err := models.DB.Begin(context.Background())
 	if err != nil {
@@ -63,6 +78,7 @@ func CreateBookingHandler(c *gin.Context) {
 	booking := &models.Booking{
 		UserID:      req.UserID,
 		ScheduleID:  req.ScheduleID,
+		LocationID:  locationID,
 		TotalAmount: req.TotalAmount,
 		Status:      "pending",
 	}
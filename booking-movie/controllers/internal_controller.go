@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"booking-movie/models"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------- Internal: Bookings of a User ----------------
+// Used by auth-backend for personal data exports
+func InternalUserBookings(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	bookings, err := models.ListUserBookings(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bookings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "bookings": bookings})
+}
+
+// ---------------- Internal: Reassign Bookings of a User ----------------
+// Used by auth-backend when an admin merges a duplicate account into another. booking_ids limits
+// the move to those bookings, so a failed merge can move exactly the returned ones back.
+func InternalReassignUserBookings(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req struct {
+		ToUserID   int   `json:"to_user_id" binding:"required"`
+		BookingIDs []int `json:"booking_ids"` // omitted: every booking of the user
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ToUserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_user_id must differ from the user ID"})
+		return
+	}
+
+	moved, err := models.ReassignUserBookings(userID, req.ToUserID, req.BookingIDs)
+	if err != nil {
+		log.Printf("❌ [InternalReassignUserBookings] %d -> %d: %v", userID, req.ToUserID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reassign bookings"})
+		return
+	}
+	log.Printf("✅ [InternalReassignUserBookings] %d bookings moved from user %d to %d", len(moved), userID, req.ToUserID)
+	c.JSON(http.StatusOK, gin.H{
+		"from_user_id": userID,
+		"to_user_id":   req.ToUserID,
+		"moved":        len(moved),
+		"booking_ids":  moved,
+	})
+}
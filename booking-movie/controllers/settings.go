@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"booking-movie/config"
+	"booking-movie/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// schedulingURL is the cinema-scheduling base URL (schedule -> cinema location lookups)
+var schedulingURL string
+
+// errScheduleNotFound is returned by scheduleLocation when scheduling doesn't know the schedule
+var errScheduleNotFound = errors.New("schedule not found")
+
+// Configure passes the loaded config to the handlers; call once from main
+func Configure(cfg *config.Config) {
+	schedulingURL = strings.TrimRight(cfg.SchedulingServiceURL, "/")
+	if schedulingURL == "" {
+		log.Println("⚠️ SCHEDULING_SERVICE_URL not set, bookings are stored without a cinema location")
+	}
+}
+
+// scheduleLocation asks cinema-scheduling which location a schedule plays at (nil when its hall has none)
+func scheduleLocation(scheduleID int) (*int, error) {
+	if schedulingURL == "" {
+		return nil, nil
+	}
+	var resp struct {
+		LocationID *int `json:"location_id"`
+	}
+	err := utils.GetJSON(fmt.Sprintf("%s/api/schedules/%d/location", schedulingURL, scheduleID), &resp)
+	if errors.Is(err, utils.ErrNotFound) {
+		return nil, errScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.LocationID, nil
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"booking-movie/models"
+	"cinema-shared/auth"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// Staff handlers run behind middleware.StaffMiddleware, so the caller's
+// claims always carry a department and a cinema location.
+
+// ---------------- Staff: List Bookings ----------------
+// Bookings at the caller's location; optional filters: user_id, schedule_id, status
+func StaffListBookings(c *gin.Context) {
+	claims := auth.ClaimsFrom(c)
+
+	var f models.LocationBookingFilter
+	var err error
+	if v := c.Query("user_id"); v != "" {
+		if f.UserID, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+	}
+	if v := c.Query("schedule_id"); v != "" {
+		if f.ScheduleID, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule_id"})
+			return
+		}
+	}
+	f.Status = c.Query("status")
+
+	bookings, err := models.ListBookingsAtLocation(claims.LocationID, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bookings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"location_id": claims.LocationID, "bookings": bookings})
+}
+
+// ---------------- Staff: Get Booking ----------------
+func StaffGetBooking(c *gin.Context) {
+	claims := auth.ClaimsFrom(c)
+
+	bookingID, err := strconv.Atoi(c.Param("booking_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking ID"})
+		return
+	}
+
+	booking, err := models.GetBookingByID(bookingID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch booking"})
+		return
+	}
+	if booking.LocationID == nil || *booking.LocationID != claims.LocationID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "booking belongs to another cinema location"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"booking": booking})
+}
@@ -1,79 +1,85 @@
 package middleware
 
 import (
+	"booking-movie/config"
+	"cinema-shared/auth"
+	"crypto/subtle"
+	"errors"
+	"log"
 	"net/http"
-	"os"
-	"strings"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-// BookingAuthMiddleware allows only customer, admin, or staff roles
-func BookingAuthMiddleware() gin.HandlerFunc {
-	allowedRoles := map[string]bool{
-		"customer": true,
-		"admin":    true,
-		"staff":    true,
-	}
+// verifier checks auth-backend tokens with the shared rules (JWKS keys + revocation list)
+var verifier *auth.Verifier
 
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing Authorization header"})
-			c.Abort()
-			return
-		}
+// internalKey authenticates service-to-service calls (X-Internal-Key)
+var internalKey string
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing Bearer token"})
-			c.Abort()
-			return
-		}
+// Init starts syncing signing keys and revocations from auth-backend; call once from main.
+// Without INTERNAL_API_KEY revoked tokens would pass, so that is an error.
+func Init(cfg *config.Config) error {
+	if cfg.AuthServiceURL == "" {
+		log.Println("⚠️ AUTH_SERVICE_URL not set, no token can be verified")
+	}
 
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "JWT_SECRET not configured"})
-			c.Abort()
-			return
-		}
+	jwks := auth.NewJWKSClient(cfg.AuthServiceURL + "/.well-known/jwks.json")
+	jwks.Start(5 * time.Minute)
+
+	v := &auth.Verifier{
+		Keys:     jwks,
+		Issuer:   auth.DefaultIssuer,
+		Audience: auth.DefaultAudience,
+		Leeway:   30 * time.Second,
+	}
+	if cfg.InternalAPIKey == "" {
+		return errors.New("INTERNAL_API_KEY is required to fetch the token revocation list")
+	}
+	revocations := auth.NewRevocationClient(cfg.AuthServiceURL, cfg.InternalAPIKey)
+	revocations.Start(30 * time.Second)
+	v.Revocations = revocations
+	verifier = v
+	internalKey = cfg.InternalAPIKey
+	return nil
+}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
+// BookingAuthMiddleware allows tokens carrying the "bookings:create" permission
+func BookingAuthMiddleware() gin.HandlerFunc {
+	requirePerm := auth.RequirePermission("bookings:create")
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+	return func(c *gin.Context) {
+		if auth.Authenticate(c, verifier) == nil {
 			return
 		}
+		requirePerm(c)
+	}
+}
+
+// StaffMiddleware allows staff of the given departments assigned to a location
+func StaffMiddleware(depts ...string) gin.HandlerFunc {
+	requireDept := auth.RequireDepartment(depts...)
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+	return func(c *gin.Context) {
+		if auth.Authenticate(c, verifier) == nil {
 			return
 		}
+		requireDept(c)
+	}
+}
 
-		userID, ok := claims["user_id"].(float64) // JWT stores numbers as float64
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user_id in token"})
-			c.Abort()
+// InternalMiddleware allows other services presenting INTERNAL_API_KEY in X-Internal-Key
+func InternalMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if internalKey == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "INTERNAL_API_KEY not configured"})
 			return
 		}
-
-		role, ok := claims["role"].(string)
-		if !ok || !allowedRoles[role] {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this resource"})
-			c.Abort()
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Internal-Key")), []byte(internalKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid internal key"})
 			return
 		}
-
-		// Save user info in context
-		c.Set("user_id", int(userID))
-		c.Set("role", role)
-
 		c.Next()
 	}
 }
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS booking_snacks;
+DROP TABLE IF EXISTS booking_seats;
+DROP TABLE IF EXISTS bookings;
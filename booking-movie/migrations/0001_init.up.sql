@@ -0,0 +1,42 @@
+-- Baseline: the cinema_booking schema as the original db/init.sql created it. Databases that
+-- ran that script already have all of it, so this version is a no-op there; the changes made
+-- since are the following versions.
+
+-- ==============================
+-- Table: bookings
+-- ==============================
+CREATE TABLE IF NOT EXISTS bookings (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,               -- from cinema_auth.users
+    schedule_id INT NOT NULL,           -- from cinema_scheduling.schedules
+    total_amount NUMERIC(10,2) NOT NULL,
+    status VARCHAR(50) DEFAULT 'PENDING', -- "PENDING", "CONFIRMED", "CANCELLED", "PAID"
+    payment_reference TEXT,              -- transaction id from Chapa or other gateway
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ==============================
+-- Table: booking_seats
+-- ==============================
+CREATE TABLE IF NOT EXISTS booking_seats (
+    id SERIAL PRIMARY KEY,
+    booking_id INT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    seat_number VARCHAR(10) NOT NULL,
+    is_available BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ==============================
+-- Table: booking_snacks
+-- ==============================
+CREATE TABLE IF NOT EXISTS booking_snacks (
+    id SERIAL PRIMARY KEY,
+    booking_id INT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    schedule_snack_id INT NOT NULL,   -- ✅ from cinema_scheduling.schedule_snacks
+    quantity INT NOT NULL DEFAULT 1,
+    price NUMERIC(10,2) NOT NULL,     -- copied from schedule_snacks/snacks at booking time
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Cinema location of a booking (cinema_scheduling.cinema_locations, via the schedule's hall),
+-- stored at booking time so staff can be scoped to their location
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS location_id INT;
@@ -15,6 +15,7 @@ type Booking struct {
 	ID               int            `json:"id"`
 	UserID           int            `json:"user_id"`
 	ScheduleID       int            `json:"schedule_id"`
+	LocationID       *int           `json:"location_id,omitempty"` // cinema location of the schedule
 	TotalAmount      float64        `json:"total_amount"`
 	Status           string         `json:"status"`
 	PaymentReference *string        `json:"payment_reference,omitempty"`
@@ -43,9 +44,9 @@ type BookingSnack struct {
 // ---------------- Create Booking ----------------
 func CreateBookingTx(tx pgx.Tx, b *Booking) error {
 	return tx.QueryRow(context.Background(),
-		`INSERT INTO bookings (user_id, schedule_id, total_amount, status, created_at, updated_at)
-		 VALUES ($1,$2,$3,$4,NOW(),NOW()) RETURNING id, created_at, updated_at`,
-		b.UserID, b.ScheduleID, b.TotalAmount, b.Status,
+		`INSERT INTO bookings (user_id, schedule_id, location_id, total_amount, status, created_at, updated_at)
+		 VALUES ($1,$2,$3,$4,$5,NOW(),NOW()) RETURNING id, created_at, updated_at`,
+		b.UserID, b.ScheduleID, b.LocationID, b.TotalAmount, b.Status,
 	).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
 }
 
@@ -72,9 +73,9 @@ func GetBookingByID(id int) (*Booking, error) {
 	ctx := context.Background()
 	b := &Booking{}
 	err := DB.QueryRow(ctx,
-		`SELECT id, user_id, schedule_id, total_amount, status, payment_reference, created_at, updated_at
+		`SELECT id, user_id, schedule_id, location_id, total_amount, status, payment_reference, created_at, updated_at
 		 FROM bookings WHERE id=$1`, id,
-	).Scan(&b.ID, &b.UserID, &b.ScheduleID, &b.TotalAmount, &b.Status, &b.PaymentReference, &b.CreatedAt, &b.UpdatedAt)
+	).Scan(&b.ID, &b.UserID, &b.ScheduleID, &b.LocationID, &b.TotalAmount, &b.Status, &b.PaymentReference, &b.CreatedAt, &b.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +109,7 @@ func GetBookingByID(id int) (*Booking, error) {
 func GetAllBookings() ([]Booking, error) {
 	ctx := context.Background()
 	rows, err := DB.Query(ctx,
-		`SELECT id, user_id, schedule_id, total_amount, status, payment_reference, created_at, updated_at
+		`SELECT id, user_id, schedule_id, location_id, total_amount, status, payment_reference, created_at, updated_at
 		 FROM bookings ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
@@ -118,7 +119,7 @@ func GetAllBookings() ([]Booking, error) {
 	var bookings []Booking
 	for rows.Next() {
 		var b Booking
-		if err := rows.Scan(&b.ID, &b.UserID, &b.ScheduleID, &b.TotalAmount, &b.Status, &b.PaymentReference, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		if err := rows.Scan(&b.ID, &b.UserID, &b.ScheduleID, &b.LocationID, &b.TotalAmount, &b.Status, &b.PaymentReference, &b.CreatedAt, &b.UpdatedAt); err != nil {
 			return nil, err
 		}
 		// Load seats
@@ -144,6 +145,119 @@ func GetAllBookings() ([]Booking, error) {
 	return bookings, nil
 }
 
+// ---------------- Bookings of a User ----------------
+// ListUserBookings returns all bookings of a user with their seats and snacks, newest first
+func ListUserBookings(userID int) ([]Booking, error) {
+	ctx := context.Background()
+	rows, err := DB.Query(ctx,
+		`SELECT id, user_id, schedule_id, location_id, total_amount, status, payment_reference, created_at, updated_at
+		 FROM bookings WHERE user_id=$1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	bookings := []Booking{}
+	for rows.Next() {
+		var b Booking
+		if err := rows.Scan(&b.ID, &b.UserID, &b.ScheduleID, &b.LocationID, &b.TotalAmount, &b.Status, &b.PaymentReference, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range bookings {
+		b := &bookings[i]
+		seatRows, err := DB.Query(ctx, `SELECT id, booking_id, seat_number, created_at FROM booking_seats WHERE booking_id=$1`, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		for seatRows.Next() {
+			var s BookingSeat
+			if err := seatRows.Scan(&s.ID, &s.BookingID, &s.SeatNumber, &s.CreatedAt); err == nil {
+				b.Seats = append(b.Seats, s)
+			}
+		}
+		seatRows.Close()
+
+		snackRows, err := DB.Query(ctx, `SELECT id, booking_id, schedule_snack_id, quantity, price, created_at FROM booking_snacks WHERE booking_id=$1`, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		for snackRows.Next() {
+			var s BookingSnack
+			if err := snackRows.Scan(&s.ID, &s.BookingID, &s.ScheduleSnackID, &s.Quantity, &s.Price, &s.CreatedAt); err == nil {
+				b.Snacks = append(b.Snacks, s)
+			}
+		}
+		snackRows.Close()
+	}
+	return bookings, nil
+}
+
+// ---------------- Bookings at a Location ----------------
+
+// LocationBookingFilter narrows ListBookingsAtLocation; zero values mean "any"
+type LocationBookingFilter struct {
+	UserID     int
+	ScheduleID int
+	Status     string
+}
+
+// ListBookingsAtLocation returns the latest bookings (max 200) made at one cinema location, without seats or snacks
+func ListBookingsAtLocation(locationID int, f LocationBookingFilter) ([]Booking, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, user_id, schedule_id, location_id, total_amount, status, payment_reference, created_at, updated_at
+		 FROM bookings
+		 WHERE location_id=$1
+		   AND ($2 = 0 OR user_id = $2)
+		   AND ($3 = 0 OR schedule_id = $3)
+		   AND ($4 = '' OR status = $4)
+		 ORDER BY created_at DESC
+		 LIMIT 200`, locationID, f.UserID, f.ScheduleID, f.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookings := []Booking{}
+	for rows.Next() {
+		var b Booking
+		if err := rows.Scan(&b.ID, &b.UserID, &b.ScheduleID, &b.LocationID, &b.TotalAmount, &b.Status, &b.PaymentReference, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+	return bookings, rows.Err()
+}
+
+// ---------------- Reassign Bookings ----------------
+// ReassignUserBookings moves the bookings of one user to another (merged accounts) and returns
+// their IDs. With ids, only those bookings move (undoing an earlier move); nil moves them all.
+func ReassignUserBookings(fromUserID, toUserID int, ids []int) ([]int, error) {
+	rows, err := DB.Query(context.Background(),
+		`UPDATE bookings SET user_id=$2, updated_at=NOW()
+		 WHERE user_id=$1 AND ($3::int[] IS NULL OR id = ANY($3))
+		 RETURNING id`, fromUserID, toUserID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	moved := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		moved = append(moved, id)
+	}
+	return moved, rows.Err()
+}
+
 // ---------------- Update Booking ----------------
 func UpdateBooking(b *Booking) error {
 	_, err := DB.Exec(context.Background(),
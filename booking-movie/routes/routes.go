@@ -17,4 +17,22 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config) {
 		api.POST("/bookings", controllers.CreateBookingHandler)
 
 	}
+
+	// Box office: bookings at the staffer's own cinema location
+	staff := r.Group("/api/v1/staff")
+	{
+		staff.Use(middleware.StaffMiddleware("box_office"))
+
+		staff.GET("/bookings", controllers.StaffListBookings)
+		staff.GET("/bookings/:booking_id", controllers.StaffGetBooking)
+	}
+
+	// Service-to-service (auth-backend data exports and account merges)
+	internal := r.Group("/api/internal")
+	{
+		internal.Use(middleware.InternalMiddleware())
+
+		internal.GET("/users/:user_id/bookings", controllers.InternalUserBookings)
+		internal.POST("/users/:user_id/bookings/reassign", controllers.InternalReassignUserBookings)
+	}
 }
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,12 +11,18 @@ import (
 
 var httpClient = &http.Client{Timeout: 8 * time.Second}
 
+// ErrNotFound is returned by GetJSON when the remote answers 404
+var ErrNotFound = errors.New("not found")
+
 func GetJSON(url string, target interface{}) error {
 	resp, err := httpClient.Get(url)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,6 +20,10 @@ type Config struct {
 	JWTSecret      string
 	JWTExpiryHours int
 	PostgresURL    string
+	DBAutoMigrate  bool          // apply pending migrations at startup
+	ShutdownDrain  time.Duration // readiness fails this long before the server stops on SIGTERM
+	AuthServiceURL string
+	InternalAPIKey string
 }
 
 func LoadConfig() *Config {
@@ -38,8 +44,16 @@ func LoadConfig() *Config {
 		DBUser:         os.Getenv("DB_USER"),
 		DBPassword:     os.Getenv("DB_PASSWORD"),
 		DBName:         os.Getenv("DB_NAME"),
+		DBAutoMigrate:  os.Getenv("DB_AUTO_MIGRATE") != "false",
+		ShutdownDrain:  5 * time.Second,
 		JWTSecret:      os.Getenv("JWT_SECRET"),
 		JWTExpiryHours: 72,
+		AuthServiceURL: os.Getenv("AUTH_SERVICE_URL"),
+		InternalAPIKey: os.Getenv("INTERNAL_API_KEY"),
+	}
+
+	if v, err := strconv.Atoi(os.Getenv("SHUTDOWN_DRAIN_SECONDS")); err == nil && v >= 0 {
+		cfg.ShutdownDrain = time.Duration(v) * time.Second
 	}
 
 	// Build Postgres URL once and store it
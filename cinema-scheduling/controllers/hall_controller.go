@@ -94,9 +94,10 @@ func UpdateHall(c *gin.Context) {
 	}
 
 	var req struct {
-		Name     *string `json:"name"`
-		Capacity *int    `json:"capacity"`
-		Location *string `json:"location"`
+		Name       *string `json:"name"`
+		Capacity   *int    `json:"capacity"`
+		Location   *string `json:"location"`
+		LocationID *int    `json:"location_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -112,6 +113,9 @@ func UpdateHall(c *gin.Context) {
 	if req.Location != nil {
 		existingHall.Location = req.Location
 	}
+	if req.LocationID != nil {
+		existingHall.LocationID = req.LocationID
+	}
 
 	if err := models.UpdateHall(existingHall); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update hall"})
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"cinema-scheduling/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------- Add Location ----------------
+func AddLocation(c *gin.Context) {
+	var location models.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if location.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Location name is required"})
+		return
+	}
+
+	if err := models.CreateLocation(&location); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create location"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Location created", "location": location})
+}
+
+// ---------------- List Locations ----------------
+func ListLocations(c *gin.Context) {
+	locations, err := models.GetAllLocations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch locations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+// ---------------- Get Location by ID ----------------
+func GetLocation(c *gin.Context) {
+	locationID, err := strconv.Atoi(c.Param("location_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	location, err := models.GetLocationByID(locationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch location"})
+		return
+	}
+	if location == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"location": location})
+}
+
+// ---------------- Update Location ----------------
+func UpdateLocation(c *gin.Context) {
+	locationID, err := strconv.Atoi(c.Param("location_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	location, err := models.GetLocationByID(locationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch location"})
+		return
+	}
+	if location == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+
+	var req struct {
+		Name    *string `json:"name"`
+		Address *string `json:"address"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name != nil && *req.Name != "" {
+		location.Name = *req.Name
+	}
+	if req.Address != nil {
+		location.Address = req.Address
+	}
+
+	if err := models.UpdateLocation(location); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update location"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Location updated", "location": location})
+}
+
+// ---------------- Delete Location ----------------
+func DeleteLocation(c *gin.Context) {
+	locationID, err := strconv.Atoi(c.Param("location_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	if err := models.DeleteLocation(locationID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete location"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Location deleted"})
+}
+
+// ---------------- Get Schedule Location ----------------
+// Public: which cinema a schedule plays at (used by booking-movie to scope bookings)
+func GetScheduleLocation(c *gin.Context) {
+	scheduleID, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	locationID, found, err := models.GetScheduleLocationID(scheduleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule location"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedule_id": scheduleID, "location_id": locationID})
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"cinema-scheduling/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------- Staff scope ----------------
+// Staff routes only touch schedules playing at the staffer's location ("location_id" from the token).
+
+// staffSchedule parses :schedule_id and checks it plays at the caller's location; writes the error itself
+func staffSchedule(c *gin.Context) (int, bool) {
+	scheduleID, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return 0, false
+	}
+
+	locationID, found, err := models.GetScheduleLocationID(scheduleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
+		return 0, false
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		return 0, false
+	}
+	if locationID == nil || *locationID != c.GetInt("location_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This schedule is not at your location"})
+		return 0, false
+	}
+	return scheduleID, true
+}
+
+// staffScheduleSnack loads :snack_id of a schedule at the caller's location
+func staffScheduleSnack(c *gin.Context) (*models.ScheduleSnack, bool) {
+	scheduleID, ok := staffSchedule(c)
+	if !ok {
+		return nil, false
+	}
+	snackID, err := strconv.Atoi(c.Param("snack_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snack ID"})
+		return nil, false
+	}
+
+	ss, err := models.GetScheduleSnackByScheduleAndSnack(scheduleID, snackID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule snack"})
+		return nil, false
+	}
+	if ss == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule snack not found for this schedule"})
+		return nil, false
+	}
+	return ss, true
+}
+
+// ---------------- Staff: List Schedule Snacks ----------------
+func StaffListScheduleSnacks(c *gin.Context) {
+	scheduleID, ok := staffSchedule(c)
+	if !ok {
+		return
+	}
+
+	snacks, err := models.GetScheduleSnacks(scheduleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule snacks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedule_snacks": snacks})
+}
+
+// ---------------- Staff: Add Schedule Snack ----------------
+func StaffAddScheduleSnack(c *gin.Context) {
+	scheduleID, ok := staffSchedule(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		SnackID   int   `json:"snack_id" binding:"required"`
+		Available *bool `json:"available"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ss := &models.ScheduleSnack{ScheduleID: scheduleID, SnackID: req.SnackID, Available: true}
+	if req.Available != nil {
+		ss.Available = *req.Available
+	}
+	if err := models.AddScheduleSnack(ss); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add snack to schedule"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Snack added to schedule", "schedule_snack": ss})
+}
+
+// ---------------- Staff: Update Schedule Snack ----------------
+func StaffUpdateScheduleSnack(c *gin.Context) {
+	ss, ok := staffScheduleSnack(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Available *bool `json:"available" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ss.Available = *req.Available
+	if err := models.UpdateScheduleSnack(ss); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule snack"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule snack updated", "schedule_snack": ss})
+}
+
+// ---------------- Staff: Delete Schedule Snack ----------------
+func StaffDeleteScheduleSnack(c *gin.Context) {
+	ss, ok := staffScheduleSnack(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteScheduleSnack(ss.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule snack"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule snack deleted"})
+}
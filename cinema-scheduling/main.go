@@ -2,10 +2,16 @@ package main
 
 import (
 	"cinema-scheduling/config"
+	"cinema-scheduling/middleware"
+	"cinema-scheduling/migrations"
 	"cinema-scheduling/models"
 	"cinema-scheduling/routes"
+	"cinema-shared/health"
+	"cinema-shared/migrate"
 	"context"
 	"log"
+	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -23,13 +29,40 @@ func main() {
 	}
 	log.Println("✅ Connected to Postgres (Cinema Scheduling)")
 
+	// ---------------- Schema migrations ----------------
+	schema, err := migrate.Load(migrations.FS)
+	if err != nil {
+		log.Fatalf("❌ Invalid migrations: %v", err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate.Command(context.Background(), models.DB, schema, os.Args[2:]); err != nil {
+			log.Fatalf("❌ migrate: %v", err)
+		}
+		return
+	}
+	if err := migrate.Startup(context.Background(), models.DB, schema, cfg.DBAutoMigrate); err != nil {
+		log.Fatalf("❌ Database schema not ready: %v", err)
+	}
+
+	// ---------------- Token verification (JWKS + revocations from auth) ----------------
+	if err := middleware.Init(cfg); err != nil {
+		log.Fatalf("❌ Token verification not configured: %v", err)
+	}
+
 	// ---------------- Setup HTTP routes ----------------
 	router := gin.Default()
 	routes.SetupRoutes(router, cfg)
 
+	// ---------------- Health probes ----------------
+	probes := health.New()
+	probes.Add("postgres", health.Postgres(models.DB))
+	router.GET("/healthz", probes.Live)
+	router.GET("/readyz", probes.Ready)
+
 	// ---------------- Run Server ----------------
 	log.Printf("🎬 Cinema Scheduling service running on port %s", cfg.Port)
-	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: router}
+	if err := health.ListenAndServe(srv, probes, cfg.ShutdownDrain); err != nil {
+		log.Fatalf("❌ Server error: %v", err)
 	}
 }
@@ -1,80 +1,71 @@
 package middleware
 
 import (
-	"net/http"
-	"os"
-	"strings"
+	"cinema-scheduling/config"
+	"cinema-shared/auth"
+	"errors"
+	"log"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-// JWTAuthMiddleware returns a middleware that allows only the specified roles
-func JWTAuthMiddleware(allowedRoles ...string) gin.HandlerFunc {
-	roleMap := make(map[string]bool)
-	for _, r := range allowedRoles {
-		roleMap[r] = true
+// verifier checks auth-backend tokens with the shared rules (JWKS keys + revocation list)
+var verifier *auth.Verifier
+
+// Init starts syncing signing keys and revocations from auth-backend; call once from main.
+// Without INTERNAL_API_KEY revoked tokens would pass, so that is an error.
+func Init(cfg *config.Config) error {
+	if cfg.AuthServiceURL == "" {
+		log.Println("⚠️ AUTH_SERVICE_URL not set, no token can be verified")
 	}
 
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing Authorization header"})
-			c.Abort()
-			return
-		}
+	jwks := auth.NewJWKSClient(cfg.AuthServiceURL + "/.well-known/jwks.json")
+	jwks.Start(5 * time.Minute)
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing Bearer token"})
-			c.Abort()
-			return
-		}
-
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "JWT_SECRET not configured"})
-			c.Abort()
-			return
-		}
+	v := &auth.Verifier{
+		Keys:     jwks,
+		Issuer:   auth.DefaultIssuer,
+		Audience: auth.DefaultAudience,
+		Leeway:   30 * time.Second,
+	}
+	if cfg.InternalAPIKey == "" {
+		return errors.New("INTERNAL_API_KEY is required to fetch the token revocation list")
+	}
+	revocations := auth.NewRevocationClient(cfg.AuthServiceURL, cfg.InternalAPIKey)
+	revocations.Start(30 * time.Second)
+	v.Revocations = revocations
+	verifier = v
+	return nil
+}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
+// JWTAuthMiddleware authenticates the request; with roles given, only those roles pass
+func JWTAuthMiddleware(allowedRoles ...string) gin.HandlerFunc {
+	requireRole := auth.RequireRole(allowedRoles...)
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+	return func(c *gin.Context) {
+		if auth.Authenticate(c, verifier) == nil {
 			return
 		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
+		if len(allowedRoles) > 0 {
+			requireRole(c)
 		}
+	}
+}
 
-		// Extract user_id safely
-		userID, ok := claims["user_id"].(float64) // JWT encodes numbers as float64
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user_id in token"})
-			c.Abort()
-			return
-		}
+// RequirePermission allows tokens carrying every listed permission (e.g. "movies:write"); run after JWTAuthMiddleware
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return auth.RequirePermission(perms...)
+}
+
+// StaffMiddleware allows staff of the given departments assigned to a location
+func StaffMiddleware(depts ...string) gin.HandlerFunc {
+	requireDept := auth.RequireDepartment(depts...)
 
-		// Extract role safely
-		role, ok := claims["role"].(string)
-		if !ok || !roleMap[role] {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this resource"})
-			c.Abort()
+	return func(c *gin.Context) {
+		if auth.Authenticate(c, verifier) == nil {
 			return
 		}
-
-		// Save to context for downstream handlers
-		c.Set("user_id", int(userID))
-		c.Set("role", role)
-
-		c.Next()
+		requireDept(c)
 	}
 }
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS schedule_snacks;
+DROP TABLE IF EXISTS snacks;
+DROP TABLE IF EXISTS schedules;
+DROP TABLE IF EXISTS movies;
+DROP TABLE IF EXISTS halls;
+DROP TABLE IF EXISTS genres;
@@ -0,0 +1,85 @@
+-- Baseline: the cinema_scheduling schema as the original db/init.sql created it. Databases that
+-- ran that script already have all of it, so this version is a no-op there; the changes made
+-- since are the following versions.
+
+-- ==============================
+-- Table: genres
+-- ==============================
+CREATE TABLE IF NOT EXISTS genres (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ==============================
+-- Table: halls
+-- ==============================
+CREATE TABLE IF NOT EXISTS halls (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    capacity INT NOT NULL,
+    location VARCHAR(255),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ==============================
+-- Table: movies
+-- ==============================
+CREATE TABLE IF NOT EXISTS movies (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    trailer_url TEXT ,
+    genres TEXT[], -- Array of genre names
+    duration INT NOT NULL,
+    release_year INT NOT NULL,
+    rating NUMERIC(3,1),
+    image_poster_url TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+
+
+-- ==============================
+-- Table: schedules
+-- ==============================
+CREATE TABLE IF NOT EXISTS schedules (
+    id SERIAL PRIMARY KEY,
+    movie_id INT NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    hall_id INT NOT NULL REFERENCES halls(id) ON DELETE CASCADE,
+    show_time TIMESTAMP NOT NULL,
+    available_seats INT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ==============================
+-- Table: snacks
+-- ==============================
+CREATE TABLE IF NOT EXISTS snacks (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    price NUMERIC(10,2) NOT NULL,
+    description TEXT,
+    category VARCHAR(255),
+    snack_image_url TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ==============================
+-- Table: schedule_snacks (snacks assigned to a schedule)
+-- ==============================
+CREATE TABLE IF NOT EXISTS schedule_snacks (
+    id SERIAL PRIMARY KEY,
+    schedule_id INT NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    snack_id INT NOT NULL REFERENCES snacks(id) ON DELETE CASCADE,
+    available BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (schedule_id, snack_id)
+);
+
@@ -0,0 +1,2 @@
+-- Ticket price of a showing; the code has written it since schedules got a price field
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS price NUMERIC(10,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE halls DROP COLUMN IF EXISTS location_id;
+DROP TABLE IF EXISTS cinema_locations;
@@ -0,0 +1,11 @@
+-- Cinema locations; staff are assigned to one and halls belong to one
+CREATE TABLE IF NOT EXISTS cinema_locations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    address TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- halls.location stays as free text, superseded by location_id
+ALTER TABLE halls ADD COLUMN IF NOT EXISTS location_id INT REFERENCES cinema_locations(id) ON DELETE SET NULL;
@@ -0,0 +1,8 @@
+// Package migrations embeds the service's versioned schema migrations (see cinema-shared/migrate).
+// Never edit a migration once it has been applied anywhere: add the next version instead.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -7,21 +7,22 @@ import (
 )
 
 type Hall struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Capacity  int       `json:"capacity"`
-	Location  *string   `json:"location"` // nullable
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Capacity   int       `json:"capacity"`
+	Location   *string   `json:"location"`    // nullable, free text
+	LocationID *int      `json:"location_id"` // nullable, cinema_locations.id
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // ---------------- Create Hall ----------------
 func CreateHall(h *Hall) error {
 	err := DB.QueryRow(context.Background(),
-		`INSERT INTO halls (name, capacity, location, created_at, updated_at) 
-		 VALUES ($1,$2,$3,NOW(),NOW())
+		`INSERT INTO halls (name, capacity, location, location_id, created_at, updated_at) 
+		 VALUES ($1,$2,$3,$4,NOW(),NOW())
          RETURNING id, created_at, updated_at`,
-		h.Name, h.Capacity, h.Location).
+		h.Name, h.Capacity, h.Location, h.LocationID).
 		Scan(&h.ID, &h.CreatedAt, &h.UpdatedAt)
 
 	if err != nil {
@@ -34,7 +35,7 @@ func CreateHall(h *Hall) error {
 // ---------------- Get All Halls ----------------
 func GetAllHalls() ([]*Hall, error) {
 	rows, err := DB.Query(context.Background(),
-		`SELECT id, name, capacity, location, created_at, updated_at 
+		`SELECT id, name, capacity, location, location_id, created_at, updated_at 
          FROM halls ORDER BY id ASC`)
 	if err != nil {
 		return nil, err
@@ -44,7 +45,7 @@ func GetAllHalls() ([]*Hall, error) {
 	var halls []*Hall
 	for rows.Next() {
 		h := &Hall{}
-		if err := rows.Scan(&h.ID, &h.Name, &h.Capacity, &h.Location, &h.CreatedAt, &h.UpdatedAt); err != nil {
+		if err := rows.Scan(&h.ID, &h.Name, &h.Capacity, &h.Location, &h.LocationID, &h.CreatedAt, &h.UpdatedAt); err != nil {
 			log.Printf("❌ Scan hall error: %v", err)
 			return nil, err
 		}
@@ -57,9 +58,9 @@ func GetAllHalls() ([]*Hall, error) {
 func GetHallByID(id int) (*Hall, error) {
 	h := &Hall{}
 	err := DB.QueryRow(context.Background(),
-		`SELECT id, name, capacity, location, created_at, updated_at 
+		`SELECT id, name, capacity, location, location_id, created_at, updated_at 
          FROM halls WHERE id = $1`, id).
-		Scan(&h.ID, &h.Name, &h.Capacity, &h.Location, &h.CreatedAt, &h.UpdatedAt)
+		Scan(&h.ID, &h.Name, &h.Capacity, &h.Location, &h.LocationID, &h.CreatedAt, &h.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -71,9 +72,9 @@ func GetHallByID(id int) (*Hall, error) {
 func UpdateHall(h *Hall) error {
 	_, err := DB.Exec(context.Background(),
 		`UPDATE halls 
-         SET name = $1, capacity = $2, updated_at = NOW() 
-         WHERE id = $3`,
-		h.Name, h.Capacity, &h.Location, h.ID)
+         SET name = $1, capacity = $2, location = $3, location_id = $4, updated_at = NOW() 
+         WHERE id = $5`,
+		h.Name, h.Capacity, h.Location, h.LocationID, h.ID)
 
 	if err != nil {
 		log.Printf("❌ UpdateHall error: %v", err)
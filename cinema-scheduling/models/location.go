@@ -0,0 +1,107 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Location is a cinema site; halls and staff belong to one
+type Location struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Address   *string   `json:"address"` // nullable
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ---------------- Create Location ----------------
+func CreateLocation(l *Location) error {
+	err := DB.QueryRow(context.Background(),
+		`INSERT INTO cinema_locations (name, address, created_at, updated_at)
+		 VALUES ($1,$2,NOW(),NOW()) RETURNING id, created_at, updated_at`,
+		l.Name, l.Address,
+	).Scan(&l.ID, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		log.Printf("❌ CreateLocation error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ---------------- Get All Locations ----------------
+func GetAllLocations() ([]*Location, error) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, name, address, created_at, updated_at FROM cinema_locations ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []*Location{}
+	for rows.Next() {
+		l := &Location{}
+		if err := rows.Scan(&l.ID, &l.Name, &l.Address, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			log.Printf("❌ Scan location error: %v", err)
+			return nil, err
+		}
+		locations = append(locations, l)
+	}
+	return locations, nil
+}
+
+// ---------------- Get Location By ID ----------------
+// Returns nil if it doesn't exist
+func GetLocationByID(id int) (*Location, error) {
+	l := &Location{}
+	err := DB.QueryRow(context.Background(),
+		`SELECT id, name, address, created_at, updated_at FROM cinema_locations WHERE id=$1`, id,
+	).Scan(&l.ID, &l.Name, &l.Address, &l.CreatedAt, &l.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ---------------- Update Location ----------------
+func UpdateLocation(l *Location) error {
+	_, err := DB.Exec(context.Background(),
+		`UPDATE cinema_locations SET name=$1, address=$2, updated_at=NOW() WHERE id=$3`,
+		l.Name, l.Address, l.ID)
+	if err != nil {
+		log.Printf("❌ UpdateLocation error: %v", err)
+	}
+	return err
+}
+
+// ---------------- Delete Location ----------------
+// Halls of the location are kept with location_id = NULL
+func DeleteLocation(id int) error {
+	_, err := DB.Exec(context.Background(), `DELETE FROM cinema_locations WHERE id=$1`, id)
+	if err != nil {
+		log.Printf("❌ DeleteLocation error: %v", err)
+	}
+	return err
+}
+
+// ---------------- Get Schedule Location ----------------
+// Location of the hall a schedule plays in; found is false if the schedule doesn't exist,
+// locationID is nil if the hall has no location
+func GetScheduleLocationID(scheduleID int) (locationID *int, found bool, err error) {
+	err = DB.QueryRow(context.Background(),
+		`SELECT h.location_id FROM schedules s JOIN halls h ON s.hall_id = h.id WHERE s.id=$1`,
+		scheduleID).Scan(&locationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return locationID, true, nil
+}
@@ -77,7 +77,7 @@ func GetScheduleByID(id int) (*Schedule, error) {
 // ---------------- Update Schedule ----------------
 func UpdateSchedule(s *Schedule) error {
 	_, err := DB.Exec(context.Background(),
-		`UPDATE schedules SET movie_id=$1, hall_id=$2, show_time=$3, available_seats=$4, price=$5 updated_at=NOW() WHERE id=$5`,
+		`UPDATE schedules SET movie_id=$1, hall_id=$2, show_time=$3, available_seats=$4, price=$5, updated_at=NOW() WHERE id=$6`,
 		s.MovieID, s.HallID, s.ShowTime, s.AvailableSeats, s.Price, s.ID)
 	if err != nil {
 		log.Printf("❌ UpdateSchedule error: %v", err)
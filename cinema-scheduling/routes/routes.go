@@ -9,52 +9,77 @@ import (
 )
 
 func SetupRoutes(router *gin.Engine, cfg *config.Config) {
-	// ---------------- Admin & Manager Routes ----------------
+	// ---------------- Admin Routes ----------------
+	// Any authenticated user; each resource needs its "<resource>:write" permission
 	adminGroup := router.Group("/api/admin")
-	adminGroup.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret, "admin", "manager"))
+	adminGroup.Use(middleware.JWTAuthMiddleware())
 	{
 		// ---------------- Movies ----------------
-		adminGroup.POST("/movies", controllers.AddMovie)
-		adminGroup.GET("/movies", controllers.ListMovies)
-		adminGroup.GET("/movies/:movie_id", controllers.GetMovie)
-		adminGroup.PUT("/movies/:movie_id", controllers.UpdateMovie)
-		adminGroup.DELETE("/movies/:movie_id", controllers.DeleteMovie)
+		movies := adminGroup.Group("/movies", middleware.RequirePermission("movies:write"))
+		movies.POST("", controllers.AddMovie)
+		movies.GET("", controllers.ListMovies)
+		movies.GET("/:movie_id", controllers.GetMovie)
+		movies.PUT("/:movie_id", controllers.UpdateMovie)
+		movies.DELETE("/:movie_id", controllers.DeleteMovie)
 
 		// ---------------- Genres ----------------
-		adminGroup.POST("/genres", controllers.AddGenre)
-		adminGroup.GET("/genres", controllers.ListGenres)
-		adminGroup.GET("/genres/:genre_id", controllers.GetGenre)
-		adminGroup.PUT("/genres/:genre_id", controllers.UpdateGenre)
-		adminGroup.DELETE("/genres/:genre_id", controllers.DeleteGenre)
+		genres := adminGroup.Group("/genres", middleware.RequirePermission("genres:write"))
+		genres.POST("", controllers.AddGenre)
+		genres.GET("", controllers.ListGenres)
+		genres.GET("/:genre_id", controllers.GetGenre)
+		genres.PUT("/:genre_id", controllers.UpdateGenre)
+		genres.DELETE("/:genre_id", controllers.DeleteGenre)
 
 		// ---------------- Schedules ----------------
-		adminGroup.POST("/schedules", controllers.AddSchedule)
-		adminGroup.GET("/schedules", controllers.ListSchedules)
-		adminGroup.GET("/schedules/:schedule_id", controllers.GetSchedule)
-		adminGroup.PUT("/schedules/:schedule_id", controllers.UpdateSchedule)
-		adminGroup.DELETE("/schedules/:schedule_id", controllers.DeleteSchedule)
+		schedules := adminGroup.Group("/schedules", middleware.RequirePermission("schedules:write"))
+		schedules.POST("", controllers.AddSchedule)
+		schedules.GET("", controllers.ListSchedules)
+		schedules.GET("/:schedule_id", controllers.GetSchedule)
+		schedules.PUT("/:schedule_id", controllers.UpdateSchedule)
+		schedules.DELETE("/:schedule_id", controllers.DeleteSchedule)
+
+		// ---------------- Schedule-specific Snacks ----------------
+		schedules.POST("/:schedule_id/snacks", controllers.AddScheduleSnack)
+		schedules.GET("/:schedule_id/snacks", controllers.ListScheduleSnacks)
+		// Updated to fetch specific snack for a schedule
+		schedules.GET("/:schedule_id/snacks/:snack_id", controllers.GetScheduleSnack)
+		schedules.PUT("/:schedule_id/snacks/:snack_id", controllers.UpdateScheduleSnack)
+		schedules.DELETE("/:schedule_id/snacks/:snack_id", controllers.DeleteScheduleSnack)
 
 		// ---------------- Snacks ----------------
-		adminGroup.POST("/snacks", controllers.AddSnack)
-		adminGroup.GET("/snacks", controllers.ListSnacks)
-		adminGroup.GET("/snacks/:snack_id", controllers.GetSnack)
-		adminGroup.PUT("/snacks/:snack_id", controllers.UpdateSnack)
-		adminGroup.DELETE("/snacks/:snack_id", controllers.DeleteSnack)
+		snacks := adminGroup.Group("/snacks", middleware.RequirePermission("snacks:write"))
+		snacks.POST("", controllers.AddSnack)
+		snacks.GET("", controllers.ListSnacks)
+		snacks.GET("/:snack_id", controllers.GetSnack)
+		snacks.PUT("/:snack_id", controllers.UpdateSnack)
+		snacks.DELETE("/:snack_id", controllers.DeleteSnack)
+
+		// ---------------- Locations ----------------
+		locations := adminGroup.Group("/locations", middleware.RequirePermission("locations:write"))
+		locations.POST("", controllers.AddLocation)
+		locations.GET("", controllers.ListLocations)
+		locations.GET("/:location_id", controllers.GetLocation)
+		locations.PUT("/:location_id", controllers.UpdateLocation)
+		locations.DELETE("/:location_id", controllers.DeleteLocation)
 
 		// ---------------- Halls ----------------
-		adminGroup.POST("/halls", controllers.AddHall)
-		adminGroup.GET("/halls", controllers.ListHalls)
-		adminGroup.GET("/halls/:hall_id", controllers.GetHall)
-		adminGroup.PUT("/halls/:hall_id", controllers.UpdateHall)
-		adminGroup.DELETE("/halls/:hall_id", controllers.DeleteHall)
+		halls := adminGroup.Group("/halls", middleware.RequirePermission("halls:write"))
+		halls.POST("", controllers.AddHall)
+		halls.GET("", controllers.ListHalls)
+		halls.GET("/:hall_id", controllers.GetHall)
+		halls.PUT("/:hall_id", controllers.UpdateHall)
+		halls.DELETE("/:hall_id", controllers.DeleteHall)
+	}
 
-		// ---------------- Schedule-specific Snacks ----------------
-		adminGroup.POST("/schedules/:schedule_id/snacks", controllers.AddScheduleSnack)
-		adminGroup.GET("/schedules/:schedule_id/snacks", controllers.ListScheduleSnacks)
-		// Updated to fetch specific snack for a schedule
-		adminGroup.GET("/schedules/:schedule_id/snacks/:snack_id", controllers.GetScheduleSnack)
-		adminGroup.PUT("/schedules/:schedule_id/snacks/:snack_id", controllers.UpdateScheduleSnack)
-		adminGroup.DELETE("/schedules/:schedule_id/snacks/:snack_id", controllers.DeleteScheduleSnack)
+	// ---------------- Staff Routes ----------------
+	// Concessions staff manage snacks of schedules at their own location
+	staffGroup := router.Group("/api/staff")
+	staffGroup.Use(middleware.StaffMiddleware("concessions"))
+	{
+		staffGroup.GET("/schedules/:schedule_id/snacks", controllers.StaffListScheduleSnacks)
+		staffGroup.POST("/schedules/:schedule_id/snacks", controllers.StaffAddScheduleSnack)
+		staffGroup.PUT("/schedules/:schedule_id/snacks/:snack_id", controllers.StaffUpdateScheduleSnack)
+		staffGroup.DELETE("/schedules/:schedule_id/snacks/:snack_id", controllers.StaffDeleteScheduleSnack)
 	}
 
 	// ---------------- Public Routes ----------------
@@ -72,6 +97,11 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 		publicGroup.GET("/snacks", controllers.ListSnacks)
 		publicGroup.GET("/snacks/:snack_id", controllers.GetSnack)
 
+		// Locations
+		publicGroup.GET("/locations", controllers.ListLocations)
+		publicGroup.GET("/locations/:location_id", controllers.GetLocation)
+		publicGroup.GET("/schedules/:schedule_id/location", controllers.GetScheduleLocation)
+
 		// Halls
 		publicGroup.GET("/halls", controllers.ListHalls)
 		publicGroup.GET("/halls/:hall_id", controllers.GetHall)
@@ -0,0 +1,90 @@
+// Package auth holds the token rules shared by every cinema service:
+// claims, issuing (auth-backend only), verification, JWKS and revocation clients, and gin helpers.
+package auth
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token types carried in the "type" claim
+const (
+	TypeAccess       = "access"
+	TypeRefresh      = "refresh"
+	TypeMFAChallenge = "mfa_challenge"
+)
+
+// Issuer / audience every service expects
+const (
+	DefaultIssuer   = "cinema-auth"
+	DefaultAudience = "cinema-services"
+)
+
+// Algorithm is the only signing algorithm accepted anywhere
+const Algorithm = "RS256"
+
+// Claims is the payload of every token issued by auth-backend
+type Claims struct {
+	UserID      int      `json:"user_id"`
+	Role        string   `json:"role,omitempty"`
+	SessionID   string   `json:"sid,omitempty"`
+	MFA         bool     `json:"mfa,omitempty"`          // login passed a second factor
+	MFARequired bool     `json:"mfa_required,omitempty"` // the role requires a second factor
+	Perms       []string `json:"perms,omitempty"`        // effective permissions, e.g. "movies:write"
+	Dept        string   `json:"dept,omitempty"`         // staff department, e.g. "concessions"
+	LocationID  int      `json:"location_id,omitempty"`  // staff cinema location (cinema_locations.id)
+	Type        string   `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// MFAPending reports whether the role requires a second factor the login didn't pass
+// (user not enrolled yet). Such a token grants no role, permission or department.
+func (c *Claims) MFAPending() bool {
+	return c.MFARequired && !c.MFA
+}
+
+// HasRole reports whether the token's role is one of roles
+func (c *Claims) HasRole(roles ...string) bool {
+	if c.MFAPending() {
+		return false
+	}
+	for _, r := range roles {
+		if c.Role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the token grants perm
+func (c *Claims) HasPermission(perm string) bool {
+	if c.MFAPending() {
+		return false
+	}
+	for _, p := range c.Perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// InDepartment reports whether the token belongs to staff of one of depts assigned to a location
+func (c *Claims) InDepartment(depts ...string) bool {
+	if c.LocationID == 0 || c.MFAPending() {
+		return false
+	}
+	for _, d := range depts {
+		if c.Dept == d {
+			return true
+		}
+	}
+	return false
+}
+
+// IssuedAtUnix returns iat (0 if missing)
+func (c *Claims) IssuedAtUnix() int64 {
+	if c.IssuedAt == nil {
+		return 0
+	}
+	return c.IssuedAt.Unix()
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimsKey is the gin context key holding the verified *Claims
+const ClaimsKey = "claims"
+
+// BearerToken extracts the token from "Authorization: Bearer <token>"
+func BearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	return token, token != ""
+}
+
+// SetContext stores the claims and the usual shortcuts (user_id, role, session_id, jti, mfa, dept, location_id)
+func SetContext(c *gin.Context, claims *Claims) {
+	c.Set(ClaimsKey, claims)
+	c.Set("user_id", claims.UserID)
+	c.Set("role", claims.Role)
+	c.Set("session_id", claims.SessionID)
+	c.Set("jti", claims.ID)
+	c.Set("mfa", claims.MFA)
+	c.Set("dept", claims.Dept)
+	c.Set("location_id", claims.LocationID)
+}
+
+// ClaimsFrom returns the verified claims of the request (nil if unauthenticated)
+func ClaimsFrom(c *gin.Context) *Claims {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil
+	}
+	claims, _ := v.(*Claims)
+	return claims
+}
+
+// Authenticate verifies the bearer access token; on failure it aborts with 401 and returns nil
+func Authenticate(c *gin.Context, v *Verifier) *Claims {
+	tokenString, ok := BearerToken(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid Authorization header"})
+		return nil
+	}
+
+	claims, err := v.Verify(tokenString, TypeAccess)
+	if err != nil {
+		msg := "Invalid token"
+		switch {
+		case errors.Is(err, ErrWrongTokenType):
+			msg = "Invalid token type"
+		case errors.Is(err, ErrRevoked):
+			msg = "Token has been revoked"
+		case errors.Is(err, ErrRevocationUnavailable):
+			msg = "Unable to verify token status"
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": msg})
+		return nil
+	}
+
+	SetContext(c, claims)
+	return claims
+}
+
+// Middleware authenticates every request of a route group
+func Middleware(v *Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		Authenticate(c, v)
+	}
+}
+
+// abortMFAPending answers 403 when the role requires 2FA and the login didn't pass it
+func abortMFAPending(c *gin.Context, claims *Claims) bool {
+	if !claims.MFAPending() {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":        "Two-factor authentication is mandatory for this role, please enroll and log in again",
+		"mfa_required": true,
+	})
+	return true
+}
+
+// RequireRole allows only the given roles; run after Authenticate
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := ClaimsFrom(c)
+		if claims != nil && abortMFAPending(c, claims) {
+			return
+		}
+		if claims == nil || !claims.HasRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this resource"})
+		}
+	}
+}
+
+// RequirePermission requires every listed permission; run after Authenticate
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := ClaimsFrom(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if abortMFAPending(c, claims) {
+			return
+		}
+		for _, p := range perms {
+			if !claims.HasPermission(p) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + p})
+				return
+			}
+		}
+	}
+}
+
+// RequireDepartment allows staff of the given departments that are assigned to a location; run after Authenticate.
+// Handlers scope data with the "location_id" context value.
+func RequireDepartment(depts ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := ClaimsFrom(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		if abortMFAPending(c, claims) {
+			return
+		}
+		if !claims.InDepartment(depts...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Only staff of " + strings.Join(depts, ", ") + " assigned to a location can access this resource"})
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMFAPolicyEnforced(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := newTestKeys(t, "k1")
+	issuer := &Issuer{Keys: keys, Issuer: DefaultIssuer, Audience: []string{DefaultAudience}}
+	verifier := &Verifier{Keys: keys, Issuer: DefaultIssuer, Audience: DefaultAudience}
+
+	router := gin.New()
+	router.Use(Middleware(verifier))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/movies", RequirePermission("movies:write"), ok)
+	router.GET("/admin", RequireRole("manager", "admin"), ok)
+	router.GET("/staff", RequireDepartment("box_office"), ok)
+
+	manager := func(mfa, required bool) *Claims {
+		return &Claims{UserID: 7, Role: "manager", Perms: []string{"movies:write"}, MFA: mfa, MFARequired: required, Type: TypeAccess}
+	}
+	staff := func(mfa, required bool) *Claims {
+		return &Claims{UserID: 8, Role: "staff", Dept: "box_office", LocationID: 1, MFA: mfa, MFARequired: required, Type: TypeAccess}
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		claims          *Claims
+		wantStatus      int
+		wantMFARequired bool
+	}{
+		{"manager not enrolled, permission route", "/movies", manager(false, true), http.StatusForbidden, true},
+		{"manager not enrolled, role route", "/admin", manager(false, true), http.StatusForbidden, true},
+		{"staff not enrolled, department route", "/staff", staff(false, true), http.StatusForbidden, true},
+		{"manager after 2FA", "/movies", manager(true, true), http.StatusOK, false},
+		{"manager after 2FA, role route", "/admin", manager(true, true), http.StatusOK, false},
+		{"staff after 2FA", "/staff", staff(true, true), http.StatusOK, false},
+		{"role without 2FA policy", "/movies", manager(false, false), http.StatusOK, false},
+		{"missing permission", "/movies", &Claims{UserID: 9, Role: "customer", Type: TypeAccess}, http.StatusForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := issuer.Issue(tt.claims, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var body struct {
+				MFARequired bool `json:"mfa_required"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if body.MFARequired != tt.wantMFARequired {
+				t.Errorf("mfa_required = %v, want %v", body.MFARequired, tt.wantMFARequired)
+			}
+		})
+	}
+}
+
+func TestClaimsMFAPending(t *testing.T) {
+	pending := &Claims{Role: "admin", Perms: []string{"users:manage"}, Dept: "box_office", LocationID: 1, MFARequired: true}
+	if !pending.MFAPending() {
+		t.Fatal("MFAPending() = false for a required, skipped second factor")
+	}
+	if pending.HasRole("admin") || pending.HasPermission("users:manage") || pending.InDepartment("box_office") {
+		t.Error("a token pending 2FA still grants its role, permissions or department")
+	}
+
+	passed := *pending
+	passed.MFA = true
+	if passed.MFAPending() || !passed.HasRole("admin") || !passed.HasPermission("users:manage") || !passed.InDepartment("box_office") {
+		t.Error("a token that passed 2FA lost its role, permissions or department")
+	}
+}
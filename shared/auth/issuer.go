@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer signs tokens; only auth-backend holds one
+type Issuer struct {
+	Keys     SigningKeyProvider
+	Issuer   string
+	Audience []string
+}
+
+// NewTokenID returns a random identifier used as the jti claim
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Issue fills iss, aud, iat, exp and jti and signs the claims with the active key
+func (i *Issuer) Issue(claims *Claims, ttl time.Duration) (string, error) {
+	if i == nil || i.Keys == nil {
+		return "", errors.New("token issuer not initialized")
+	}
+	if claims.Type == "" {
+		return "", errors.New("token type is required")
+	}
+	if claims.ID == "" {
+		jti, err := NewTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.ID = jti
+	}
+	now := time.Now()
+	claims.Issuer = i.Issuer
+	claims.Audience = i.Audience
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+
+	kid, key, err := i.Keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// An unknown kid triggers a refetch (new key after rotation), at most this often
+const jwksMinRefetch = 30 * time.Second
+
+// JWKSClient caches the public signing keys of a JWKS document by kid
+// (auth-backend's, or an external OpenID provider's)
+type JWKSClient struct {
+	url       string
+	client    *http.Client
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	lastFetch time.Time // last attempt, successful or not
+}
+
+// NewJWKSClient returns a client for the JWKS document at url
+func NewJWKSClient(url string) *JWKSClient {
+	return &JWKSClient{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   map[string]*rsa.PublicKey{},
+	}
+}
+
+// Start fetches the keys now and then every interval in the background
+func (j *JWKSClient) Start(interval time.Duration) {
+	pull := func() {
+		if err := j.Refresh(); err != nil {
+			log.Printf("⚠️ Failed to refresh JWKS: %v", err)
+		}
+	}
+
+	pull()
+	go func() {
+		ticker := time.NewTicker(interval)
+		for range ticker.C {
+			pull()
+		}
+	}()
+}
+
+// Refresh downloads the JWKS document
+func (j *JWKSClient) Refresh() error {
+	j.mu.Lock()
+	j.lastFetch = time.Now()
+	j.mu.Unlock()
+
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		// Some identity providers (e.g. Microsoft) omit alg on RSA keys
+		if k.Alg != Algorithm && k.Alg != "" {
+			continue
+		}
+		pub, err := k.RSAPublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.KID] = pub
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.mu.Unlock()
+	return nil
+}
+
+// VerificationKey returns the key for a kid, refetching once if it is unknown (KeyResolver)
+func (j *JWKSClient) VerificationKey(kid string) (crypto.PublicKey, bool) {
+	j.mu.Lock()
+	key, ok := j.keys[kid]
+	stale := time.Since(j.lastFetch) > jwksMinRefetch
+	if !ok && stale {
+		j.lastFetch = time.Now() // claim the refetch so concurrent misses don't pile up
+	}
+	j.mu.Unlock()
+	if ok {
+		return key, true
+	}
+	if !stale {
+		return nil, false
+	}
+
+	if err := j.Refresh(); err != nil {
+		log.Printf("⚠️ Failed to refresh JWKS for kid %q: %v", kid, err)
+		return nil, false
+	}
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok = j.keys[kid]
+	if !ok {
+		return nil, false
+	}
+	return key, true
+}
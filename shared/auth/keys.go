@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+)
+
+// SigningKeyProvider supplies the active private key (auth-backend's keyring)
+type SigningKeyProvider interface {
+	SigningKey() (kid string, key crypto.Signer, err error)
+}
+
+// KeyResolver finds the public key for a kid (keyring or JWKS client)
+type KeyResolver interface {
+	VerificationKey(kid string) (crypto.PublicKey, bool)
+}
+
+// JWK is one public key in a JWKS document (RFC 7517)
+type JWK struct {
+	KTY string `json:"kty"`
+	KID string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKSet is served by auth-backend at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewRSAJWK encodes an RSA public key as a JWK
+func NewRSAJWK(kid string, pub *rsa.PublicKey) JWK {
+	return JWK{
+		KTY: "RSA",
+		KID: kid,
+		Use: "sig",
+		Alg: Algorithm,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+// RSAPublicKey decodes an RSA JWK
+func (k JWK) RSAPublicKey() (*rsa.PublicKey, error) {
+	if k.KTY != "RSA" {
+		return nil, errors.New("not an RSA key")
+	}
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RevocationList is the snapshot auth-backend serves at /api/internal/revocations
+type RevocationList struct {
+	Tokens   map[string]int64 `json:"tokens"`   // jti → exp
+	Users    map[string]int64 `json:"users"`    // user_id → tokens issued up to this second are revoked
+	Sessions map[string]int64 `json:"sessions"` // sid → revoked at
+}
+
+// ErrRevocationListStale is returned while the local revocation list can't be trusted:
+// never loaded, or not refreshed for longer than the client's max age
+var ErrRevocationListStale = errors.New("revocation list is stale")
+
+// RevocationClient keeps a local copy of auth-backend's revocation list.
+// It fails closed: once the copy is older than maxAge every check returns an error.
+type RevocationClient struct {
+	url         string
+	internalKey string
+	client      *http.Client
+
+	mu          sync.RWMutex
+	tokens      map[string]int64
+	users       map[int]int64
+	sessions    map[string]int64
+	lastRefresh time.Time     // last successful refresh
+	maxAge      time.Duration // set by Start; 0 = no bound
+}
+
+// NewRevocationClient returns a client polling authServiceURL with the internal API key
+func NewRevocationClient(authServiceURL, internalKey string) *RevocationClient {
+	return &RevocationClient{
+		url:         authServiceURL + "/api/internal/revocations",
+		internalKey: internalKey,
+		client:      &http.Client{Timeout: 5 * time.Second},
+		tokens:      map[string]int64{},
+		users:       map[int]int64{},
+		sessions:    map[string]int64{},
+	}
+}
+
+// Start pulls the list now and then every interval in the background. Tokens are rejected
+// once two intervals pass without a successful refresh.
+func (r *RevocationClient) Start(interval time.Duration) {
+	r.mu.Lock()
+	r.maxAge = 2 * interval
+	r.mu.Unlock()
+
+	stale := false
+	pull := func() {
+		err := r.Refresh()
+		switch {
+		case err == nil && stale:
+			stale = false
+			log.Println("✅ Revocation list refreshed again, accepting tokens")
+		case err != nil && r.stale():
+			stale = true
+			log.Printf("🚨 Revocation list is stale (last refresh: %s), rejecting every token until auth-backend answers: %v", r.lastRefreshed(), err)
+		case err != nil:
+			log.Printf("⚠️ Failed to refresh revocation list: %v", err)
+		}
+	}
+
+	pull()
+	go func() {
+		ticker := time.NewTicker(interval)
+		for range ticker.C {
+			pull()
+		}
+	}()
+}
+
+// Refresh downloads the current revocation list
+func (r *RevocationClient) Refresh() error {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Internal-Key", r.internalKey)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var list RevocationList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return err
+	}
+
+	users := make(map[int]int64, len(list.Users))
+	for id, ts := range list.Users {
+		if userID, err := strconv.Atoi(id); err == nil {
+			users[userID] = ts
+		}
+	}
+
+	r.mu.Lock()
+	r.tokens = list.Tokens
+	r.users = users
+	r.sessions = list.Sessions
+	r.lastRefresh = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// stale reports whether the list was never loaded or is older than maxAge
+func (r *RevocationClient) stale() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.staleLocked()
+}
+
+func (r *RevocationClient) staleLocked() bool {
+	if r.lastRefresh.IsZero() {
+		return true
+	}
+	return r.maxAge > 0 && time.Since(r.lastRefresh) > r.maxAge
+}
+
+func (r *RevocationClient) lastRefreshed() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.lastRefresh.IsZero() {
+		return "never"
+	}
+	return time.Since(r.lastRefresh).Round(time.Second).String() + " ago"
+}
+
+// IsRevoked checks a token by jti, session, user and issue time (RevocationChecker).
+// Returns ErrRevocationListStale while the list can't be trusted.
+func (r *RevocationClient) IsRevoked(c *Claims) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.staleLocked() {
+		return false, ErrRevocationListStale
+	}
+
+	if _, ok := r.tokens[c.ID]; ok && c.ID != "" {
+		return true, nil
+	}
+	if _, ok := r.sessions[c.SessionID]; ok && c.SessionID != "" {
+		return true, nil
+	}
+	// iat has whole seconds: a token issued in the cutoff's second may predate it
+	if cutoff, ok := r.users[c.UserID]; ok && c.IssuedAtUnix() <= cutoff {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRevocationClientStaleness(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastRefresh time.Duration // ago; 0 = never refreshed
+		maxAge      time.Duration
+		wantErr     error
+	}{
+		{"never refreshed", 0, time.Minute, ErrRevocationListStale},
+		{"never refreshed, not started", 0, 0, ErrRevocationListStale},
+		{"fresh", 10 * time.Second, time.Minute, nil},
+		{"just within max age", 55 * time.Second, time.Minute, nil},
+		{"older than max age", 2 * time.Minute, time.Minute, ErrRevocationListStale},
+		{"not started: no bound", time.Hour, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRevocationClient("http://auth.invalid", "internal")
+			r.maxAge = tt.maxAge
+			if tt.lastRefresh > 0 {
+				r.lastRefresh = time.Now().Add(-tt.lastRefresh)
+			}
+			revoked, err := r.IsRevoked(&Claims{UserID: 7})
+			if !errors.Is(err, tt.wantErr) || revoked {
+				t.Errorf("IsRevoked() = %v, %v; want false, %v", revoked, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRevocationClientStartFailsClosed(t *testing.T) {
+	// Nothing answers: the first pull fails and the list is never loaded
+	r := NewRevocationClient("http://127.0.0.1:1", "internal")
+	r.Start(time.Hour)
+
+	if _, err := r.IsRevoked(&Claims{UserID: 7}); !errors.Is(err, ErrRevocationListStale) {
+		t.Errorf("IsRevoked() error = %v, want ErrRevocationListStale", err)
+	}
+	if r.maxAge != 2*time.Hour {
+		t.Errorf("maxAge = %s, want two intervals", r.maxAge)
+	}
+}
+
+func TestRevocationClientUserCutoff(t *testing.T) {
+	cutoff := time.Now().Unix()
+	tests := []struct {
+		name     string
+		issuedAt int64
+		want     bool
+	}{
+		{"issued the second before", cutoff - 1, true},
+		{"issued in the cutoff's second", cutoff, true},
+		{"issued the second after", cutoff + 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRevocationClient("http://auth.invalid", "internal")
+			r.lastRefresh = time.Now()
+			r.users = map[int]int64{7: cutoff}
+
+			claims := &Claims{UserID: 7, RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(time.Unix(tt.issuedAt, 0))}}
+			if revoked, err := r.IsRevoked(claims); err != nil || revoked != tt.want {
+				t.Errorf("IsRevoked() = %v, %v; want %v", revoked, err, tt.want)
+			}
+		})
+	}
+}
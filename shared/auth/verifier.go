@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Verification errors; wrap-checked with errors.Is
+var (
+	ErrInvalidToken           = errors.New("invalid token")
+	ErrWrongTokenType         = errors.New("invalid token type")
+	ErrRevoked                = errors.New("token has been revoked")
+	ErrRevocationUnavailable  = errors.New("unable to verify token status")
+	errVerifierNotInitialized = errors.New("token verifier not initialized")
+)
+
+// RevocationChecker reports tokens revoked before they expire (logout, revoked sessions, ...)
+type RevocationChecker interface {
+	IsRevoked(c *Claims) (bool, error)
+}
+
+// Verifier applies the same validation rules in every service:
+// RS256 only, known kid, issuer, audience, exp/iat, token type and revocation.
+type Verifier struct {
+	Keys        KeyResolver
+	Issuer      string
+	Audience    string
+	Revocations RevocationChecker // optional
+	Leeway      time.Duration     // clock skew tolerated on exp/iat
+}
+
+// Verify parses a token and checks it is a valid token of expectedType
+func (v *Verifier) Verify(tokenString, expectedType string) (*Claims, error) {
+	if v == nil || v.Keys == nil {
+		return nil, errVerifierNotInitialized
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			key, ok := v.Keys.VerificationKey(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown signing key %q", kid)
+			}
+			return key, nil
+		},
+		jwt.WithValidMethods([]string{Algorithm}),
+		jwt.WithIssuer(v.Issuer),
+		jwt.WithAudience(v.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(v.Leeway),
+	)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Type != expectedType {
+		return nil, ErrWrongTokenType
+	}
+	if claims.UserID <= 0 {
+		return nil, fmt.Errorf("%w: missing user_id", ErrInvalidToken)
+	}
+
+	if v.Revocations != nil {
+		revoked, err := v.Revocations.IsRevoked(claims)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRevocationUnavailable, err)
+		}
+		if revoked {
+			return nil, ErrRevoked
+		}
+	}
+	return claims, nil
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeys is a fixed keyring: signs with its active kid, resolves every kid it holds
+type testKeys struct {
+	active string
+	keys   map[string]*rsa.PrivateKey
+}
+
+func (k *testKeys) SigningKey() (string, crypto.Signer, error) {
+	return k.active, k.keys[k.active], nil
+}
+
+func (k *testKeys) VerificationKey(kid string) (crypto.PublicKey, bool) {
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, false
+	}
+	return &key.PublicKey, true
+}
+
+func newTestKeys(t *testing.T, kids ...string) *testKeys {
+	t.Helper()
+	k := &testKeys{active: kids[0], keys: map[string]*rsa.PrivateKey{}}
+	for _, kid := range kids {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.keys[kid] = key
+	}
+	return k
+}
+
+// revocationServer serves list as auth-backend's /api/internal/revocations
+func revocationServer(t *testing.T, list RevocationList) *RevocationClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Internal-Key") != "internal" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(list)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewRevocationClient(srv.URL, "internal")
+	if err := client.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestVerifier(t *testing.T) {
+	keys := newTestKeys(t, "k1", "k2")
+	issuer := &Issuer{Keys: keys, Issuer: DefaultIssuer, Audience: []string{DefaultAudience}}
+	other := newTestKeys(t, "k1")
+
+	issue := func(mutate func(c *Claims)) func(t *testing.T) string {
+		return func(t *testing.T) string {
+			claims := &Claims{UserID: 7, Role: "customer", SessionID: "s-1", Type: TypeAccess}
+			token, err := issuer.Issue(claims, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mutate == nil {
+				return token
+			}
+			// Re-sign the modified claims with the same key and kid
+			mutate(claims)
+			signed := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			signed.Header["kid"] = "k1"
+			token, err = signed.SignedString(keys.keys["k1"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}
+	}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) func(t *testing.T) string {
+		return func(t *testing.T) string {
+			now := time.Now()
+			token := jwt.NewWithClaims(method, &Claims{
+				UserID: 7,
+				Type:   TypeAccess,
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    DefaultIssuer,
+					Audience:  jwt.ClaimStrings{DefaultAudience},
+					IssuedAt:  jwt.NewNumericDate(now),
+					ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+				},
+			})
+			token.Header["kid"] = kid
+			s, err := token.SignedString(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		}
+	}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		typ     string
+		wantErr error
+	}{
+		{"valid", issue(nil), TypeAccess, nil},
+		{"signed with an older key still held", sign(jwt.SigningMethodRS256, "k2", keys.keys["k2"]), TypeAccess, nil},
+
+		{"wrong alg: HS256", sign(jwt.SigningMethodHS256, "k1", []byte("secret")), TypeAccess, ErrInvalidToken},
+		{"wrong alg: RS512", sign(jwt.SigningMethodRS512, "k1", keys.keys["k1"]), TypeAccess, ErrInvalidToken},
+		{"wrong alg: none", sign(jwt.SigningMethodNone, "k1", jwt.UnsafeAllowNoneSignatureType), TypeAccess, ErrInvalidToken},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "k3", keys.keys["k1"]), TypeAccess, ErrInvalidToken},
+		{"known kid, other key", sign(jwt.SigningMethodRS256, "k1", other.keys["k1"]), TypeAccess, ErrInvalidToken},
+		{"not a JWT", func(*testing.T) string { return "not.a.jwt" }, TypeAccess, ErrInvalidToken},
+
+		{"wrong token type", issue(nil), TypeRefresh, ErrWrongTokenType},
+		{"wrong audience", issue(func(c *Claims) { c.Audience = jwt.ClaimStrings{"other-service"} }), TypeAccess, ErrInvalidToken},
+		{"no audience", issue(func(c *Claims) { c.Audience = nil }), TypeAccess, ErrInvalidToken},
+		{"wrong issuer", issue(func(c *Claims) { c.Issuer = "someone-else" }), TypeAccess, ErrInvalidToken},
+		{"expired", issue(func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }), TypeAccess, ErrInvalidToken},
+		{"no expiry", issue(func(c *Claims) { c.ExpiresAt = nil }), TypeAccess, ErrInvalidToken},
+		{"issued in the future", issue(func(c *Claims) { c.IssuedAt = jwt.NewNumericDate(time.Now().Add(time.Hour)) }), TypeAccess, ErrInvalidToken},
+		{"missing user_id", issue(func(c *Claims) { c.UserID = 0 }), TypeAccess, ErrInvalidToken},
+	}
+	verifier := &Verifier{Keys: keys, Issuer: DefaultIssuer, Audience: DefaultAudience}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token(t), tt.typ)
+			if tt.wantErr == nil {
+				if err != nil || claims.UserID != 7 {
+					t.Errorf("Verify() = %v, %v; want user 7", claims, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifierRevocation(t *testing.T) {
+	keys := newTestKeys(t, "k1")
+	issuer := &Issuer{Keys: keys, Issuer: DefaultIssuer, Audience: []string{DefaultAudience}}
+
+	issue := func(t *testing.T, jti, sid string) string {
+		t.Helper()
+		token, err := issuer.Issue(&Claims{UserID: 7, SessionID: sid, Type: TypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{ID: jti}}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name    string
+		list    RevocationList
+		wantErr error
+	}{
+		{"nothing revoked", RevocationList{}, nil},
+		{"other tokens revoked", RevocationList{
+			Tokens:   map[string]int64{"other-jti": now + 60},
+			Sessions: map[string]int64{"other-sid": now},
+			Users:    map[string]int64{"8": now + 60},
+		}, nil},
+		{"jti revoked", RevocationList{Tokens: map[string]int64{"jti-1": now + 60}}, ErrRevoked},
+		{"session revoked", RevocationList{Sessions: map[string]int64{"sid-1": now}}, ErrRevoked},
+		{"issued before the user's cutoff", RevocationList{Users: map[string]int64{"7": now + 60}}, ErrRevoked},
+		{"issued after the user's cutoff", RevocationList{Users: map[string]int64{"7": now - 60}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := &Verifier{
+				Keys:        keys,
+				Issuer:      DefaultIssuer,
+				Audience:    DefaultAudience,
+				Revocations: revocationServer(t, tt.list),
+			}
+			_, err := verifier.Verify(issue(t, "jti-1", "sid-1"), TypeAccess)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// failingRevocations can't tell whether a token is revoked
+type failingRevocations struct{}
+
+func (failingRevocations) IsRevoked(*Claims) (bool, error) {
+	return false, errors.New("list unavailable")
+}
+
+func TestVerifierRevocationUnavailable(t *testing.T) {
+	keys := newTestKeys(t, "k1")
+	issuer := &Issuer{Keys: keys, Issuer: DefaultIssuer, Audience: []string{DefaultAudience}}
+	token, err := issuer.Issue(&Claims{UserID: 7, Type: TypeAccess}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := &Verifier{Keys: keys, Issuer: DefaultIssuer, Audience: DefaultAudience, Revocations: failingRevocations{}}
+	if _, err := verifier.Verify(token, TypeAccess); !errors.Is(err, ErrRevocationUnavailable) {
+		t.Errorf("Verify() error = %v, want ErrRevocationUnavailable", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package health serves the liveness (/healthz) and readiness (/readyz) probes of a service
+// and drains it on shutdown: once SIGTERM arrives readiness fails, so the orchestrator stops
+// routing traffic before the server stops accepting it.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CheckTimeout bounds each dependency check of a readiness probe
+const CheckTimeout = 2 * time.Second
+
+// ShutdownTimeout is how long in-flight requests may take to finish after draining
+const ShutdownTimeout = 15 * time.Second
+
+// Check reports whether a dependency is usable; ctx carries the check's deadline
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Probes holds the dependency checks of a service
+type Probes struct {
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// New returns probes without dependencies (always ready until draining)
+func New() *Probes {
+	return &Probes{}
+}
+
+// Add registers a dependency readiness depends on
+func (p *Probes) Add(name string, check Check) {
+	p.checks = append(p.checks, namedCheck{name, check})
+}
+
+// Drain makes readiness fail from now on
+func (p *Probes) Drain() {
+	p.draining.Store(true)
+}
+
+// DependencyStatus is the result of one check
+type DependencyStatus struct {
+	Status     string `json:"status"` // "ok", "fail"
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// ---------------- Liveness ----------------
+// The process is up and serving requests; dependencies are not checked, so a database
+// outage doesn't get every replica restarted.
+func (p *Probes) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ---------------- Readiness ----------------
+// All dependencies are checked in parallel, each within CheckTimeout. 200 when all pass,
+// 503 when one fails or the service is draining.
+func (p *Probes) Ready(c *gin.Context) {
+	results := make(map[string]DependencyStatus, len(p.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range p.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), CheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(ctx)
+			res := DependencyStatus{Status: "ok", DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				res.Status, res.Error = "fail", err.Error()
+			}
+			mu.Lock()
+			results[nc.name] = res
+			mu.Unlock()
+		}(nc)
+	}
+	wg.Wait()
+
+	status, code := "ready", http.StatusOK
+	for _, res := range results {
+		if res.Status != "ok" {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+	}
+	if p.draining.Load() {
+		status, code = "draining", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": results})
+}
+
+// Postgres checks that the pool can reach the database
+func Postgres(pool *pgxpool.Pool) Check {
+	return func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	}
+}
+
+var httpClient = &http.Client{Timeout: CheckTimeout}
+
+// Downstream checks that another service is alive: its /healthz answers with a 2xx status.
+// Its readiness isn't asked, so one broken dependency doesn't take every caller out of rotation.
+func Downstream(baseURL string) Check {
+	return func(ctx context.Context) error {
+		if baseURL == "" {
+			return errors.New("not configured")
+		}
+		url := strings.TrimRight(baseURL, "/") + "/healthz"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// ---------------- Serve ----------------
+// ListenAndServe runs srv until SIGINT or SIGTERM, then drains: readiness fails for drainFor
+// while requests are still served, then in-flight requests get up to ShutdownTimeout to finish.
+func ListenAndServe(srv *http.Server, p *Probes, drainFor time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-errc:
+		return err
+	case sig := <-stop:
+		log.Printf("🛑 Received %s, draining for %s", sig, drainFor)
+	}
+
+	p.Drain()
+	time.Sleep(drainFor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	log.Println("👋 Server stopped")
+	return nil
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func get(t *testing.T, p *Probes, path string) (int, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/healthz", p.Live)
+	r.GET("/readyz", p.Ready)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, body
+}
+
+func TestReady(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		drain      bool
+		wantCode   int
+		wantStatus string
+	}{
+		{"no dependencies", nil, false, http.StatusOK, "ready"},
+		{"all pass", map[string]Check{"postgres": ok, "redis": ok}, false, http.StatusOK, "ready"},
+		{"one fails", map[string]Check{"postgres": ok, "redis": fail}, false, http.StatusServiceUnavailable, "not_ready"},
+		{"draining", map[string]Check{"postgres": ok}, true, http.StatusServiceUnavailable, "draining"},
+		{"draining without dependencies", nil, true, http.StatusServiceUnavailable, "draining"},
+		{"draining wins over a failure", map[string]Check{"postgres": fail}, true, http.StatusServiceUnavailable, "draining"},
+		{"check times out", map[string]Check{"postgres": slow}, false, http.StatusServiceUnavailable, "not_ready"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			for name, check := range tt.checks {
+				p.Add(name, check)
+			}
+			if tt.drain {
+				p.Drain()
+			}
+			code, body := get(t, p, "/readyz")
+			if code != tt.wantCode || body["status"] != tt.wantStatus {
+				t.Errorf("/readyz = %d %v, want %d %q", code, body["status"], tt.wantCode, tt.wantStatus)
+			}
+			checks, _ := body["checks"].(map[string]interface{})
+			if len(checks) != len(tt.checks) {
+				t.Errorf("checks = %v, want one per dependency", checks)
+			}
+		})
+	}
+}
+
+func TestLiveWhileDraining(t *testing.T) {
+	// Liveness ignores dependencies and draining: the process is still up
+	p := New()
+	p.Add("postgres", func(context.Context) error { return errors.New("down") })
+	p.Drain()
+	if code, body := get(t, p, "/healthz"); code != http.StatusOK || body["status"] != "ok" {
+		t.Errorf("/healthz = %d %v, want 200 ok", code, body)
+	}
+}
+
+func TestDownstream(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"healthy", up.URL, false},
+		{"trailing slash", up.URL + "/", false},
+		{"unhealthy", down.URL, true},
+		{"not configured", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := Downstream(tt.url)(ctx); (err != nil) != tt.wantErr {
+				t.Errorf("Downstream(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Statements worth preparing: string constants that read like SQL
+var sqlStart = regexp.MustCompile(`(?is)^\s*(SELECT|INSERT|UPDATE|DELETE|WITH)\s`)
+
+// Query is an SQL statement found in Go source
+type Query struct {
+	Pos string // file:line
+	SQL string
+}
+
+// FindQueries collects the SQL statements in the Go files of dirs: string literals, and
+// concatenations of literals and package-level string constants, that start with SELECT,
+// INSERT, UPDATE, DELETE or WITH. Queries built at run time (fmt.Sprintf, ...) are skipped.
+func FindQueries(dirs ...string) ([]Query, error) {
+	fset := token.NewFileSet()
+	seen := map[string]bool{}
+	queries := []Query{}
+
+	for _, dir := range dirs {
+		files, err := parseDir(fset, dir)
+		if err != nil {
+			return nil, err
+		}
+		consts := packageStrings(files)
+		for _, f := range files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				switch e := n.(type) {
+				case *ast.CallExpr:
+					return !isFmtCall(e) // format strings, not statements
+				case *ast.BasicLit:
+				case *ast.BinaryExpr:
+					if e.Op != token.ADD {
+						return true
+					}
+				default:
+					return true
+				}
+				s, ok := stringValue(n.(ast.Expr), consts)
+				if !ok {
+					return false // partly dynamic: its pieces aren't statements on their own
+				}
+				if sqlStart.MatchString(s) && !seen[s] {
+					seen[s] = true
+					queries = append(queries, Query{Pos: fset.Position(n.Pos()).String(), SQL: s})
+				}
+				return false
+			})
+		}
+	}
+	return queries, nil // in source order: files by name, then line
+}
+
+func isFmtCall(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "fmt"
+}
+
+// parseDir parses the non-test Go files of one package directory
+func parseDir(fset *token.FileSet, dir string) ([]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []*ast.File{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// packageStrings resolves the package-level string constants and variables of a package
+func packageStrings(files []*ast.File) map[string]string {
+	specs := map[string]ast.Expr{}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || (gen.Tok != token.CONST && gen.Tok != token.VAR) {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Names) != len(vs.Values) {
+					continue
+				}
+				for i, name := range vs.Names {
+					specs[name.Name] = vs.Values[i]
+				}
+			}
+		}
+	}
+
+	// Constants may be built from each other: resolve until nothing changes
+	values := map[string]string{}
+	for changed := true; changed; {
+		changed = false
+		for name, expr := range specs {
+			if _, done := values[name]; done {
+				continue
+			}
+			if s, ok := stringValue(expr, values); ok {
+				values[name] = s
+				changed = true
+			}
+		}
+	}
+	return values
+}
+
+// stringValue evaluates string literals, known identifiers and their concatenation
+func stringValue(expr ast.Expr, known map[string]string) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.Ident:
+		s, ok := known[e.Name]
+		return s, ok
+	case *ast.ParenExpr:
+		return stringValue(e.X, known)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		l, ok := stringValue(e.X, known)
+		if !ok {
+			return "", false
+		}
+		r, ok := stringValue(e.Y, known)
+		return l + r, ok
+	}
+	return "", false
+}
+
+// QueryError is a statement the database rejected
+type QueryError struct {
+	Query
+	Err error
+}
+
+// CheckQueries prepares (parses and plans, without running) every query against the database,
+// so a column or table the code uses but no migration creates is reported. Statements whose
+// parameter types PostgreSQL can't infer on their own are counted as unchecked, not as failures.
+func CheckQueries(ctx context.Context, pool *pgxpool.Pool, queries []Query) (failed []QueryError, unchecked []Query, err error) {
+	c, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Release()
+	pg := c.Conn().PgConn()
+
+	for _, q := range queries {
+		_, err := pg.Prepare(ctx, "", q.SQL, nil) // unnamed: replaced by the next one
+		if err == nil {
+			continue
+		}
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) {
+			return nil, nil, fmt.Errorf("%s: %w", q.Pos, err)
+		}
+		if pgErr.Code == "42P18" { // indeterminate_datatype
+			unchecked = append(unchecked, q)
+			continue
+		}
+		failed = append(failed, QueryError{Query: q, Err: err})
+	}
+	return failed, unchecked, nil
+}
@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Command runs the "migrate" subcommand of a service:
+//
+//	migrate up              apply pending migrations
+//	migrate down [n]        revert the newest n migrations (default 1, 0 = all)
+//	migrate status          list migrations and when they were applied
+//	migrate check [dirs...] apply pending migrations, then prepare every SQL statement of
+//	                        the Go files in dirs (default "models") against the schema
+func Command(ctx context.Context, pool *pgxpool.Pool, migrations []*Migration, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [n] | status | check [dirs...]")
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := Up(ctx, pool, migrations)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Schema up to date (%d migration(s) applied)", n)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 0 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		n, err := Down(ctx, pool, migrations, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Reverted %d migration(s)", n)
+
+	case "status":
+		done, err := Status(ctx, pool, migrations)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if a, ok := done[m.Version]; ok {
+				log.Printf("✅ %04d_%s applied %s", m.Version, m.Name, a.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				log.Printf("⏳ %04d_%s pending", m.Version, m.Name)
+			}
+		}
+		return verify(migrations, done)
+
+	case "check":
+		dirs := args[1:]
+		if len(dirs) == 0 {
+			dirs = []string{"models"}
+		}
+		if _, err := Up(ctx, pool, migrations); err != nil {
+			return err
+		}
+		queries, err := FindQueries(dirs...)
+		if err != nil {
+			return err
+		}
+		failed, unchecked, err := CheckQueries(ctx, pool, queries)
+		if err != nil {
+			return err
+		}
+		for _, q := range unchecked {
+			log.Printf("⚠️ %s: parameter types can't be inferred, not checked", q.Pos)
+		}
+		for _, f := range failed {
+			log.Printf("❌ %s: %v", f.Pos, f.Err)
+		}
+		log.Printf("🔎 Checked %d queries: %d failed, %d unchecked", len(queries), len(failed), len(unchecked))
+		if len(failed) > 0 {
+			return fmt.Errorf("%d queries don't match the migrated schema", len(failed))
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	return nil
+}
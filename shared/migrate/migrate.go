@@ -0,0 +1,311 @@
+// Package migrate applies the versioned SQL migrations each service embeds.
+//
+// A migration is a pair of files "<version>_<name>.up.sql" / "<version>_<name>.down.sql"
+// (e.g. "0002_schedule_price.up.sql"), or a Go function for data changes that need the
+// service's own logic. Applied versions are recorded in schema_migrations together with a
+// checksum of the up script, so an edited migration is noticed.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockKey serializes migrations of replicas starting at the same time (pg_advisory_lock)
+const lockKey = 727_001
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,              -- SHA-256 of the up script
+    applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+)`
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one schema version
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+
+	// Func is the up step of a migration written in Go (Up and Down are empty). It runs in the
+	// migration's transaction; reverting it only forgets the version, the data stays as it is.
+	Func func(ctx context.Context, tx pgx.Tx) error
+}
+
+// Applied is a row of schema_migrations
+type Applied struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Load reads the migrations in the root of fsys and adds the Go migrations of code, ordered by
+// version. Every SQL version needs both scripts; other files are ignored.
+func Load(fsys fs.FS, code ...*Migration) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%s: invalid version", e.Name())
+		}
+		body, err := fs.ReadFile(fsys, path.Join(".", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("version %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+			sum := sha256.Sum256(body)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	for _, mig := range code {
+		if mig.Func == nil || mig.Up != "" || mig.Down != "" || mig.Version <= 0 {
+			return nil, fmt.Errorf("migration %d_%s: a Go migration needs a version and only a Func", mig.Version, mig.Name)
+		}
+		if other, ok := byVersion[mig.Version]; ok {
+			return nil, fmt.Errorf("version %d has two migrations: %s and %s", mig.Version, other.Name, mig.Name)
+		}
+		sum := sha256.Sum256([]byte("go:" + mig.Name))
+		mig.Checksum = hex.EncodeToString(sum[:])
+		byVersion[mig.Version] = mig
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Func != nil {
+			migrations = append(migrations, mig)
+			continue
+		}
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// withLock runs fn on one connection holding the migration lock, with schema_migrations in place
+func withLock(ctx context.Context, pool *pgxpool.Pool, fn func(conn *pgx.Conn) error) error {
+	c, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Release()
+	conn := c.Conn()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	if _, err := conn.Exec(ctx, createVersionTable); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+func applied(ctx context.Context, conn *pgx.Conn) (map[int64]*Applied, error) {
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]*Applied{}
+	for rows.Next() {
+		a := &Applied{}
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		out[a.Version] = a
+	}
+	return out, rows.Err()
+}
+
+// verify refuses to go on when an applied migration was edited or is unknown to this build
+func verify(migrations []*Migration, done map[int64]*Applied) error {
+	known := map[int64]*Migration{}
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+	for v, a := range done {
+		m, ok := known[v]
+		if !ok {
+			return fmt.Errorf("database is at version %d (%s), which this build doesn't know: deploy a newer build or migrate down with it", v, a.Name)
+		}
+		if m.Checksum != a.Checksum {
+			return fmt.Errorf("migration %d_%s was changed after it was applied: add a new migration instead", v, m.Name)
+		}
+	}
+	return nil
+}
+
+// run executes one script and updates schema_migrations in the same transaction
+func run(ctx context.Context, conn *pgx.Conn, m *Migration, up bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	script, direction := m.Up, "up"
+	if !up {
+		script, direction = m.Down, "down"
+	}
+	switch {
+	case m.Func != nil && up:
+		err = m.Func(ctx, tx)
+	case script != "":
+		_, err = tx.Exec(ctx, script)
+	}
+	if err != nil {
+		return fmt.Errorf("migration %d_%s (%s): %w", m.Version, m.Name, direction, err)
+	}
+	if up {
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1,$2,$3)`, m.Version, m.Name, m.Checksum)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version=$1`, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Up applies every pending migration in order and returns how many ran
+func Up(ctx context.Context, pool *pgxpool.Pool, migrations []*Migration) (int, error) {
+	count := 0
+	err := withLock(ctx, pool, func(conn *pgx.Conn) error {
+		done, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(migrations, done); err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, m, true); err != nil {
+				return err
+			}
+			log.Printf("⬆️ Applied migration %d_%s", m.Version, m.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the newest steps applied migrations (all of them if steps <= 0)
+func Down(ctx context.Context, pool *pgxpool.Pool, migrations []*Migration, steps int) (int, error) {
+	count := 0
+	err := withLock(ctx, pool, func(conn *pgx.Conn) error {
+		done, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(migrations, done); err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if steps > 0 && count == steps {
+				break
+			}
+			m := migrations[i]
+			if _, ok := done[m.Version]; !ok {
+				continue
+			}
+			if err := run(ctx, conn, m, false); err != nil {
+				return err
+			}
+			log.Printf("⬇️ Reverted migration %d_%s", m.Version, m.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status lists the migrations with the applied ones' rows (nil while pending)
+func Status(ctx context.Context, pool *pgxpool.Pool, migrations []*Migration) (map[int64]*Applied, error) {
+	var done map[int64]*Applied
+	err := withLock(ctx, pool, func(conn *pgx.Conn) error {
+		var err error
+		done, err = applied(ctx, conn)
+		return err
+	})
+	return done, err
+}
+
+// ErrPending is returned by Current when the database is behind the build
+var ErrPending = errors.New("database schema has pending migrations")
+
+// Current checks that every migration is applied (for services started without auto-migration)
+func Current(ctx context.Context, pool *pgxpool.Pool, migrations []*Migration) error {
+	done, err := Status(ctx, pool, migrations)
+	if err != nil {
+		return err
+	}
+	if err := verify(migrations, done); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if _, ok := done[m.Version]; !ok {
+			return fmt.Errorf("%w (first: %d_%s)", ErrPending, m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// Startup prepares the schema when a service starts: with auto, pending migrations are
+// applied; without, the service refuses to run against an outdated schema.
+func Startup(ctx context.Context, pool *pgxpool.Pool, migrations []*Migration, auto bool) error {
+	if !auto {
+		return Current(ctx, pool, migrations)
+	}
+	n, err := Up(ctx, pool, migrations)
+	if err == nil && n > 0 {
+		log.Printf("✅ Applied %d migration(s)", n)
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func file(body string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(body)}
+}
+
+func checksum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+func noop(ctx context.Context, tx pgx.Tx) error { return nil }
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_tenth.up.sql":     file("CREATE TABLE ten ();"),
+		"0010_tenth.down.sql":   file("DROP TABLE ten;"),
+		"0002_second.up.sql":    file("CREATE TABLE two ();"),
+		"0002_second.down.sql":  file("DROP TABLE two;"),
+		"0001_init.up.sql":      file("CREATE TABLE one ();"),
+		"0001_init.down.sql":    file("DROP TABLE one;"),
+		"README.md":             file("not a migration"),
+		"0003_Upper.up.sql":     file("ignored: names are lowercase"),
+		"seed/0004_x.up.sql":    file("ignored: not in the root"),
+		"0005_draft.sql":        file("ignored: no direction"),
+		"0006_backup.up.sql.gz": file("ignored"),
+	}
+	code := &Migration{Version: 7, Name: "backfill", Func: noop}
+
+	migrations, err := Load(fsys, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		version  int64
+		name     string
+		checksum string
+	}{
+		{1, "init", checksum("CREATE TABLE one ();")},
+		{2, "second", checksum("CREATE TABLE two ();")},
+		{7, "backfill", checksum("go:backfill")},
+		{10, "tenth", checksum("CREATE TABLE ten ();")},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("Load returned %d migrations, want %d", len(migrations), len(want))
+	}
+	for i, w := range want {
+		m := migrations[i]
+		if m.Version != w.version || m.Name != w.name || m.Checksum != w.checksum {
+			t.Errorf("migration %d = %d_%s (%s), want %d_%s (%s)", i, m.Version, m.Name, m.Checksum, w.version, w.name, w.checksum)
+		}
+	}
+	if migrations[0].Up != "CREATE TABLE one ();" || migrations[0].Down != "DROP TABLE one;" {
+		t.Errorf("0001_init scripts = %q / %q", migrations[0].Up, migrations[0].Down)
+	}
+	if migrations[2] != code || migrations[2].Func == nil {
+		t.Error("the Go migration wasn't kept as given")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		code    []*Migration
+		wantErr string
+	}{
+		{
+			name:    "missing down file",
+			fsys:    fstest.MapFS{"0001_init.up.sql": file("CREATE TABLE one ();")},
+			wantErr: "needs both an up and a down script",
+		},
+		{
+			name:    "missing up file",
+			fsys:    fstest.MapFS{"0001_init.down.sql": file("DROP TABLE one;")},
+			wantErr: "needs both an up and a down script",
+		},
+		{
+			name:    "empty down file",
+			fsys:    fstest.MapFS{"0001_init.up.sql": file("CREATE TABLE one ();"), "0001_init.down.sql": file("")},
+			wantErr: "needs both an up and a down script",
+		},
+		{
+			name:    "version zero",
+			fsys:    fstest.MapFS{"0000_init.up.sql": file("SELECT 1;"), "0000_init.down.sql": file("SELECT 1;")},
+			wantErr: "invalid version",
+		},
+		{
+			name: "one version, two names",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":    file("CREATE TABLE one ();"),
+				"0001_other.down.sql": file("DROP TABLE one;"),
+			},
+			wantErr: "has two names",
+		},
+		{
+			name: "Go migration on a file's version",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":   file("CREATE TABLE one ();"),
+				"0001_init.down.sql": file("DROP TABLE one;"),
+			},
+			code:    []*Migration{{Version: 1, Name: "backfill", Func: noop}},
+			wantErr: "has two migrations",
+		},
+		{
+			name:    "Go migration without Func",
+			fsys:    fstest.MapFS{},
+			code:    []*Migration{{Version: 2, Name: "backfill"}},
+			wantErr: "needs a version and only a Func",
+		},
+		{
+			name:    "Go migration with a script",
+			fsys:    fstest.MapFS{},
+			code:    []*Migration{{Version: 2, Name: "backfill", Up: "SELECT 1;", Func: noop}},
+			wantErr: "needs a version and only a Func",
+		},
+		{
+			name:    "Go migration without version",
+			fsys:    fstest.MapFS{},
+			code:    []*Migration{{Name: "backfill", Func: noop}},
+			wantErr: "needs a version and only a Func",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys, tt.code...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	migrations := []*Migration{
+		{Version: 1, Name: "init", Checksum: checksum("one")},
+		{Version: 2, Name: "second", Checksum: checksum("two")},
+	}
+	tests := []struct {
+		name    string
+		done    map[int64]*Applied
+		wantErr string
+	}{
+		{"nothing applied", map[int64]*Applied{}, ""},
+		{"some applied", map[int64]*Applied{1: {Version: 1, Name: "init", Checksum: checksum("one")}}, ""},
+		{"all applied", map[int64]*Applied{
+			1: {Version: 1, Name: "init", Checksum: checksum("one")},
+			2: {Version: 2, Name: "second", Checksum: checksum("two")},
+		}, ""},
+		{"checksum mismatch", map[int64]*Applied{
+			1: {Version: 1, Name: "init", Checksum: checksum("one")},
+			2: {Version: 2, Name: "second", Checksum: checksum("two, edited")},
+		}, "was changed after it was applied"},
+		{"unknown version", map[int64]*Applied{
+			1: {Version: 1, Name: "init", Checksum: checksum("one")},
+			3: {Version: 3, Name: "third", Checksum: checksum("three")},
+		}, "which this build doesn't know"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(migrations, tt.done)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("verify() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("verify() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// An edited up script gets a new checksum, which verify then reports
+func TestLoadChecksumMismatch(t *testing.T) {
+	load := func(up string) []*Migration {
+		t.Helper()
+		migrations, err := Load(fstest.MapFS{"0001_init.up.sql": file(up), "0001_init.down.sql": file("DROP TABLE one;")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return migrations
+	}
+	original := load("CREATE TABLE one ();")
+	done := map[int64]*Applied{1: {Version: 1, Name: "init", Checksum: original[0].Checksum}}
+
+	if err := verify(original, done); err != nil {
+		t.Errorf("verify() with the applied script = %v", err)
+	}
+	if err := verify(load("CREATE TABLE one (id INT);"), done); err == nil {
+		t.Error("verify() accepted an edited migration")
+	}
+}